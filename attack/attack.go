@@ -5,19 +5,36 @@ package attack
 import (
 	"fmt"
 	"sharding/config"
-	"sharding/event"
+	"sharding/lottery"
 	"sharding/node"
-	"sharding/shard"
 )
 
-// ExecuteAttack performs the specified attack based on the AttackType.
-// It appends attack-related logs to the simulation's AttackLogs.
-func ExecuteAttack(atkType config.AttackType, currentTime int64, nodes map[int]*node.Node, shards map[int]*shard.Shard, eq *event.EventQueue, cfg config.Config, attackLogs *[]string) {
+// ActiveAttack returns the attack the schedule runs at the given time: the one set at the
+// latest scheduled time not after it
+func ActiveAttack(schedule map[int64]config.AttackType, currentTime int64) config.AttackType {
+	active, since := config.NoAttack, int64(-1)
+	for t, atkType := range schedule {
+		if t <= currentTime && t > since {
+			active, since = atkType, t
+		}
+	}
+	return active
+}
+
+// ExecuteAttack logs the start or the end of an attack. The attack itself runs in the
+// lottery rounds while it is active.
+func ExecuteAttack(atkType config.AttackType, currentTime int64, targetShard int, attackLogs *[]string) {
 	switch atkType {
 	case config.GrindingAttack:
-		performGrindingAttack(currentTime, nodes, eq, cfg, attackLogs)
+		performGrindingAttack(currentTime, targetShard, attackLogs)
+	case config.ShardTakeoverAttack:
+		performShardTakeover(currentTime, targetShard, attackLogs)
+	case config.EclipseAttack:
+		performEclipseAttack(currentTime, targetShard, attackLogs)
+	case config.AdaptiveCorruptionAttack:
+		performAdaptiveCorruption(currentTime, attackLogs)
 	case config.NoAttack:
-		// stopGrindingAttack(currentTime, nodes, shards, eq, cfg, attackLogs)
+		stopAttack(currentTime, attackLogs)
 	default:
 		// Unknown attack type
 		log := fmt.Sprintf("[Attack] Unknown attack type: %v at time %d", atkType, currentTime)
@@ -25,33 +42,141 @@ func ExecuteAttack(atkType config.AttackType, currentTime int64, nodes map[int]*
 	}
 }
 
-// performGrindingAttack schedules additional LotteryEvents for malicious nodes to increase their shard assignments.
-func performGrindingAttack(currentTime int64, nodes map[int]*node.Node, eq *event.EventQueue, cfg config.Config, attackLogs *[]string) {
-	log := fmt.Sprintf("[Attack] Performing Grinding Attack at time %d", currentTime)
-	fmt.Println("Current Time:", currentTime)
+// performGrindingAttack logs the start of a grinding attack on the target shard
+func performGrindingAttack(currentTime int64, targetShard int, attackLogs *[]string) {
+	log := fmt.Sprintf("[Attack] Performing Grinding Attack on shard %d at time %d", targetShard, currentTime)
+	*attackLogs = append(*attackLogs, log)
+}
+
+// performShardTakeover logs the start of a takeover attempt on the target shard
+func performShardTakeover(currentTime int64, targetShard int, attackLogs *[]string) {
+	log := fmt.Sprintf("[Attack] Performing Shard Takeover on shard %d at time %d", targetShard, currentTime)
+	*attackLogs = append(*attackLogs, log)
+}
+
+// performEclipseAttack logs the start of an eclipse attack on the nodes rotating into the target shard
+func performEclipseAttack(currentTime int64, targetShard int, attackLogs *[]string) {
+	log := fmt.Sprintf("[Attack] Performing Eclipse Attack on nodes rotating into shard %d at time %d", targetShard, currentTime)
 	*attackLogs = append(*attackLogs, log)
+}
 
-	// for _, n := range nodes {
-	// 	if !n.IsHonest {
-	// 		// Schedule additional LotteryEvents based on the multiplier
-	// 		for i := 0; i < cfg.MaliciousNodeMultiplier; i++ {
-	// 			extraLottery := &event.Event{
-	// 				Timestamp: currentTime, // Immediate participation
-	// 				Type:      event.LotteryEvent,
-	// 				NodeID:    n.ID,
-	// 			}
-	// 			heap.Push(eq, extraLottery)
-	// 		}
-	// 		log := fmt.Sprintf("[Attack] Malicious Node %d scheduled %d additional LotteryEvents", n.ID, cfg.MaliciousNodeMultiplier)
-	// 		*attackLogs = append(*attackLogs, log)
-	// 	}
-	// }
-}
-
-// // stopGrindingAttack logs the termination of the Grinding Attack.
-// // Currently, no specific action is required to stop the attack.
-// // This function serves as a placeholder for potential future implementations.
-func stopGrindingAttack(currentTime int64, nodes map[int]*node.Node, shards map[int]*shard.Shard, eq *event.EventQueue, cfg config.Config, attackLogs *[]string) {
-	log := fmt.Sprintf("[Attack] Stopping Grinding Attack at time %d", currentTime)
+// performAdaptiveCorruption logs the start of an adaptive adversary corrupting nodes after they
+// landed in a shard
+func performAdaptiveCorruption(currentTime int64, attackLogs *[]string) {
+	log := fmt.Sprintf("[Attack] Performing Adaptive Corruption at time %d", currentTime)
 	*attackLogs = append(*attackLogs, log)
 }
+
+// stopAttack logs the end of an attack. Malicious nodes go back to taking every lottery win with
+// a single attempt per round.
+func stopAttack(currentTime int64, attackLogs *[]string) {
+	log := fmt.Sprintf("[Attack] Stopping attack at time %d", currentTime)
+	*attackLogs = append(*attackLogs, log)
+}
+
+// GrindingRound is the effort the adversary spent in one lottery round
+type GrindingRound struct {
+	Time     int64
+	Attempts int
+	Cost     float64
+	// Tickets into the target shard the adversary kept, and winning tickets it threw away
+	TargetWins    int
+	DiscardedWins int
+}
+
+// Grinder is an adversary that grinds lottery tickets for its malicious nodes. Every round a node
+// draws up to Resources * MaliciousNodeMultiplier tickets and only publishes a winning ticket
+// into the target shard, so a node in the target shard stays there. Every attempt costs
+// AttemptCost compute units out of a budget shared by all malicious nodes each round.
+type Grinder struct {
+	TargetShard         int
+	AttemptsPerResource int
+	AttemptCost         float64
+	WinProbability      float64
+	// Budget per round in compute units, 0 for no limit
+	Budget    float64
+	Rounds    []*GrindingRound
+	Spent     float64
+	Attempts  int
+	round     *GrindingRound
+	remaining float64
+}
+
+// NewGrinder sets up the grinder of cfg. An attempt cost of 0 or less, like the zero value of a
+// configuration that leaves it out, falls back to config.GrindingAttemptCost.
+func NewGrinder(cfg *config.Config) *Grinder {
+	cost := cfg.GrindingAttemptCost
+	if cost <= 0 {
+		cost = config.GrindingAttemptCost
+	}
+	return &Grinder{
+		TargetShard:         cfg.AttackTargetShard,
+		AttemptsPerResource: max(1, cfg.MaliciousNodeMultiplier),
+		AttemptCost:         cost,
+		WinProbability:      cfg.LotteryWinProbability,
+		Budget:              cfg.GrindingBudget,
+		Rounds:              make([]*GrindingRound, 0),
+	}
+}
+
+// StartRound opens a lottery round at time t with a fresh budget
+func (g *Grinder) StartRound(t int64) {
+	g.round = &GrindingRound{Time: t}
+	g.Rounds = append(g.Rounds, g.round)
+	g.remaining = g.Budget
+}
+
+// Grind draws tickets for a malicious node until one wins the target shard, the node runs out of
+// attempts or the round's budget runs out. It returns whether the node moves and where to.
+func (g *Grinder) Grind(n *node.Node, currentTime int64, numShards int) (bool, int) {
+	for i := 0; i < n.Resources*g.AttemptsPerResource; i++ {
+		if g.Budget > 0 && g.remaining < g.AttemptCost {
+			break
+		}
+		g.remaining -= g.AttemptCost
+		g.round.Attempts++
+		g.round.Cost += g.AttemptCost
+		g.Attempts++
+		g.Spent += g.AttemptCost
+
+		if !lottery.WinLottery(1, g.WinProbability) {
+			continue
+		}
+		shardID := lottery.AssignShard(n.ID, currentTime, numShards)
+		if shardID == g.TargetShard {
+			g.round.TargetWins++
+			// A node already in the target shard has nothing to gain from moving
+			return n.AssignedShard != g.TargetShard, shardID
+		}
+		g.round.DiscardedWins++
+	}
+	return false, -1
+}
+
+// Takeover concentrates malicious nodes in the target shard through ordinary rotation. A malicious
+// node in the target shard never gives up its seat, and one anywhere else only publishes a win
+// that lands in the target shard, while honest nodes keep rotating out of it.
+type Takeover struct {
+	TargetShard int
+	// Malicious nodes moved into the target shard and wins thrown away to stay in place
+	Joined    int
+	Discarded int
+}
+
+func NewTakeover(cfg *config.Config) *Takeover {
+	return &Takeover{TargetShard: cfg.AttackTargetShard}
+}
+
+// Decide filters the lottery outcome of a malicious node. It returns whether the node moves and
+// where to.
+func (t *Takeover) Decide(n *node.Node, won bool, shardID int) (bool, int) {
+	if !won {
+		return false, -1
+	}
+	if shardID != t.TargetShard || n.AssignedShard == t.TargetShard {
+		t.Discarded++
+		return false, -1
+	}
+	t.Joined++
+	return true, shardID
+}
@@ -0,0 +1,146 @@
+// attack/attack_test.go
+
+package attack
+
+import (
+	"sharding/config"
+	"sharding/node"
+	"testing"
+)
+
+// newGrinder opens the first round of a grinder against target with the given attempts per
+// resource unit, cost per attempt and budget per round
+func newGrinder(target, multiplier int, cost, budget float64) *Grinder {
+	g := NewGrinder(&config.Config{
+		AttackTargetShard:       target,
+		MaliciousNodeMultiplier: multiplier,
+		GrindingAttemptCost:     cost,
+		GrindingBudget:          budget,
+	})
+	g.StartRound(0)
+	return g
+}
+
+func TestActiveAttackFollowsTheSchedule(t *testing.T) {
+	schedule := map[int64]config.AttackType{
+		100: config.GrindingAttack,
+		300: config.NoAttack,
+		200: config.ShardTakeoverAttack,
+	}
+	for now, want := range map[int64]config.AttackType{
+		0:   config.NoAttack,
+		100: config.GrindingAttack,
+		199: config.GrindingAttack,
+		250: config.ShardTakeoverAttack,
+		300: config.NoAttack,
+		900: config.NoAttack,
+	} {
+		if got := ActiveAttack(schedule, now); got != want {
+			t.Errorf("time %d: %v, want %v", now, got, want)
+		}
+	}
+	if got := ActiveAttack(nil, 50); got != config.NoAttack {
+		t.Errorf("empty schedule runs %v", got)
+	}
+}
+
+func TestGrinderStopsAtTheBudget(t *testing.T) {
+	// A single shard never matches target shard 5, so every node grinds until it is cut off
+	g := newGrinder(5, 1, 3, 10)
+	a := &node.Node{ID: 1, Resources: 100, AssignedShard: -1}
+	b := &node.Node{ID: 2, Resources: 100, AssignedShard: -1}
+
+	g.Grind(a, 0, 1)
+	g.Grind(b, 0, 1)
+	if g.Attempts != 3 || g.Spent != 9 {
+		t.Errorf("%d attempts costing %v in a round with budget 10, want 3 costing 9", g.Attempts, g.Spent)
+	}
+
+	g.StartRound(1)
+	g.Grind(b, 1, 1)
+	if len(g.Rounds) != 2 || g.Rounds[1].Attempts != 3 || g.Attempts != 6 {
+		t.Errorf("next round made %d attempts, %d in total, want a fresh budget", g.Rounds[1].Attempts, g.Attempts)
+	}
+}
+
+func TestGrinderAttemptsScaleWithResources(t *testing.T) {
+	g := newGrinder(5, 4, 0.5, 0)
+	moved, shardID := g.Grind(&node.Node{Resources: 3, AssignedShard: -1}, 0, 1)
+	if moved || shardID != -1 {
+		t.Errorf("node moved to shard %d that is not the target", shardID)
+	}
+	if g.Attempts != 12 || g.Spent != 6 {
+		t.Errorf("%d attempts costing %v, want 3 resources times 4 attempts costing 6", g.Attempts, g.Spent)
+	}
+	if r := g.Rounds[0]; r.TargetWins != 0 || r.Attempts != 12 {
+		t.Errorf("round %+v", r)
+	}
+}
+
+func TestGrinderKeepsOnlyTargetWins(t *testing.T) {
+	// With one shard every winning ticket lands in target shard 0. 5000 attempts at the default
+	// win probability practically always win.
+	g := newGrinder(0, 1, 1, 0)
+	outside := &node.Node{ID: 1, Resources: 5_000, AssignedShard: -1}
+	if moved, shardID := g.Grind(outside, 0, 1); !moved || shardID != 0 {
+		t.Fatalf("node outside the target moved %v to shard %d", moved, shardID)
+	}
+	if g.Rounds[0].TargetWins != 1 || g.Attempts >= 5_000 {
+		t.Errorf("%d target wins after %d attempts, want the first win to end grinding", g.Rounds[0].TargetWins, g.Attempts)
+	}
+
+	// A node already in the target shard stays put
+	inside := &node.Node{ID: 2, Resources: 5_000, AssignedShard: 0}
+	if moved, _ := g.Grind(inside, 0, 1); moved {
+		t.Error("node in the target shard left it")
+	}
+
+	// Wins into any other shard are thrown away
+	other := newGrinder(5, 1, 1, 0)
+	other.Grind(outside, 0, 1)
+	if r := other.Rounds[0]; r.DiscardedWins == 0 || r.TargetWins != 0 {
+		t.Errorf("round %+v, want only discarded wins", r)
+	}
+}
+
+func TestTakeoverDecide(t *testing.T) {
+	takeover := NewTakeover(&config.Config{AttackTargetShard: 1})
+	outside := &node.Node{AssignedShard: 0}
+	inside := &node.Node{AssignedShard: 1}
+
+	steps := []struct {
+		n        *node.Node
+		won      bool
+		shardID  int
+		moves    bool
+		joined   int
+		discards int
+	}{
+		// Losing the lottery decides nothing
+		{outside, false, -1, false, 0, 0},
+		{outside, true, 1, true, 1, 0},
+		// A win elsewhere is withheld
+		{outside, true, 2, false, 1, 1},
+		// A node in the target shard never gives up its seat, not even for the target itself
+		{inside, true, 0, false, 1, 2},
+		{inside, true, 1, false, 1, 3},
+		{&node.Node{AssignedShard: -1}, true, 1, true, 2, 3},
+	}
+	for i, s := range steps {
+		moves, shardID := takeover.Decide(s.n, s.won, s.shardID)
+		if moves != s.moves || (moves && shardID != s.shardID) || (!moves && shardID != -1) {
+			t.Errorf("step %d: moves %v to %d", i, moves, shardID)
+		}
+		if takeover.Joined != s.joined || takeover.Discarded != s.discards {
+			t.Errorf("step %d: joined %d, discarded %d, want %d and %d", i, takeover.Joined, takeover.Discarded, s.joined, s.discards)
+		}
+	}
+}
+
+func TestGrinderFallsBackToDefaultCost(t *testing.T) {
+	for _, cost := range []float64{0, -2} {
+		if g := NewGrinder(&config.Config{GrindingAttemptCost: cost}); g.AttemptCost != config.GrindingAttemptCost {
+			t.Errorf("attempt cost %v became %v, want the default %v", cost, g.AttemptCost, config.GrindingAttemptCost)
+		}
+	}
+}
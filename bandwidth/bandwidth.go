@@ -0,0 +1,103 @@
+// bandwidth/bandwidth.go
+
+package bandwidth
+
+import (
+	"sharding/config"
+	"sync"
+)
+
+// Queue is one direction of a node's access link. Transfers go through it one after the other
+// in the order they were reserved.
+type Queue struct {
+	// Bandwidth in Mbps
+	Bandwidth float64
+	// Time in seconds at which the link finishes the transfers reserved so far
+	FreeAt float64
+	// Busy is the total time in seconds the link spent transmitting
+	Busy      float64
+	Transfers int
+}
+
+// transmissionTime returns the seconds the link needs to send size bytes
+func (q *Queue) transmissionTime(size int) float64 {
+	if q.Bandwidth <= 0 {
+		return 0
+	}
+	return float64(size) * 8.0 / (q.Bandwidth * 1000000.0)
+}
+
+// Transfer is a reserved transfer and how long it waited for both links
+type Transfer struct {
+	From    int
+	To      int
+	ShardID int
+	Size    int
+	// Wait in milliseconds before both links were free
+	Wait float64
+}
+
+// Links keeps the upload and download queue of every node. Downloads run in parallel
+// goroutines, so reservations are serialised.
+type Links struct {
+	Upload    map[int]*Queue
+	Download  map[int]*Queue
+	Transfers []Transfer
+	mu        sync.Mutex
+	upload    float64
+	download  float64
+}
+
+// NewLinks returns the access links of the network, or nil when transfers never contend
+func NewLinks(cfg *config.Config) *Links {
+	if !cfg.EnableLinkQueues {
+		return nil
+	}
+	l := &Links{
+		Upload:    make(map[int]*Queue),
+		Download:  make(map[int]*Queue),
+		Transfers: make([]Transfer, 0),
+		upload:    float64(cfg.NetworkBandwidth),
+		download:  float64(cfg.NetworkBandwidth),
+	}
+	if cfg.UploadBandwidth > 0 {
+		l.upload = float64(cfg.UploadBandwidth)
+	}
+	if cfg.DownloadBandwidth > 0 {
+		l.download = float64(cfg.DownloadBandwidth)
+	}
+	return l
+}
+
+// Reserve queues a transfer of size bytes from one node to another, starting at now in seconds.
+// The sender's upload link and the receiver's download link each send one transfer at a time and
+// the transfer completes when both are done with it. It returns the milliseconds the transfer
+// waits on top of its own delay.
+func (l *Links) Reserve(from, to int, shardID int, size int, now float64) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	up := l.queue(l.Upload, from, l.upload)
+	down := l.queue(l.Download, to, l.download)
+	upTime, downTime := up.transmissionTime(size), down.transmissionTime(size)
+	up.FreeAt = max(now, up.FreeAt) + upTime
+	down.FreeAt = max(now, down.FreeAt) + downTime
+	up.Busy += upTime
+	down.Busy += downTime
+	up.Transfers++
+	down.Transfers++
+
+	// The slower link's transmission is already part of the transfer's own delay
+	wait := (max(up.FreeAt, down.FreeAt) - now - max(upTime, downTime)) * 1000.0
+	l.Transfers = append(l.Transfers, Transfer{From: from, To: to, ShardID: shardID, Size: size, Wait: wait})
+	return wait
+}
+
+func (l *Links) queue(queues map[int]*Queue, id int, bandwidth float64) *Queue {
+	q, exists := queues[id]
+	if !exists {
+		q = &Queue{Bandwidth: bandwidth}
+		queues[id] = q
+	}
+	return q
+}
@@ -0,0 +1,97 @@
+// beacon/beacon.go
+
+package beacon
+
+import (
+	"math/rand"
+	"sharding/block"
+	"sharding/node"
+	"sort"
+)
+
+// PendingHeader is a shard block header on its way into the beacon chain
+type PendingHeader struct {
+	Header    *block.BlockHeader
+	ArrivedAt float64
+}
+
+// Chain is the beacon chain. Its committee takes turns producing beacon blocks, each of which
+// crosslinks the shard headers that reached the committee since the previous beacon block.
+type Chain struct {
+	Committee []*node.Node
+	Blocks    []*block.BeaconBlock
+	// MaxCrosslinks caps the headers per beacon block, 0 for no limit
+	MaxCrosslinks int
+	pending       []*PendingHeader
+}
+
+// NewChain draws a committee of committeeSize nodes out of nodes
+func NewChain(nodes []*node.Node, committeeSize int, maxCrosslinks int) *Chain {
+	candidates := make([]*node.Node, len(nodes))
+	copy(candidates, nodes)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	committee := candidates[:min(committeeSize, len(candidates))]
+
+	return &Chain{
+		Committee:     committee,
+		Blocks:        make([]*block.BeaconBlock, 0),
+		MaxCrosslinks: maxCrosslinks,
+		pending:       make([]*PendingHeader, 0),
+	}
+}
+
+// SubmitHeader queues a shard header that reaches the beacon committee at arrivedAt
+func (c *Chain) SubmitHeader(header *block.BlockHeader, arrivedAt float64) {
+	c.pending = append(c.pending, &PendingHeader{Header: header, ArrivedAt: arrivedAt})
+}
+
+// Producer returns the committee member producing the beacon block at the given height
+func (c *Chain) Producer(height int) *node.Node {
+	if len(c.Committee) == 0 {
+		return nil
+	}
+	return c.Committee[height%len(c.Committee)]
+}
+
+// ProduceBlock builds the next beacon block at time now, crosslinking the headers that arrived
+// by then in arrival order. It returns nil when the committee is empty.
+func (c *Chain) ProduceBlock(now int64) *block.BeaconBlock {
+	height := len(c.Blocks) + 1
+	producer := c.Producer(height)
+	if producer == nil {
+		return nil
+	}
+
+	sort.SliceStable(c.pending, func(i, j int) bool {
+		return c.pending[i].ArrivedAt < c.pending[j].ArrivedAt
+	})
+	crosslinks := make([]*block.BlockHeader, 0)
+	remaining := make([]*PendingHeader, 0)
+	for _, p := range c.pending {
+		if p.ArrivedAt <= float64(now) && (c.MaxCrosslinks <= 0 || len(crosslinks) < c.MaxCrosslinks) {
+			crosslinks = append(crosslinks, p.Header)
+		} else {
+			remaining = append(remaining, p)
+		}
+	}
+	c.pending = remaining
+
+	beaconBlock := &block.BeaconBlock{
+		ID:         height,
+		ProducerID: producer.ID,
+		Timestamp:  now,
+		Crosslinks: crosslinks,
+	}
+	c.Blocks = append(c.Blocks, beaconBlock)
+	return beaconBlock
+}
+
+// PendingHeaders returns the number of shard headers not crosslinked yet
+func (c *Chain) PendingHeaders() int {
+	return len(c.pending)
+}
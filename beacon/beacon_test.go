@@ -0,0 +1,48 @@
+// beacon/beacon_test.go
+
+package beacon
+
+import (
+	"sharding/block"
+	"sharding/node"
+	"testing"
+)
+
+func TestCommitteeTakesTurns(t *testing.T) {
+	nodes := make([]*node.Node, 5)
+	for i := range nodes {
+		nodes[i] = &node.Node{ID: i}
+	}
+	c := NewChain(nodes, 3, 0)
+	if len(c.Committee) != 3 {
+		t.Fatalf("committee of %d, want 3", len(c.Committee))
+	}
+	for height := 0; height < 6; height++ {
+		if c.Producer(height) != c.Committee[height%3] {
+			t.Errorf("height %d produced out of turn", height)
+		}
+	}
+	if empty := NewChain(nil, 3, 0); empty.ProduceBlock(10) != nil {
+		t.Error("beacon block without a committee")
+	}
+}
+
+func TestCrosslinksFollowArrival(t *testing.T) {
+	c := NewChain([]*node.Node{{ID: 1}}, 1, 2)
+	for i, arrival := range []float64{9.5, 3, 12, 5} {
+		c.SubmitHeader(&block.BlockHeader{ID: i}, arrival)
+	}
+
+	// Three headers arrived by time 10, the cap lets two of them in, oldest first
+	first := c.ProduceBlock(10)
+	if len(first.Crosslinks) != 2 || first.Crosslinks[0].ID != 1 || first.Crosslinks[1].ID != 3 {
+		t.Fatalf("first beacon block crosslinks %v", first.Crosslinks)
+	}
+	second := c.ProduceBlock(11)
+	if len(second.Crosslinks) != 1 || second.Crosslinks[0].ID != 0 || second.ID != 2 {
+		t.Errorf("second beacon block %d crosslinks %v", second.ID, second.Crosslinks)
+	}
+	if c.PendingHeaders() != 1 {
+		t.Errorf("%d headers pending, want the one arriving at 12", c.PendingHeaders())
+	}
+}
@@ -1,20 +1,65 @@
 package block
 
 type Block struct {
+	ID            int
+	Hash          int
+	ShardID       int
+	ProducerID    int
+	ProducerStake int
+	PreviousHash  int
+	Timestamp     int64
+	IsMalicious   bool
+	// Withheld is set when the producer broadcasts the header but keeps the body back
+	Withheld bool
+	// Transactions taken from the shard mempool, nil when blocks are assumed to be full
+	Transactions []*Transaction
+	// Cross-shard receipts credited by this block
+	Receipts []*Receipt
+}
+
+// Transaction is a single transaction submitted to a shard, CreatedAt is its arrival time.
+// A cross-shard transaction is debited in ShardID and credited in every shard of TargetShards.
+type Transaction struct {
 	ID           int
 	ShardID      int
-	ProducerID   int
-	PreviousHash int
-	Timestamp    int64
-	IsMalicious  bool
+	TargetShards []int
+	CreatedAt    float64
+}
+
+// Receipt proves that a source shard block debited a cross-shard transaction, so the target
+// shard can credit it once the source block header has reached it
+type Receipt struct {
+	Tx              *Transaction
+	SourceShardID   int
+	SourceBlockHash int
+	TargetShardID   int
+	DebitedAt       int64
+	AvailableAt     float64
+}
+
+// BeaconBlock is a block of the beacon chain. Its crosslinks carry the shard block headers
+// that reached the beacon chain since the previous beacon block.
+type BeaconBlock struct {
+	ID         int
+	ProducerID int
+	Timestamp  int64
+	Crosslinks []*BlockHeader
+}
+
+// Size returns the beacon block size in bytes: its own header plus one header per crosslink
+func (b *BeaconBlock) Size(headerSize int) int {
+	return headerSize * (1 + len(b.Crosslinks))
 }
 
 type BlockHeader struct {
 	ID         int
+	Hash       int
 	ShardID    int
 	ProducerID int
 	PreviousID int
 	Timestamp  int64
+	// Hash of the parent block, which tells apart the links of competing blocks at one height
+	PreviousHash int
 }
 
 func NewBlockHeader(id, shardID, producerID, previousID int, timestamp int64) *BlockHeader {
@@ -27,13 +72,74 @@ func NewBlockHeader(id, shardID, producerID, previousID int, timestamp int64) *B
 	}
 }
 
-func NewBlock(id, shardID, producerID, previousHash int, timestamp int64) *Block {
+// NewBlock returns a block of the given hash, which must be unique within the simulation and
+// above 0, the hash of the genesis blocks
+func NewBlock(id, hash, shardID, producerID, previousHash int, timestamp int64) *Block {
+	return &Block{
+		ID:            id,
+		Hash:          hash,
+		ShardID:       shardID,
+		ProducerID:    producerID,
+		ProducerStake: 1,
+		PreviousHash:  previousHash,
+		Timestamp:     timestamp,
+		IsMalicious:   false, // Default to false
+	}
+}
+
+// NewGenesisBlock returns the genesis block of a shard, which has ID and hash 0
+func NewGenesisBlock(shardID int) *Block {
 	return &Block{
-		ID:           id,
-		ShardID:      shardID,
-		ProducerID:   producerID,
-		PreviousHash: previousHash,
-		Timestamp:    timestamp,
-		IsMalicious:  false, // Default to false
+		ID:         0,
+		Hash:       0,
+		ShardID:    shardID,
+		ProducerID: -1,
+	}
+}
+
+// ExecutionReceiptHeader commits to the execution result of a block and is gossiped network-wide
+type ExecutionReceiptHeader struct {
+	BlockID    int
+	BlockHash  int
+	ShardID    int
+	ProducerID int
+	Timestamp  int64
+}
+
+// ExecutionReceiptBody carries the full execution result and is sent to the verifiers of the shard
+type ExecutionReceiptBody struct {
+	Header *ExecutionReceiptHeader
+}
+
+func NewExecutionReceipt(blk *Block) (*ExecutionReceiptHeader, *ExecutionReceiptBody) {
+	header := &ExecutionReceiptHeader{
+		BlockID:    blk.ID,
+		BlockHash:  blk.Hash,
+		ShardID:    blk.ShardID,
+		ProducerID: blk.ProducerID,
+		Timestamp:  blk.Timestamp,
+	}
+	return header, &ExecutionReceiptBody{Header: header}
+}
+
+// FraudProof shows that a block is invalid. It is challenged at Timestamp, and nodes only
+// revert the block if that falls within the challenge period of the block.
+type FraudProof struct {
+	BlockID        int
+	BlockHash      int
+	ShardID        int
+	ProverID       int
+	BlockTimestamp int64
+	Timestamp      float64
+}
+
+func NewFraudProof(blk *Block, proverID int, timestamp float64) *FraudProof {
+	return &FraudProof{
+		BlockID:        blk.ID,
+		BlockHash:      blk.Hash,
+		ShardID:        blk.ShardID,
+		ProverID:       proverID,
+		BlockTimestamp: blk.Timestamp,
+		Timestamp:      timestamp,
 	}
 }
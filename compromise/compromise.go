@@ -0,0 +1,137 @@
+// compromise/compromise.go
+
+package compromise
+
+import "sharding/config"
+
+// Interval is a stretch of time during which the malicious share of a shard stayed above the
+// threshold. End is -1 while the shard is still compromised.
+type Interval struct {
+	ShardID   int
+	Start     int64
+	End       int64
+	PeakShare float64
+}
+
+// Duration returns how long the interval lasted, up to now while it is still open
+func (i *Interval) Duration(now int64) int64 {
+	if i.End < 0 {
+		return now - i.Start
+	}
+	return i.End - i.Start
+}
+
+// Tracker records, for every shard, the intervals during which its malicious share exceeded
+// Threshold
+type Tracker struct {
+	Threshold float64
+	// Shards with fewer members, like those still filling up after bootstrap, are not observed.
+	// 0 observes every shard with at least one member.
+	MinSize   int
+	Intervals map[int][]*Interval
+	// Highest malicious share observed per shard
+	PeakShare map[int]float64
+	// Observations per shard, those above the threshold and how many had each shard size
+	Observations map[int]int
+	Exceeded     map[int]int
+	Sizes        map[int]map[int]int
+	open         map[int]*Interval
+}
+
+// NewTracker returns a tracker for the given threshold and minimum shard size. Thresholds outside
+// (0, 1), like the zero value of a configuration that leaves it out, fall back to
+// config.CompromiseThreshold.
+func NewTracker(threshold float64, minSize int) *Tracker {
+	if threshold <= 0 || threshold >= 1 {
+		threshold = config.CompromiseThreshold
+	}
+	return &Tracker{
+		Threshold:    threshold,
+		MinSize:      max(0, minSize),
+		Intervals:    make(map[int][]*Interval),
+		PeakShare:    make(map[int]float64),
+		Observations: make(map[int]int),
+		Exceeded:     make(map[int]int),
+		Sizes:        make(map[int]map[int]int),
+		open:         make(map[int]*Interval),
+	}
+}
+
+// Observe records the malicious members of a shard at time now, opening an interval when their
+// share rises above the threshold and closing it when it falls back. A shard below MinSize members
+// is left out, as a handful of nodes says nothing about the share of a full shard.
+func (t *Tracker) Observe(shardID, malicious, members int, now int64) {
+	if !t.tooSmall(members) {
+		t.Observations[shardID]++
+		if t.Sizes[shardID] == nil {
+			t.Sizes[shardID] = make(map[int]int)
+		}
+		t.Sizes[shardID][members]++
+		if float64(malicious)/float64(members) > t.Threshold {
+			t.Exceeded[shardID]++
+		}
+	}
+	t.Update(shardID, malicious, members, now)
+}
+
+// Update opens or closes the compromised interval of a shard when its malicious members change
+// between two observations. It leaves the observation counts behind Frequency alone. A shard
+// shrinking below MinSize members no longer counts as compromised.
+func (t *Tracker) Update(shardID, malicious, members int, now int64) {
+	if t.tooSmall(members) {
+		t.close(shardID, now)
+		return
+	}
+	share := float64(malicious) / float64(members)
+	t.PeakShare[shardID] = max(t.PeakShare[shardID], share)
+	interval, compromised := t.open[shardID]
+	switch {
+	case share > t.Threshold && compromised:
+		interval.PeakShare = max(interval.PeakShare, share)
+	case share > t.Threshold:
+		interval = &Interval{ShardID: shardID, Start: now, End: -1, PeakShare: share}
+		t.open[shardID] = interval
+		t.Intervals[shardID] = append(t.Intervals[shardID], interval)
+	case compromised:
+		t.close(shardID, now)
+	}
+}
+
+// close ends the open interval of a shard at time now, if there is one
+func (t *Tracker) close(shardID int, now int64) {
+	if interval, compromised := t.open[shardID]; compromised {
+		interval.End = now
+		delete(t.open, shardID)
+	}
+}
+
+// tooSmall reports whether a shard has too few members to judge its malicious share
+func (t *Tracker) tooSmall(members int) bool {
+	return members < max(1, t.MinSize)
+}
+
+// Compromised reports whether the malicious share of a shard is above the threshold right now
+func (t *Tracker) Compromised(shardID int) bool {
+	_, compromised := t.open[shardID]
+	return compromised
+}
+
+// MeanSize returns the mean number of members a shard had over its observations
+func (t *Tracker) MeanSize(shardID int) float64 {
+	if t.Observations[shardID] == 0 {
+		return 0
+	}
+	total := 0
+	for size, count := range t.Sizes[shardID] {
+		total += size * count
+	}
+	return float64(total) / float64(t.Observations[shardID])
+}
+
+// Frequency returns the share of a shard's observations above the threshold
+func (t *Tracker) Frequency(shardID int) float64 {
+	if t.Observations[shardID] == 0 {
+		return 0
+	}
+	return float64(t.Exceeded[shardID]) / float64(t.Observations[shardID])
+}
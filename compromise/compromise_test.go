@@ -0,0 +1,93 @@
+// compromise/compromise_test.go
+
+package compromise
+
+import (
+	"sharding/config"
+	"testing"
+)
+
+func TestIntervalsFollowTheThreshold(t *testing.T) {
+	tr := NewTracker(0.5, 0)
+	tr.Observe(0, 4, 10, 0)
+	tr.Observe(0, 6, 10, 10)
+	tr.Observe(0, 8, 10, 20)
+	tr.Observe(0, 5, 10, 30)
+	tr.Observe(0, 7, 10, 40)
+
+	got := tr.Intervals[0]
+	if len(got) != 2 {
+		t.Fatalf("got %d intervals, want 2", len(got))
+	}
+	if got[0].Start != 10 || got[0].End != 30 || got[0].PeakShare != 0.8 {
+		t.Errorf("first interval = %+v, want 10 to 30 peaking at 0.8", *got[0])
+	}
+	// A share of exactly the threshold is not above it
+	if got[1].Start != 40 || got[1].End != -1 || !tr.Compromised(0) {
+		t.Errorf("second interval = %+v, want open since 40", *got[1])
+	}
+	if d := got[1].Duration(55); d != 15 {
+		t.Errorf("open interval lasted %d at 55, want 15", d)
+	}
+	if tr.PeakShare[0] != 0.8 || tr.Frequency(0) != 0.6 {
+		t.Errorf("peak %v and frequency %v, want 0.8 and 0.6", tr.PeakShare[0], tr.Frequency(0))
+	}
+}
+
+func TestShrinkingBelowMinSizeClosesTheInterval(t *testing.T) {
+	tr := NewTracker(0.5, 10)
+	tr.Observe(0, 6, 4, 0)
+	if tr.Compromised(0) || tr.Observations[0] != 0 {
+		t.Fatal("a shard below the minimum size was observed")
+	}
+
+	tr.Observe(0, 7, 12, 10)
+	tr.Observe(0, 5, 6, 25)
+	if tr.Compromised(0) {
+		t.Fatal("shard stayed compromised after shrinking below the minimum size")
+	}
+	if got := tr.Intervals[0]; len(got) != 1 || got[0].End != 25 {
+		t.Fatalf("intervals = %v, want one closed at 25", got)
+	}
+	if tr.Observations[0] != 1 || tr.MeanSize(0) != 12 {
+		t.Errorf("%d observations of mean size %v, want only the one of 12 members", tr.Observations[0], tr.MeanSize(0))
+	}
+
+	// Update closes the interval the same way
+	tr.Update(0, 9, 12, 30)
+	tr.Update(0, 9, 9, 35)
+	if tr.Compromised(0) || tr.Intervals[0][1].End != 35 {
+		t.Errorf("Update left the interval %+v open", *tr.Intervals[0][1])
+	}
+}
+
+func TestUpdateLeavesObservationsAlone(t *testing.T) {
+	tr := NewTracker(0.5, 0)
+	tr.Observe(1, 2, 10, 0)
+	tr.Update(1, 9, 10, 5)
+	if !tr.Compromised(1) || tr.PeakShare[1] != 0.9 {
+		t.Fatal("Update did not open an interval")
+	}
+	if tr.Observations[1] != 1 || tr.Frequency(1) != 0 {
+		t.Errorf("Update changed the observations: %d at frequency %v", tr.Observations[1], tr.Frequency(1))
+	}
+}
+
+func TestNewTrackerDefaults(t *testing.T) {
+	for _, threshold := range []float64{0, -0.2, 1, 1.5} {
+		if got := NewTracker(threshold, 0).Threshold; got != config.CompromiseThreshold {
+			t.Errorf("threshold %v became %v, want %v", threshold, got, config.CompromiseThreshold)
+		}
+	}
+
+	// Without a minimum size only empty shards are left out
+	tr := NewTracker(0.5, 0)
+	tr.Observe(0, 0, 0, 0)
+	tr.Observe(0, 1, 1, 5)
+	if tr.Observations[0] != 1 || !tr.Compromised(0) {
+		t.Errorf("%d observations, compromised %v, want a single-member shard to count", tr.Observations[0], tr.Compromised(0))
+	}
+	if tr.MeanSize(3) != 0 || tr.Frequency(3) != 0 {
+		t.Error("a shard never observed has a size or frequency")
+	}
+}
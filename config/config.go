@@ -9,8 +9,563 @@ const (
 const (
 	NoAttack AttackType = iota
 	GrindingAttack
+	ShardTakeoverAttack
+	EclipseAttack
+	AdaptiveCorruptionAttack
 )
 
+// ParseAttackType maps an attack name to its type, falling back to grinding for unknown names
+func ParseAttackType(name string) AttackType {
+	switch name {
+	case "none":
+		return NoAttack
+	case "takeover":
+		return ShardTakeoverAttack
+	case "eclipse":
+		return EclipseAttack
+	case "adaptive":
+		return AdaptiveCorruptionAttack
+	default:
+		return GrindingAttack
+	}
+}
+
+func (a AttackType) String() string {
+	switch a {
+	case NoAttack:
+		return "none"
+	case ShardTakeoverAttack:
+		return "takeover"
+	case EclipseAttack:
+		return "eclipse"
+	case AdaptiveCorruptionAttack:
+		return "adaptive"
+	default:
+		return "grinding"
+	}
+}
+
+// StakeDistributionType decides how much stake every node gets when it is created. Stake weights
+// stake-weighted producer selection.
+type StakeDistributionType int
+
+const (
+	// UniformStake gives every node one unit of stake
+	UniformStake StakeDistributionType = iota
+	// ParetoStake draws stakes from a Pareto distribution of tail index StakeParetoShape, from 1 up to MaxStake
+	ParetoStake
+	// ListStake hands out StakeList in node ID order, cycling through it
+	ListStake
+)
+
+// ParseStakeDistribution maps the API name of a stake distribution to its value.
+// Unknown or empty names fall back to UniformStake.
+func ParseStakeDistribution(name string) StakeDistributionType {
+	switch name {
+	case "pareto":
+		return ParetoStake
+	case "list":
+		return ListStake
+	default:
+		return UniformStake
+	}
+}
+
+func (d StakeDistributionType) String() string {
+	switch d {
+	case ParetoStake:
+		return "pareto"
+	case ListStake:
+		return "list"
+	default:
+		return "uniform"
+	}
+}
+
+// ProducerSelectionRule decides which node produces the next block of a shard
+type ProducerSelectionRule int
+
+const (
+	LotteryWinnerOnly ProducerSelectionRule = iota
+	RoundRobin
+	StakeWeighted
+	VRFLowestTicket
+)
+
+// ParseProducerSelectionRule maps the API name of a selection rule to its value.
+// Unknown or empty names fall back to LotteryWinnerOnly.
+func ParseProducerSelectionRule(name string) ProducerSelectionRule {
+	switch name {
+	case "round-robin":
+		return RoundRobin
+	case "stake-weighted":
+		return StakeWeighted
+	case "vrf-lowest-ticket":
+		return VRFLowestTicket
+	default:
+		return LotteryWinnerOnly
+	}
+}
+
+func (r ProducerSelectionRule) String() string {
+	switch r {
+	case RoundRobin:
+		return "round-robin"
+	case StakeWeighted:
+		return "stake-weighted"
+	case VRFLowestTicket:
+		return "vrf-lowest-ticket"
+	default:
+		return "lottery-winner-only"
+	}
+}
+
+// ForkChoiceRule decides which branch of a shard's block tree a node treats as canonical
+type ForkChoiceRule int
+
+const (
+	LongestChain ForkChoiceRule = iota
+	GHOST
+	HeaviestStake
+)
+
+// ParseForkChoiceRule maps the API name of a fork-choice rule to its value.
+// Unknown or empty names fall back to LongestChain.
+func ParseForkChoiceRule(name string) ForkChoiceRule {
+	switch name {
+	case "ghost":
+		return GHOST
+	case "heaviest-stake":
+		return HeaviestStake
+	default:
+		return LongestChain
+	}
+}
+
+func (r ForkChoiceRule) String() string {
+	switch r {
+	case GHOST:
+		return "ghost"
+	case HeaviestStake:
+		return "heaviest-stake"
+	default:
+		return "longest-chain"
+	}
+}
+
+// WorkloadType decides how transactions arrive at the shards' mempools
+type WorkloadType int
+
+const (
+	// SaturatedWorkload assumes every block is full, without generating transactions
+	SaturatedWorkload WorkloadType = iota
+	ConstantWorkload
+	PoissonWorkload
+	BurstyWorkload
+	TraceWorkload
+)
+
+// ParseWorkloadType maps the API name of a workload to its value.
+// Unknown or empty names fall back to SaturatedWorkload.
+func ParseWorkloadType(name string) WorkloadType {
+	switch name {
+	case "constant":
+		return ConstantWorkload
+	case "poisson":
+		return PoissonWorkload
+	case "bursty":
+		return BurstyWorkload
+	case "trace":
+		return TraceWorkload
+	default:
+		return SaturatedWorkload
+	}
+}
+
+func (w WorkloadType) String() string {
+	switch w {
+	case ConstantWorkload:
+		return "constant"
+	case PoissonWorkload:
+		return "poisson"
+	case BurstyWorkload:
+		return "bursty"
+	case TraceWorkload:
+		return "trace"
+	default:
+		return "saturated"
+	}
+}
+
+// FinalityRuleType decides when a shard block becomes final
+type FinalityRuleType int
+
+const (
+	NoFinality FinalityRuleType = iota
+	KConfirmations
+	BFTCommittee
+	BeaconFinality
+)
+
+// ParseFinalityRule maps the API name of a finality rule to its value.
+// Unknown or empty names fall back to NoFinality.
+func ParseFinalityRule(name string) FinalityRuleType {
+	switch name {
+	case "k-confirmations":
+		return KConfirmations
+	case "bft-committee":
+		return BFTCommittee
+	case "beacon":
+		return BeaconFinality
+	default:
+		return NoFinality
+	}
+}
+
+func (r FinalityRuleType) String() string {
+	switch r {
+	case KConfirmations:
+		return "k-confirmations"
+	case BFTCommittee:
+		return "bft-committee"
+	case BeaconFinality:
+		return "beacon"
+	default:
+		return "none"
+	}
+}
+
+// StateSyncStrategy decides what a node downloads to catch up with the state of a shard it joins
+type StateSyncStrategy int
+
+const (
+	// NoStateSync lets rotated nodes produce right away, only downloading the latest blocks
+	NoStateSync StateSyncStrategy = iota
+	FullStateSync
+	SnapshotSync
+	StatelessSync
+)
+
+// ParseStateSyncStrategy maps the API name of a state sync strategy to its value.
+// Unknown or empty names fall back to NoStateSync.
+func ParseStateSyncStrategy(name string) StateSyncStrategy {
+	switch name {
+	case "full":
+		return FullStateSync
+	case "snapshot":
+		return SnapshotSync
+	case "stateless":
+		return StatelessSync
+	default:
+		return NoStateSync
+	}
+}
+
+func (s StateSyncStrategy) String() string {
+	switch s {
+	case FullStateSync:
+		return "full"
+	case SnapshotSync:
+		return "snapshot"
+	case StatelessSync:
+		return "stateless"
+	default:
+		return "none"
+	}
+}
+
+// TopologyType decides how the peer graph that blocks are gossiped over is generated
+type TopologyType int
+
+const (
+	// NoTopology estimates propagation from the number of gossip hops instead of simulating them
+	NoTopology TopologyType = iota
+	RandomRegular
+	SmallWorld
+	ScaleFree
+)
+
+// ParseTopology maps the API name of a topology to its value.
+// Unknown or empty names fall back to NoTopology.
+func ParseTopology(name string) TopologyType {
+	switch name {
+	case "random-regular":
+		return RandomRegular
+	case "small-world":
+		return SmallWorld
+	case "scale-free":
+		return ScaleFree
+	default:
+		return NoTopology
+	}
+}
+
+func (t TopologyType) String() string {
+	switch t {
+	case RandomRegular:
+		return "random-regular"
+	case SmallWorld:
+		return "small-world"
+	case ScaleFree:
+		return "scale-free"
+	default:
+		return "none"
+	}
+}
+
+// DelayDistribution decides how the latency of a network hop is drawn
+type DelayDistribution int
+
+const (
+	NormalDelay DelayDistribution = iota
+	LogNormalDelay
+	ParetoDelay
+	ShiftedExponentialDelay
+	// EmpiricalDelay draws from the RTT samples of DelayTraceFile
+	EmpiricalDelay
+)
+
+// ParseDelayDistribution maps the API name of a delay distribution to its value.
+// Unknown or empty names fall back to NormalDelay.
+func ParseDelayDistribution(name string) DelayDistribution {
+	switch name {
+	case "log-normal":
+		return LogNormalDelay
+	case "pareto":
+		return ParetoDelay
+	case "shifted-exponential":
+		return ShiftedExponentialDelay
+	case "empirical":
+		return EmpiricalDelay
+	default:
+		return NormalDelay
+	}
+}
+
+func (d DelayDistribution) String() string {
+	switch d {
+	case LogNormalDelay:
+		return "log-normal"
+	case ParetoDelay:
+		return "pareto"
+	case ShiftedExponentialDelay:
+		return "shifted-exponential"
+	case EmpiricalDelay:
+		return "empirical"
+	default:
+		return "normal"
+	}
+}
+
+// PartitionSplit decides which nodes end up on the isolated side of a network partition
+type PartitionSplit int
+
+const (
+	// SplitByShard isolates the members and operators of the listed shards
+	SplitByShard PartitionSplit = iota
+	// SplitByRegion isolates the nodes placed in the listed regions
+	SplitByRegion
+	// SplitByList isolates the listed node IDs
+	SplitByList
+	// SplitByFraction isolates a random fraction of all nodes and operators
+	SplitByFraction
+)
+
+// ParsePartitionSplit maps the API name of a partition split to its value.
+// Unknown or empty names fall back to SplitByShard.
+func ParsePartitionSplit(name string) PartitionSplit {
+	switch name {
+	case "region":
+		return SplitByRegion
+	case "list":
+		return SplitByList
+	case "random":
+		return SplitByFraction
+	default:
+		return SplitByShard
+	}
+}
+
+func (s PartitionSplit) String() string {
+	switch s {
+	case SplitByRegion:
+		return "region"
+	case SplitByList:
+		return "list"
+	case SplitByFraction:
+		return "random"
+	default:
+		return "shard"
+	}
+}
+
+// PartitionModeType decides what happens to messages sent across a partition
+type PartitionModeType int
+
+const (
+	// DropAcrossPartition loses every message sent across the partition
+	DropAcrossPartition PartitionModeType = iota
+	// HoldAcrossPartition delivers messages sent across the partition once it heals
+	HoldAcrossPartition
+)
+
+// ParsePartitionMode maps the API name of a partition mode to its value.
+// Unknown or empty names fall back to DropAcrossPartition.
+func ParsePartitionMode(name string) PartitionModeType {
+	if name == "hold" {
+		return HoldAcrossPartition
+	}
+	return DropAcrossPartition
+}
+
+func (m PartitionModeType) String() string {
+	if m == HoldAcrossPartition {
+		return "hold"
+	}
+	return "drop"
+}
+
+// WithholdingMode decides who malicious producers still hand the bodies of their blocks to.
+// Headers are always broadcast, so the network learns about blocks it cannot download.
+type WithholdingMode int
+
+const (
+	// NoWithholding publishes every block body
+	NoWithholding WithholdingMode = iota
+	// WithholdFromAll keeps block bodies to the producer
+	WithholdFromAll
+	// WithholdForColluders only hands block bodies to other malicious nodes
+	WithholdForColluders
+)
+
+// ParseWithholdingMode maps the API name of a withholding mode to its value.
+// Unknown or empty names fall back to NoWithholding.
+func ParseWithholdingMode(name string) WithholdingMode {
+	switch name {
+	case "all":
+		return WithholdFromAll
+	case "colluders":
+		return WithholdForColluders
+	default:
+		return NoWithholding
+	}
+}
+
+func (m WithholdingMode) String() string {
+	switch m {
+	case WithholdFromAll:
+		return "all"
+	case WithholdForColluders:
+		return "colluders"
+	default:
+		return "none"
+	}
+}
+
+// CorruptionTargetType decides which shard an adaptive adversary corrupts nodes in
+type CorruptionTargetType int
+
+const (
+	// TargetClosestShard goes for the shard that needs the fewest corruptions to cross the
+	// compromise threshold
+	TargetClosestShard CorruptionTargetType = iota
+	// TargetFixedShard always goes for AttackTargetShard
+	TargetFixedShard
+)
+
+// ParseCorruptionTarget maps the API name of a targeting rule to its value.
+// Unknown or empty names fall back to TargetClosestShard.
+func ParseCorruptionTarget(name string) CorruptionTargetType {
+	switch name {
+	case "fixed":
+		return TargetFixedShard
+	default:
+		return TargetClosestShard
+	}
+}
+
+func (t CorruptionTargetType) String() string {
+	switch t {
+	case TargetFixedShard:
+		return "fixed"
+	default:
+		return "closest"
+	}
+}
+
+// ServingStrategyType is how a node answers block download requests. Honest nodes always serve
+// honestly, malicious ones follow the strategy they were assigned.
+type ServingStrategyType int
+
+const (
+	// ServeDelayed answers after an extra download timeout
+	ServeDelayed ServingStrategyType = iota
+	// ServeHonestly answers like an honest node
+	ServeHonestly
+	// ServeRefuse never answers, so the requester times out
+	ServeRefuse
+	// ServeSlow sends the block at SlowServeFraction of the bandwidth
+	ServeSlow
+	// ServeCorrupt sends a corrupt block the requester only detects once it is fully downloaded
+	ServeCorrupt
+	// ServeStale sends an older block than the one requested
+	ServeStale
+	// ServeMixed picks one of the strategies above at random for every request
+	ServeMixed
+)
+
+// ParseServingStrategy maps the API name of a serving strategy to its value.
+// Unknown or empty names fall back to ServeDelayed.
+func ParseServingStrategy(name string) ServingStrategyType {
+	switch name {
+	case "honest":
+		return ServeHonestly
+	case "refuse":
+		return ServeRefuse
+	case "slow":
+		return ServeSlow
+	case "corrupt":
+		return ServeCorrupt
+	case "stale":
+		return ServeStale
+	case "mixed":
+		return ServeMixed
+	default:
+		return ServeDelayed
+	}
+}
+
+func (s ServingStrategyType) String() string {
+	switch s {
+	case ServeHonestly:
+		return "honest"
+	case ServeRefuse:
+		return "refuse"
+	case ServeSlow:
+		return "slow"
+	case ServeCorrupt:
+		return "corrupt"
+	case ServeStale:
+		return "stale"
+	case ServeMixed:
+		return "mixed"
+	default:
+		return "delay"
+	}
+}
+
+// Partition cuts the network in two from Start until it heals at End. Groups holds the shard
+// IDs or region indexes of the isolated side, Nodes its node IDs and Fraction its share of
+// all nodes, depending on Split.
+type Partition struct {
+	Start    int64
+	End      int64
+	Split    PartitionSplit
+	Groups   []int
+	Nodes    []int
+	Fraction float64
+}
+
 type Config struct {
 	NumNodes                int
 	NumOperators            int
@@ -40,6 +595,96 @@ type Config struct {
 	MaxP2PConnections       int
 	TimeOut                 int64
 	NumBlocksToDownload     int
+	StakeDistribution       StakeDistributionType
+	StakeParetoShape        float64
+	MaxStake                int
+	StakeList               []int
+	ProducerSelection       ProducerSelectionRule
+	EnableForks             bool
+	ConcurrentProducers     int
+	ForkChoice              ForkChoiceRule
+	NumERVerifiers          int
+	EnableFraudProofs       bool
+	FraudDetectionProb      float64
+	FraudDetectionDelay     int64
+	FraudProofSize          int
+	FraudVerifiers          int
+	FraudReexecutionFactor  float64
+	CompromiseMinShardSize  int
+	ChallengePeriod         int64
+	Workload                WorkloadType
+	TransactionRate         float64
+	BurstMultiplier         float64
+	BurstDuration           int64
+	BurstPeriod             int64
+	WorkloadTraceFile       string
+	MempoolCapacity         int
+	CrossShardRatio         float64
+	MaxShardsPerTx          int
+	Finality                FinalityRuleType
+	FinalityConfirmations   int
+	FinalityQuorum          float64
+	VoteSize                int
+	BeaconBlockInterval     int64
+	FinalityStallThreshold  float64
+	FinalityTimeout         int64
+	EnableBeaconChain       bool
+	BeaconCommitteeSize     int
+	MaxCrosslinks           int
+	EnableDAS               bool
+	DASDataChunks           int
+	DASExtensionFactor      int
+	DASSamplesPerNode       int
+	DASProofSize            int
+	NumLightNodes           int
+	DASWithheldFraction     float64
+	StateSync               StateSyncStrategy
+	GenesisStateSize        int64
+	StateGrowthPerBlock     int
+	StateGrowthPerTx        int
+	SnapshotInterval        int
+	BlockReplayTime         float64
+	WitnessSizePerTx        int
+	Topology                TopologyType
+	PeerDegree              int
+	SmallWorldRewiring      float64
+	RegionLatencyFile       string
+	NodeRegionWeights       []float64
+	OperatorRegionWeights   []float64
+	EnableLinkQueues        bool
+	UploadBandwidth         int64
+	DownloadBandwidth       int64
+	MinLinkLossRate         float64
+	MaxLinkLossRate         float64
+	MaxRetransmissions      int
+	RetransmissionTimeout   float64
+	Partitions              []Partition
+	PartitionMode           PartitionModeType
+	BlockDelayModel         DelayDistribution
+	HeaderDelayModel        DelayDistribution
+	DownloadDelayModel      DelayDistribution
+	ParetoShape             float64
+	DelayTraceFile          string
+	EnableHeaderSync        bool
+	HeaderSyncBatch         int
+	HeaderSyncRetries       int
+	HeaderSyncTimeout       int64
+	AttackTargetShard       int
+	GrindingAttemptCost     float64
+	GrindingBudget          float64
+	CompromiseThreshold     float64
+	Replicas                int
+	BlockWithholding        WithholdingMode
+	ServingStrategy         ServingStrategyType
+	ServingStrategyWeights  []float64
+	SlowServeFraction       float64
+	EclipsePeerSlots        int
+	EclipseAdvantage        float64
+	CorruptionDelay         int64
+	CorruptionBudget        int
+	CorruptionTarget        CorruptionTargetType
+	// DelayTrace holds the RTT samples of DelayTraceFile, loaded when the simulation starts
+	DelayTrace []float64
 }
 
 const (
@@ -57,7 +702,7 @@ const (
 	MaliciousNodeRatio      = 0.1 // 10% of nodes are malicious
 	AttackStartTime         = 20
 	AttackEndTime           = 60
-	MaliciousNodeMultiplier = 0 // Multiplier for malicious nodes in lottery attempts
+	MaliciousNodeMultiplier = 8 // Lottery attempts per resource unit a grinding node makes each round
 
 	// Lottery parameters
 	LotteryWinProbability = 0.01 // Base probability for winning the lottery
@@ -69,6 +714,7 @@ const (
 	BlockHeaderSize         = 1000                           // Increased to more realistic size in bytes
 	ERHeaderSize            = 1000                           // ER header size in bytes
 	ERBodySize              = 33000                          // ER body size in bytes
+	NumERVerifiers          = 0                              // Shard members an ER body is sent to, 0 sends it to every member
 
 	// Network simulation parameters
 	NetworkBandwidth    = 10    // Network bandwidth in Mbps
@@ -83,12 +729,155 @@ const (
 
 	// Download parameters
 	NumBlocksToDownload = 100
+
+	// Stake parameters
+	StakeDistribution = UniformStake // How stake is spread over nodes: uniform, pareto or list
+	StakeParetoShape  = 1.5          // Tail index of the Pareto stake distribution, lower is more concentrated
+	MaxStake          = 1_000        // Largest stake a node draws from the Pareto distribution (0 for no limit)
+
+	// Producer selection parameters
+	ProducerSelection = LotteryWinnerOnly // Rule used to pick the next block producer of a shard
+
+	// Fork parameters
+	EnableForks         = false        // Producers build on their own view and blocks reach peers after their propagation delay
+	ConcurrentProducers = 1            // Producers per block production event when forks are enabled
+	ForkChoice          = LongestChain // Rule used by every node to pick the canonical chain
+
+	// Fraud proof parameters
+	EnableFraudProofs   = false // Nodes accept malicious blocks optimistically and revert them on a fraud proof
+	FraudDetectionProb  = 0.5   // Probability that an honest verifier detects a malicious block
+	FraudDetectionDelay = 500   // Time a verifier needs to detect fraud once it has the block, in milliseconds
+	FraudProofSize      = 2000  // Fraud proof size in bytes
+	ChallengePeriod     = 60    // Time units after a block during which a fraud proof reverts it
+
+	// Fraud verification parameters
+	FraudVerifiers         = 3   // Shard members sampled to re-execute each block
+	FraudReexecutionFactor = 1.0 // Mean time a verifier needs to re-execute a block, in block production intervals
+
+	// Workload parameters
+	Workload          = SaturatedWorkload // Arrival process feeding the shard mempools
+	TransactionRate   = 1500              // Transactions arriving per time unit across all shards
+	BurstMultiplier   = 5.0               // Arrival rate multiplier during a burst
+	BurstDuration     = 10                // Length of a burst in time units
+	BurstPeriod       = 60                // Time units between the starts of two bursts
+	WorkloadTraceFile = ""                // CSV of "time[,shard]" lines replayed by the trace workload
+	MempoolCapacity   = 0                 // Transactions a shard mempool holds, 0 for no limit
+
+	// Cross-shard parameters
+	CrossShardRatio = 0.0 // Share of generated transactions that touch more than one shard
+	MaxShardsPerTx  = 2   // Most shards a cross-shard transaction touches, source shard included
+
+	// Finality parameters
+	Finality               = NoFinality // Rule deciding when a shard block is final
+	FinalityConfirmations  = 6          // Blocks on top of a block before k-confirmations finality
+	FinalityQuorum         = 2.0 / 3.0  // Share of the shard committee whose votes finalise a block
+	VoteSize               = 200        // Committee vote size in bytes
+	FinalityStallThreshold = 1.0 / 3.0  // Malicious share of a shard above which stalls are reported separately
+	FinalityTimeout        = 60         // Time units after which a block that is not final counts as stalled
+
+	// Beacon chain parameters
+	EnableBeaconChain   = false // Shard headers reach the network through crosslinks in beacon blocks
+	BeaconBlockInterval = 12    // Time units between beacon blocks including shard headers
+	BeaconCommitteeSize = 16    // Nodes taking turns producing beacon blocks
+	MaxCrosslinks       = 0     // Shard headers a beacon block includes, 0 for no limit
+
+	// Data availability sampling parameters
+	EnableDAS           = false // Shard members and light nodes sample erasure-coded chunks of every block
+	DASDataChunks       = 64    // Chunks a block is split into before erasure coding
+	DASExtensionFactor  = 2     // Coded chunks per data chunk, any DASDataChunks chunks recover the block
+	DASSamplesPerNode   = 16    // Chunks every sampler requests per block
+	DASProofSize        = 256   // Inclusion proof size in bytes sent along every sampled chunk
+	NumLightNodes       = 100   // Light nodes sampling every shard block besides the shard members
+	DASWithheldFraction = 0.51  // Share of the coded chunks a malicious producer withholds
+
+	// State sync parameters
+	StateSync           = NoStateSync // What a rotated node downloads before it may produce in its new shard
+	GenesisStateSize    = 10_000_000  // Shard state size in bytes at genesis
+	StateGrowthPerBlock = 1000        // State bytes added by every canonical block
+	StateGrowthPerTx    = 50          // State bytes added by every transaction
+	SnapshotInterval    = 50          // Blocks between two state snapshots, 0 for a snapshot at every block
+	BlockReplayTime     = 20.0        // Time to re-execute a block while syncing, in milliseconds
+	WitnessSizePerTx    = 500         // Witness bytes a stateless node needs per transaction
+
+	// Peer graph parameters
+	Topology           = NoTopology // Generator of the peer graph blocks are gossiped over hop by hop
+	PeerDegree         = 8          // Peers every node keeps, the mean degree for scale-free graphs
+	SmallWorldRewiring = 0.1        // Probability that a small-world ring link is rewired to a random node
+
+	// Geography parameters
+	RegionLatencyFile = "" // JSON or CSV region-to-region RTT matrix, empty to ignore geography
+
+	// Bandwidth contention parameters
+	EnableLinkQueues  = false // Transfers queue on the sender's upload and the receiver's download link
+	UploadBandwidth   = 0     // Upload bandwidth of every node in Mbps, 0 for NetworkBandwidth
+	DownloadBandwidth = 0     // Download bandwidth of every node in Mbps, 0 for NetworkBandwidth
+
+	// Packet loss parameters
+	MinLinkLossRate       = 0.0   // Lowest share of messages a link loses
+	MaxLinkLossRate       = 0.0   // Highest share of messages a link loses, 0 for lossless links
+	MaxRetransmissions    = 3     // Times a lost gossip hop or download is resent before giving up
+	RetransmissionTimeout = 200.0 // Wait before the first retransmission in milliseconds, doubled on every retry
+
+	// Network partition parameters
+	PartitionMode = DropAcrossPartition // Whether messages across a partition are lost or delivered once it heals
+
+	// Latency distribution parameters
+	BlockDelayModel    = NormalDelay // Distribution of per-hop latency for block and beacon block propagation
+	HeaderDelayModel   = NormalDelay // Distribution of per-hop latency for headers, receipt headers, fraud proofs and votes
+	DownloadDelayModel = NormalDelay // Distribution of latency for block, receipt body, chunk and state downloads
+	ParetoShape        = 2.5         // Tail index of the Pareto distribution, lower is heavier
+	DelayTraceFile     = ""          // RTT samples in milliseconds for the empirical distribution
+
+	// Header sync parameters
+	EnableHeaderSync  = false // Nodes link headers back to genesis and request missing ones from peers
+	HeaderSyncBatch   = 64    // Most headers a peer returns in one header sync response
+	HeaderSyncRetries = 3     // Requests a node sends for a gap before giving up on it
+	HeaderSyncTimeout = 2000  // Wait for a header sync response before asking another peer, in milliseconds
+
+	// Attack parameters
+	ScheduledAttack     = GrindingAttack // Attack run from AttackStartTime to AttackEndTime: grinding, takeover, eclipse or adaptive
+	AttackTargetShard   = 0              // Shard the adversary packs its malicious nodes into
+	GrindingAttemptCost = 1.0            // Compute units a single lottery attempt costs the adversary
+	GrindingBudget      = 0.0            // Compute units the adversary spends per lottery round (0 for no limit)
+	CompromiseThreshold = 1.0 / 3        // Malicious share of a shard above which it counts as compromised
+	Replicas            = 1              // Independent runs the probability of compromise is estimated over
+	BlockWithholding    = NoWithholding  // Malicious producers keep block bodies from all nodes, from all but colluders, or publish them
+	ServingStrategy     = ServeDelayed   // How malicious nodes answer block downloads when ServingStrategyWeights is nil
+	SlowServeFraction   = 0.5            // Share of the bandwidth a slow serving node sends blocks at
+	EclipsePeerSlots    = 8              // Regular peers a rotating node downloads from during an eclipse attack
+	EclipseAdvantage    = 3.0            // How much address flooding multiplies the adversary's share of peer slots
+
+	// Compromise parameters
+	CompromiseMinShardSize = 0 // Members a shard needs before its malicious share counts toward compromise, 0 for any size
+
+	// Adaptive corruption parameters
+	CorruptionDelay  = 300                // Time units an adaptive adversary needs to corrupt an honest node
+	CorruptionBudget = 50                 // Honest nodes an adaptive adversary may go for in total (0 for no limit)
+	CorruptionTarget = TargetClosestShard // Shard an adaptive adversary corrupts in: closest to the threshold or AttackTargetShard
 )
 
+// Region weights cannot be constants. Nil weights spread nodes or operators uniformly over the
+// regions of RegionLatencyFile.
+var (
+	NodeRegionWeights     []float64
+	OperatorRegionWeights []float64
+)
+
+// Partitions cannot be constants either. Nil schedules no partition.
+var Partitions []Partition
+
+// StakeList gives node i the stake StakeList[i % len(StakeList)] with the list distribution.
+// Nil gives every node one unit of stake.
+var StakeList []int
+
+// ServingStrategyWeights assigns every malicious node a serving strategy at random, weighted in
+// the order of the ServingStrategyType values. Nil gives every malicious node ServingStrategy.
+var ServingStrategyWeights []float64
+
 // InitializeAttackSchedule initializes the attack schedule with both start and end times
 func InitializeAttackSchedule() map[int64]AttackType {
 	return map[int64]AttackType{
-		AttackStartTime: GrindingAttack, // Start Grinding Attack at time step 20
-		AttackEndTime:   NoAttack,       // End Grinding Attack at time step 40
+		AttackStartTime: ScheduledAttack, // Start the attack at time step 20
+		AttackEndTime:   NoAttack,        // End the attack at time step 60
 	}
 }
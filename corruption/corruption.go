@@ -0,0 +1,263 @@
+// corruption/corruption.go
+
+package corruption
+
+import (
+	"math"
+	"math/rand"
+	"sharding/config"
+	"sharding/node"
+	"sharding/shard"
+	"sort"
+)
+
+// Corruption is an honest node the adversary went for in the shard it sat in. It lands Delay
+// after Start unless the node rotated out of the shard first.
+type Corruption struct {
+	NodeID  int
+	ShardID int
+	Start   int64
+	End     int64
+	// How long the node had been in the shard when the adversary picked it
+	Residence int64
+	Landed    bool
+	Escaped   bool
+}
+
+// Target is a lottery round in which the adversary went for a shard. Needed is how many more
+// malicious members the shard lacked to cross the threshold, counting corruptions in flight.
+type Target struct {
+	Time    int64
+	ShardID int
+	Share   float64
+	Needed  int
+	Started int
+}
+
+// Adversary corrupts honest regular nodes after they landed in a shard. A corruption takes Delay
+// time units and fails when the lottery moves the node to another shard in the meantime, so
+// rotation has to outpace the adversary. Operators never rotate and are left out, rotation
+// cannot protect them anyway.
+type Adversary struct {
+	Delay     int64
+	Threshold float64
+	// Corruptions the adversary may start in total, 0 for no limit
+	Budget      int
+	Rule        config.CorruptionTargetType
+	TargetShard int
+	Corruptions []*Corruption
+	Targets     []Target
+	Landed      int
+	Escaped     int
+	// Time units nodes stayed in a shard before the lottery moved them on
+	Residences []int64
+	joined     map[int]int64
+	pending    map[int]*Corruption
+}
+
+// NewAdversary sets up the adversary against shards compromised above threshold
+func NewAdversary(cfg *config.Config, threshold float64) *Adversary {
+	return &Adversary{
+		Delay:       cfg.CorruptionDelay,
+		Threshold:   threshold,
+		Budget:      cfg.CorruptionBudget,
+		Rule:        cfg.CorruptionTarget,
+		TargetShard: cfg.AttackTargetShard,
+		Corruptions: make([]*Corruption, 0),
+		Targets:     make([]Target, 0),
+		Residences:  make([]int64, 0),
+		joined:      make(map[int]int64),
+		pending:     make(map[int]*Corruption),
+	}
+}
+
+// Moved records a node joining another shard at time now, which ends its stay in the old one
+func (a *Adversary) Moved(nodeID int, oldShardID int, now int64) {
+	if oldShardID != -1 {
+		a.Residences = append(a.Residences, now-a.joined[nodeID])
+	}
+	a.joined[nodeID] = now
+}
+
+// Remaining returns how many more corruptions the budget allows, -1 for no limit
+func (a *Adversary) Remaining() int {
+	if a.Budget <= 0 {
+		return -1
+	}
+	return max(0, a.Budget-len(a.Corruptions))
+}
+
+// inShard reports whether a node still sits in the shard it was in when the corruption started
+func (a *Adversary) inShard(n *node.Node, c *Corruption) bool {
+	return n.AssignedShard == c.ShardID && a.joined[n.ID] <= c.Start
+}
+
+// candidates returns the honest regular members of a shard the adversary can still go for and
+// how many of its members are malicious or being corrupted
+func (a *Adversary) candidates(s *shard.Shard) ([]*node.Node, int) {
+	honest := make([]*node.Node, 0, len(s.Nodes))
+	malicious := 0
+	for _, n := range s.Nodes {
+		if !n.IsHonest {
+			malicious++
+			continue
+		}
+		if c, exists := a.pending[n.ID]; exists && a.inShard(n, c) {
+			malicious++
+			continue
+		}
+		if !n.IsOperator {
+			honest = append(honest, n)
+		}
+	}
+	sort.Slice(honest, func(i, j int) bool {
+		return honest[i].ID < honest[j].ID
+	})
+	return honest, malicious
+}
+
+// Plan picks the shard to go for at time now and starts as many corruptions as it takes to push
+// the shard above the threshold, within the budget. Nothing starts when the corruptions would
+// land at or after end, so they neither use up the budget nor stay pending. It returns the
+// corruptions started.
+func (a *Adversary) Plan(shards map[int]*shard.Shard, now, end int64) []*Corruption {
+	remaining := a.Remaining()
+	if remaining == 0 || now+a.Delay >= end {
+		return nil
+	}
+
+	shardIDs := make([]int, 0, len(shards))
+	for shardID := range shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+
+	var target *Target
+	var honest []*node.Node
+	for _, shardID := range shardIDs {
+		if a.Rule == config.TargetFixedShard && shardID != a.TargetShard {
+			continue
+		}
+		s := shards[shardID]
+		if len(s.Nodes) == 0 {
+			continue
+		}
+		candidates, malicious := a.candidates(s)
+		needed := int(math.Floor(a.Threshold*float64(len(s.Nodes)))) + 1 - malicious
+		// Shards already above the threshold, or about to be, need nothing more
+		if needed <= 0 || len(candidates) == 0 {
+			continue
+		}
+		if target == nil || needed < target.Needed {
+			target = &Target{
+				Time:    now,
+				ShardID: shardID,
+				Share:   float64(malicious) / float64(len(s.Nodes)),
+				Needed:  needed,
+			}
+			honest = candidates
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	count := min(target.Needed, len(honest))
+	if remaining > 0 {
+		count = min(count, remaining)
+	}
+	started := make([]*Corruption, 0, count)
+	for _, i := range rand.Perm(len(honest))[:count] {
+		n := honest[i]
+		c := &Corruption{
+			NodeID:    n.ID,
+			ShardID:   target.ShardID,
+			Start:     now,
+			End:       -1,
+			Residence: now - a.joined[n.ID],
+		}
+		a.pending[n.ID] = c
+		a.Corruptions = append(a.Corruptions, c)
+		started = append(started, c)
+	}
+	target.Started = len(started)
+	a.Targets = append(a.Targets, *target)
+	return started
+}
+
+// Land completes a corruption at time now. It returns whether the node turned malicious, which
+// it does only when it never left the shard the adversary found it in.
+func (a *Adversary) Land(c *Corruption, n *node.Node, now int64) bool {
+	// A node that escaped may have been picked again in its new shard
+	if a.pending[c.NodeID] == c {
+		delete(a.pending, c.NodeID)
+	}
+	c.End = now
+	if n.IsHonest && a.inShard(n, c) {
+		c.Landed = true
+		a.Landed++
+		return true
+	}
+	c.Escaped = true
+	a.Escaped++
+	return false
+}
+
+// Pending returns the corruptions still in flight
+func (a *Adversary) Pending() int {
+	return len(a.Corruptions) - a.Landed - a.Escaped
+}
+
+// Survival returns the probability that a node stays in its shard for delay time units, when the
+// lottery runs every interval and each win moves it with probability (shards-1)/shards
+func Survival(winProbability float64, interval int64, shards int, delay int64) float64 {
+	move := MoveProbability(winProbability, shards)
+	if interval <= 0 || move <= 0 {
+		return 1
+	}
+	rounds := float64(delay / interval)
+	return math.Pow(1-move, rounds)
+}
+
+// MoveProbability returns the probability that one lottery round moves a node to another shard
+func MoveProbability(winProbability float64, shards int) float64 {
+	if shards <= 1 {
+		return 0
+	}
+	return winProbability * float64(shards-1) / float64(shards)
+}
+
+// HalfLife returns the time after which half the nodes of a shard have been moved elsewhere. An
+// adversary slower than that loses most of the corruptions it starts. It is -1 when nodes
+// never move.
+func HalfLife(winProbability float64, interval int64, shards int) float64 {
+	move := MoveProbability(winProbability, shards)
+	if move <= 0 {
+		return -1
+	}
+	if move >= 1 {
+		return float64(interval)
+	}
+	return math.Ceil(math.Log(0.5)/math.Log1p(-move)) * float64(interval)
+}
+
+// RequiredWinProbability returns the smallest lottery win probability at which a node the
+// adversary goes for rotates out before a corruption of the given delay lands with probability
+// at least escape. It is -1 when no win probability gets there, as when the delay is shorter
+// than a lottery interval.
+func RequiredWinProbability(escape float64, interval int64, shards int, delay int64) float64 {
+	if interval <= 0 || shards <= 1 {
+		return -1
+	}
+	rounds := float64(delay / interval)
+	if rounds < 1 {
+		return -1
+	}
+	// 1 - (1 - p (S-1)/S)^rounds >= escape
+	move := -math.Expm1(math.Log1p(-escape) / rounds)
+	p := move * float64(shards) / float64(shards-1)
+	if p > 1 {
+		return -1
+	}
+	return p
+}
@@ -0,0 +1,154 @@
+// corruption/corruption_test.go
+
+package corruption
+
+import (
+	"math"
+	"sharding/config"
+	"sharding/node"
+	"sharding/shard"
+	"testing"
+)
+
+// newShard fills a shard with honest and malicious regular nodes and honest operators, node IDs
+// starting at firstID
+func newShard(id, firstID, honest, malicious, operators int) *shard.Shard {
+	s := &shard.Shard{ID: id, Nodes: make(map[int]*node.Node)}
+	for i := 0; i < honest+malicious+operators; i++ {
+		n := &node.Node{
+			ID:            firstID + i,
+			IsHonest:      i < honest || i >= honest+malicious,
+			IsOperator:    i >= honest+malicious,
+			AssignedShard: id,
+		}
+		s.Nodes[n.ID] = n
+	}
+	return s
+}
+
+func TestRotationOdds(t *testing.T) {
+	if p := MoveProbability(0.5, 2); p != 0.25 {
+		t.Errorf("move probability %v with two shards, want 0.25", p)
+	}
+	if MoveProbability(1, 1) != 0 || Survival(0.5, 10, 1, 100) != 1 || HalfLife(0.5, 10, 1) != -1 {
+		t.Error("nodes of a single shard have somewhere to move")
+	}
+	// Three full rounds, the half round left over does not count
+	if s := Survival(0.5, 10, 2, 35); math.Abs(s-0.421875) > 1e-12 {
+		t.Errorf("survival %v over three rounds, want 0.75^3", s)
+	}
+	// A quarter of the nodes move per round: 0.5625 stay after two rounds and 0.42 after three
+	if h := HalfLife(0.5, 10, 2); h != 30 {
+		t.Errorf("half-life %v, want 30", h)
+	}
+	if h := HalfLife(1, 10, 2); h != 10 {
+		t.Errorf("half-life %v when half the nodes move every round, want 10", h)
+	}
+}
+
+func TestRequiredWinProbabilityReachesTheEscapeRate(t *testing.T) {
+	for _, delay := range []int64{10, 30, 60, 600} {
+		for _, escape := range []float64{0.1, 0.5, 0.9} {
+			p := RequiredWinProbability(escape, 10, 4, delay)
+			if p == -1 {
+				// Only a single round can need a win probability above 1
+				if delay != 10 {
+					t.Errorf("no win probability lets %v escape a delay of %d", escape, delay)
+				}
+				continue
+			}
+			if escaped := 1 - Survival(p, 10, 4, delay); math.Abs(escaped-escape) > 1e-9 {
+				t.Errorf("delay %d: win probability %v lets %v escape, want %v", delay, p, escaped, escape)
+			}
+		}
+	}
+	if p := RequiredWinProbability(0.5, 10, 2, 5); p != -1 {
+		t.Errorf("win probability %v outruns a corruption faster than a round", p)
+	}
+}
+
+func TestPlanGoesForTheClosestShard(t *testing.T) {
+	shards := map[int]*shard.Shard{
+		0: newShard(0, 0, 8, 2, 0),
+		1: newShard(1, 100, 6, 4, 3),
+	}
+	a := NewAdversary(&config.Config{CorruptionDelay: 5}, 0.5)
+
+	// Shard 1 needs 7 of 13 members and has 4, its operators count but cannot be corrupted
+	started := a.Plan(shards, 0, 100)
+	if len(started) != 3 {
+		t.Fatalf("started %d corruptions, want 3", len(started))
+	}
+	for _, c := range started {
+		n := shards[1].Nodes[c.NodeID]
+		if c.ShardID != 1 || n == nil || !n.IsHonest || n.IsOperator {
+			t.Errorf("corruption of node %d in shard %d", c.NodeID, c.ShardID)
+		}
+	}
+
+	// Corruptions in flight count, so shard 0 is next
+	if next := a.Plan(shards, 1, 100); len(next) != 4 || next[0].ShardID != 0 {
+		t.Errorf("second plan started %d corruptions, want 4 in shard 0", len(next))
+	}
+	if a.Pending() != 7 || len(a.Targets) != 2 || a.Targets[0].Needed != 3 {
+		t.Errorf("%d pending over targets %+v", a.Pending(), a.Targets)
+	}
+}
+
+func TestPlanRespectsBudgetAndTarget(t *testing.T) {
+	shards := map[int]*shard.Shard{
+		0: newShard(0, 0, 8, 2, 0),
+		1: newShard(1, 100, 6, 4, 0),
+	}
+	a := NewAdversary(&config.Config{CorruptionBudget: 3, CorruptionTarget: config.TargetFixedShard, AttackTargetShard: 0}, 0.5)
+	if started := a.Plan(shards, 0, 100); len(started) != 3 || started[0].ShardID != 0 {
+		t.Errorf("started %d corruptions, want the budget of 3 in the fixed shard 0", len(started))
+	}
+	if a.Remaining() != 0 || a.Plan(shards, 1, 100) != nil {
+		t.Error("adversary kept corrupting past its budget")
+	}
+}
+
+func TestPlanSkipsCorruptionsLandingAfterTheEnd(t *testing.T) {
+	shards := map[int]*shard.Shard{0: newShard(0, 0, 8, 2, 0)}
+	a := NewAdversary(&config.Config{CorruptionDelay: 5, CorruptionBudget: 4}, 0.5)
+
+	// Landing at 100 or later would be after a simulation that ends at 100
+	if started := a.Plan(shards, 95, 100); started != nil {
+		t.Fatalf("started %d corruptions that cannot land before the end", len(started))
+	}
+	if a.Remaining() != 4 || a.Pending() != 0 || len(a.Targets) != 0 {
+		t.Errorf("dropped plan left %d of the budget, %d pending and targets %+v", a.Remaining(), a.Pending(), a.Targets)
+	}
+	if started := a.Plan(shards, 94, 100); len(started) != 4 {
+		t.Errorf("started %d corruptions landing at 99, want 4", len(started))
+	}
+}
+
+func TestLandOnlyInTheSameShard(t *testing.T) {
+	shards := map[int]*shard.Shard{0: newShard(0, 0, 3, 0, 0)}
+	a := NewAdversary(&config.Config{CorruptionDelay: 5}, 0.5)
+	for id := range shards[0].Nodes {
+		a.Moved(id, -1, 0)
+	}
+	started := a.Plan(shards, 2, 100)
+	if len(started) != 2 {
+		t.Fatalf("started %d corruptions, want 2", len(started))
+	}
+
+	stayed := shards[0].Nodes[started[0].NodeID]
+	rotated := shards[0].Nodes[started[1].NodeID]
+	// The lottery moves one node away and back before the corruption lands
+	a.Moved(rotated.ID, 0, 4)
+	a.Moved(rotated.ID, 1, 6)
+
+	if !a.Land(started[0], stayed, 7) || a.Land(started[1], rotated, 7) {
+		t.Error("corruption outcome does not follow rotation")
+	}
+	if a.Landed != 1 || a.Escaped != 1 || a.Pending() != 0 || !started[1].Escaped {
+		t.Errorf("landed %d, escaped %d, pending %d", a.Landed, a.Escaped, a.Pending())
+	}
+	if len(a.Residences) != 2 || a.Residences[0] != 4 || a.Residences[1] != 2 {
+		t.Errorf("residences %v, want 4 and 2", a.Residences)
+	}
+}
@@ -0,0 +1,86 @@
+// das/das.go
+
+package das
+
+import (
+	"math"
+	"math/rand"
+	"sharding/config"
+)
+
+// Params describes how a block is erasure coded. The block is split into DataChunks chunks
+// and extended to TotalChunks, any DataChunks of which are enough to recover it.
+type Params struct {
+	DataChunks  int
+	TotalChunks int
+	ChunkSize   int
+	// SampleSize is the bytes a sampler downloads per sample, the chunk and its inclusion proof
+	SampleSize int
+	Samples    int
+}
+
+func NewParams(cfg *config.Config) Params {
+	dataChunks := max(cfg.DASDataChunks, 1)
+	chunkSize := int(math.Ceil(float64(cfg.BlockSize) / float64(dataChunks)))
+	return Params{
+		DataChunks:  dataChunks,
+		TotalChunks: dataChunks * max(cfg.DASExtensionFactor, 1),
+		ChunkSize:   chunkSize,
+		SampleSize:  chunkSize + cfg.DASProofSize,
+		Samples:     cfg.DASSamplesPerNode,
+	}
+}
+
+// Publish returns which of the coded chunks of a block the producer releases. A withholding
+// producer keeps back the given fraction of the chunks, chosen at random.
+func (p Params) Publish(withheldFraction float64) []bool {
+	available := make([]bool, p.TotalChunks)
+	for i := range available {
+		available[i] = true
+	}
+	withheld := int(math.Ceil(withheldFraction * float64(p.TotalChunks)))
+	for _, i := range rand.Perm(p.TotalChunks)[:min(withheld, p.TotalChunks)] {
+		available[i] = false
+	}
+	return available
+}
+
+// Recoverable reports whether enough chunks were released to rebuild the block
+func (p Params) Recoverable(available []bool) bool {
+	return countAvailable(available) >= p.DataChunks
+}
+
+// Sample draws Samples distinct chunks and reports whether every one of them was available
+func (p Params) Sample(available []bool) bool {
+	for _, i := range rand.Perm(p.TotalChunks)[:min(p.Samples, p.TotalChunks)] {
+		if !available[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectionProbability is the chance that a single sampler hits at least one withheld chunk:
+// 1 - C(available, s) / C(total, s)
+func (p Params) DetectionProbability(available []bool) float64 {
+	a := countAvailable(available)
+	s := min(p.Samples, p.TotalChunks)
+	allAvailable := 1.0
+	for i := 0; i < s; i++ {
+		allAvailable *= float64(a-i) / float64(p.TotalChunks-i)
+		if allAvailable <= 0 {
+			return 1
+		}
+	}
+	return 1 - allAvailable
+}
+
+func countAvailable(available []bool) int {
+	count := 0
+	for _, a := range available {
+		if a {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,73 @@
+// das/das_test.go
+
+package das
+
+import (
+	"math"
+	"sharding/config"
+	"testing"
+)
+
+func TestNewParamsSplitsTheBlock(t *testing.T) {
+	p := NewParams(&config.Config{BlockSize: 1000, DASDataChunks: 3, DASExtensionFactor: 2, DASProofSize: 64, DASSamplesPerNode: 5})
+	if p.DataChunks != 3 || p.TotalChunks != 6 || p.ChunkSize != 334 || p.SampleSize != 398 || p.Samples != 5 {
+		t.Errorf("params %+v", p)
+	}
+	// Zero settings still leave one chunk holding the whole block
+	if p := NewParams(&config.Config{BlockSize: 1000}); p.DataChunks != 1 || p.TotalChunks != 1 || p.ChunkSize != 1000 {
+		t.Errorf("params %+v without erasure coding settings", p)
+	}
+}
+
+func TestPublishWithholdsAFraction(t *testing.T) {
+	p := Params{DataChunks: 4, TotalChunks: 8}
+	if available := p.Publish(0); !p.Recoverable(available) || countAvailable(available) != 8 {
+		t.Error("honest producer did not release every chunk")
+	}
+	// Withholding half of the extended chunks still leaves enough to recover
+	if available := p.Publish(0.5); countAvailable(available) != 4 || !p.Recoverable(available) {
+		t.Errorf("%d chunks released withholding half, want a recoverable 4", countAvailable(available))
+	}
+	if available := p.Publish(0.6); p.Recoverable(available) {
+		t.Errorf("block recoverable from %d of 8 chunks", countAvailable(available))
+	}
+}
+
+func TestDetectionProbability(t *testing.T) {
+	halfWithheld := []bool{true, true, false, false}
+	for samples, want := range map[int]float64{
+		0: 0,
+		1: 0.5,
+		// 1 - (2/4)(1/3)
+		2: 5.0 / 6.0,
+		// More samples than available chunks always hit a withheld one
+		3:  1,
+		10: 1,
+	} {
+		p := Params{DataChunks: 2, TotalChunks: 4, Samples: samples}
+		if got := p.DetectionProbability(halfWithheld); math.Abs(got-want) > 1e-12 {
+			t.Errorf("%d samples: detection probability %v, want %v", samples, got, want)
+		}
+	}
+
+	p := Params{DataChunks: 2, TotalChunks: 4, Samples: 2}
+	if got := p.DetectionProbability([]bool{true, true, true, true}); got != 0 {
+		t.Errorf("detection probability %v with every chunk released", got)
+	}
+}
+
+func TestSamplingMatchesDetectionProbability(t *testing.T) {
+	p := Params{DataChunks: 8, TotalChunks: 16, Samples: 3}
+	available := p.Publish(0.25)
+	const trials = 20_000
+	detected := 0
+	for i := 0; i < trials; i++ {
+		if !p.Sample(available) {
+			detected++
+		}
+	}
+	want := p.DetectionProbability(available)
+	if got := float64(detected) / trials; math.Abs(got-want) > 0.02 {
+		t.Errorf("samplers caught withholding %.3f of the time, want %.3f", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+// eclipse/eclipse.go
+
+package eclipse
+
+import (
+	"math/rand"
+	"sharding/config"
+	"sharding/node"
+	"sort"
+)
+
+// PeerSet is the set of peers a victim downloads from after the adversary went for its
+// connections. The victim is eclipsed when none of them is honest.
+type PeerSet struct {
+	Peers           []*node.Node
+	HonestPeers     int
+	HonestOperators int
+}
+
+func (p PeerSet) Eclipsed() bool {
+	return p.HonestPeers == 0
+}
+
+// Outcome counts the eclipse attempts on victims that kept a given number of honest operators
+type Outcome struct {
+	Attempts int
+	Eclipsed int
+}
+
+// Parents counts what the producers built on after an eclipse attempt: a parent behind the
+// shard's canonical head, or a malicious one
+type Parents struct {
+	Blocks    int
+	Stale     int
+	Malicious int
+}
+
+// Adversary floods the address tables of rotating nodes with its own nodes. It takes each of a
+// victim's Slots regular connections with probability Share and evicts each honest operator
+// connection with the same probability.
+type Adversary struct {
+	Slots     int
+	Share     float64
+	Malicious []*node.Node
+	Attempts  int
+	Eclipsed  int
+	// Attempts by the number of honest operators the victim kept
+	ByOperators map[int]*Outcome
+	// Parents of eclipsed producers and of the victims that kept an honest peer
+	EclipsedParents Parents
+	HonestParents   Parents
+}
+
+// NewAdversary sets up the adversary with every malicious regular node. Its share of the slots
+// is the malicious share of the network scaled by EclipseAdvantage.
+func NewAdversary(cfg *config.Config, nodes map[int]*node.Node) *Adversary {
+	malicious := make([]*node.Node, 0)
+	for _, n := range nodes {
+		if !n.IsHonest {
+			malicious = append(malicious, n)
+		}
+	}
+	sort.Slice(malicious, func(i, j int) bool {
+		return malicious[i].ID < malicious[j].ID
+	})
+	share := 0.0
+	if len(nodes) > 0 {
+		share = min(1, float64(len(malicious))/float64(len(nodes))*cfg.EclipseAdvantage)
+	}
+	return &Adversary{
+		Slots:       cfg.EclipsePeerSlots,
+		Share:       share,
+		Malicious:   malicious,
+		ByOperators: make(map[int]*Outcome),
+	}
+}
+
+// Select picks the download peers of a victim among the proposers it knows and the operators of
+// its shard. Slots the adversary wins go to its own nodes, which need not hold any block of the shard.
+func (a *Adversary) Select(victim *node.Node, proposers []*node.Node, operators []*node.Node) PeerSet {
+	honest := make([]*node.Node, 0, len(proposers))
+	for _, p := range proposers {
+		if p.IsHonest && p.ID != victim.ID {
+			honest = append(honest, p)
+		}
+	}
+
+	set := PeerSet{Peers: make([]*node.Node, 0, a.Slots+len(operators))}
+	for i := 0; i < a.Slots; i++ {
+		if len(a.Malicious) > 0 && rand.Float64() < a.Share {
+			set.Peers = append(set.Peers, a.Malicious[rand.Intn(len(a.Malicious))])
+		} else if len(honest) > 0 {
+			set.Peers = append(set.Peers, honest[rand.Intn(len(honest))])
+			set.HonestPeers++
+		}
+	}
+	for _, op := range operators {
+		if !op.IsHonest {
+			set.Peers = append(set.Peers, op)
+			continue
+		}
+		if rand.Float64() >= a.Share {
+			set.Peers = append(set.Peers, op)
+			set.HonestPeers++
+			set.HonestOperators++
+		}
+	}
+
+	a.Attempts++
+	outcome, exists := a.ByOperators[set.HonestOperators]
+	if !exists {
+		outcome = &Outcome{}
+		a.ByOperators[set.HonestOperators] = outcome
+	}
+	outcome.Attempts++
+	if set.Eclipsed() {
+		a.Eclipsed++
+		outcome.Eclipsed++
+	}
+	return set
+}
+
+// RecordParent records the parent a victim built on, other than the shard's canonical head when stale
+func (a *Adversary) RecordParent(eclipsed bool, stale bool, malicious bool) {
+	parents := &a.HonestParents
+	if eclipsed {
+		parents = &a.EclipsedParents
+	}
+	parents.Blocks++
+	if stale {
+		parents.Stale++
+	}
+	if malicious {
+		parents.Malicious++
+	}
+}
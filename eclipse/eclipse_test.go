@@ -0,0 +1,67 @@
+// eclipse/eclipse_test.go
+
+package eclipse
+
+import (
+	"sharding/config"
+	"sharding/node"
+	"testing"
+)
+
+func network(honest, malicious int) map[int]*node.Node {
+	nodes := make(map[int]*node.Node)
+	for id := 0; id < honest+malicious; id++ {
+		nodes[id] = &node.Node{ID: id, IsHonest: id < honest}
+	}
+	return nodes
+}
+
+func TestShareScalesWithAdvantage(t *testing.T) {
+	nodes := network(8, 2)
+	if a := NewAdversary(&config.Config{EclipseAdvantage: 2}, nodes); a.Share != 0.4 || len(a.Malicious) != 2 {
+		t.Errorf("share %v with %d malicious nodes, want 0.4 with 2", a.Share, len(a.Malicious))
+	}
+	if a := NewAdversary(&config.Config{EclipseAdvantage: 10}, nodes); a.Share != 1 {
+		t.Errorf("share %v, want it capped at 1", a.Share)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	nodes := network(4, 2)
+	victim := nodes[0]
+	proposers := []*node.Node{nodes[0], nodes[1], nodes[2]}
+	operators := []*node.Node{{ID: 10, IsHonest: true}, {ID: 11, IsHonest: true}, {ID: 12}}
+
+	// An adversary holding every slot eclipses every victim, even with operators
+	strong := NewAdversary(&config.Config{EclipsePeerSlots: 4, EclipseAdvantage: 100}, nodes)
+	set := strong.Select(victim, proposers, operators)
+	if !set.Eclipsed() || len(set.Peers) != 5 || set.HonestOperators != 0 {
+		t.Errorf("strong adversary left %+v", set)
+	}
+
+	// Without a share every slot goes to an honest proposer other than the victim
+	weak := NewAdversary(&config.Config{EclipsePeerSlots: 4}, nodes)
+	set = weak.Select(victim, proposers, operators)
+	if set.Eclipsed() || set.HonestPeers != 6 || set.HonestOperators != 2 {
+		t.Errorf("weak adversary left %+v", set)
+	}
+	for _, p := range set.Peers {
+		if p == victim {
+			t.Error("victim peers with itself")
+		}
+	}
+
+	if strong.ByOperators[0].Eclipsed != 1 || weak.ByOperators[2].Attempts != 1 || weak.Eclipsed != 0 {
+		t.Error("attempts are not counted by the honest operators kept")
+	}
+}
+
+func TestRecordParent(t *testing.T) {
+	a := NewAdversary(&config.Config{}, network(1, 0))
+	a.RecordParent(true, true, false)
+	a.RecordParent(true, false, true)
+	a.RecordParent(false, true, false)
+	if a.EclipsedParents != (Parents{Blocks: 2, Stale: 1, Malicious: 1}) || a.HonestParents != (Parents{Blocks: 1, Stale: 1}) {
+		t.Errorf("eclipsed %+v, honest %+v", a.EclipsedParents, a.HonestParents)
+	}
+}
@@ -11,6 +11,14 @@ const (
 	ShardBlockProductionEvent
 	MessageEvent
 	MetricsEvent
+	FraudDetectionEvent
+	FinalityEvent
+	BeaconBlockEvent
+	GossipEvent
+	PartitionEvent
+	PartitionCheckEvent
+	HeaderLagEvent
+	CorruptionEvent
 )
 
 type Event struct {
@@ -46,11 +54,12 @@ func (eq *EventQueue) Push(x interface{}) {
 	*eq = append(*eq, x.(*Event))
 }
 
+// Pop removes the last element, heap.Pop has already swapped the earliest event there
 func (eq *EventQueue) Pop() interface{} {
 	old := *eq
 	n := len(old)
-	item := old[0]
-	old[0] = old[n-1]
+	item := old[n-1]
+	old[n-1] = nil
 	*eq = old[0 : n-1]
 	return item
 }
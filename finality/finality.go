@@ -0,0 +1,186 @@
+// finality/finality.go
+
+package finality
+
+import (
+	"math"
+	"sharding/block"
+	"sharding/config"
+	"sharding/forkchoice"
+	"sharding/node"
+	"sharding/utils"
+	"sort"
+)
+
+// FinalityRule decides what the production of a block makes final. Decide returns the block
+// that becomes final and the delay in milliseconds until it does, or nil when nothing does.
+// members are the regular nodes assigned to the shard when blk is produced and now is the
+// production time.
+type FinalityRule interface {
+	Decide(tree *forkchoice.BlockTree, head *block.Block, blk *block.Block, members []*node.Node, now float64) (*block.Block, float64)
+}
+
+// NewFinalityRule returns the rule configured in cfg, or nil when shard blocks never become final
+func NewFinalityRule(cfg *config.Config) FinalityRule {
+	switch cfg.Finality {
+	case config.KConfirmations:
+		return &KConfirmationsRule{K: cfg.FinalityConfirmations}
+	case config.BFTCommittee:
+		return &BFTCommitteeRule{Config: cfg}
+	case config.BeaconFinality:
+		return &BeaconRule{Config: cfg}
+	default:
+		return nil
+	}
+}
+
+// Quorum returns the share of the committee whose votes a block must exceed. Shares outside
+// (0, 1), like the zero value of a configuration that leaves it out, fall back to
+// config.FinalityQuorum.
+func Quorum(cfg *config.Config) float64 {
+	if cfg.FinalityQuorum <= 0 || cfg.FinalityQuorum >= 1 {
+		return config.FinalityQuorum
+	}
+	return cfg.FinalityQuorum
+}
+
+// StallThreshold returns the malicious share above which stalls are reported separately, falling
+// back to config.FinalityStallThreshold outside (0, 1)
+func StallThreshold(cfg *config.Config) float64 {
+	if cfg.FinalityStallThreshold <= 0 || cfg.FinalityStallThreshold >= 1 {
+		return config.FinalityStallThreshold
+	}
+	return cfg.FinalityStallThreshold
+}
+
+// Timeout returns the time after which a block that is not final counts as stalled, falling back
+// to config.FinalityTimeout when it is not positive
+func Timeout(cfg *config.Config) int64 {
+	if cfg.FinalityTimeout <= 0 {
+		return config.FinalityTimeout
+	}
+	return cfg.FinalityTimeout
+}
+
+// KConfirmationsRule finalises a block once K blocks have been built on top of it on the canonical chain
+type KConfirmationsRule struct {
+	K int
+}
+
+func (r *KConfirmationsRule) Decide(tree *forkchoice.BlockTree, head *block.Block, blk *block.Block, members []*node.Node, now float64) (*block.Block, float64) {
+	if !tree.IsAncestor(blk, head) {
+		return nil, 0
+	}
+	confirmed := blk
+	for i := 0; i < r.K && confirmed != nil; i++ {
+		confirmed = tree.Parent(confirmed)
+	}
+	if confirmed == nil || confirmed.Hash == tree.Genesis.Hash {
+		return nil, 0
+	}
+	return confirmed, 0
+}
+
+// BFTCommitteeRule runs a prevote and a precommit round among the shard members for every new
+// block. Honest members only vote for honest blocks and malicious members only for malicious ones,
+// so a block is final once more than FinalityQuorum of the committee votes for it, and stalls otherwise.
+type BFTCommitteeRule struct {
+	Config *config.Config
+}
+
+func (r *BFTCommitteeRule) Decide(tree *forkchoice.BlockTree, head *block.Block, blk *block.Block, members []*node.Node, now float64) (*block.Block, float64) {
+	voters := 0
+	for _, n := range members {
+		if n.IsHonest != blk.IsMalicious {
+			voters++
+		}
+	}
+	// Smallest number of votes above the quorum
+	quorum := int(math.Floor(Quorum(r.Config)*float64(len(members)))) + 1
+	if len(members) == 0 || voters < quorum {
+		return nil, 0
+	}
+
+	// Each round ends when the quorum-th vote arrives
+	delay := 0.0
+	for round := 0; round < 2; round++ {
+		voteDelays := make([]float64, voters)
+		for i := range voteDelays {
+			voteDelays[i] = utils.SimulateNetworkVoteDelay(r.Config, len(members))
+		}
+		sort.Float64s(voteDelays)
+		delay += voteDelays[quorum-1]
+	}
+	return blk, delay
+}
+
+// BeaconRule finalises a block once its header is included in the next beacon block after it
+// reaches the beacon chain. With an explicit beacon chain the simulation finalises the crosslinked
+// blocks itself when each beacon block is produced, so the rule only estimates the inclusion without one.
+type BeaconRule struct {
+	Config *config.Config
+}
+
+func (r *BeaconRule) Decide(tree *forkchoice.BlockTree, head *block.Block, blk *block.Block, members []*node.Node, now float64) (*block.Block, float64) {
+	if r.Config.EnableBeaconChain || r.Config.BeaconBlockInterval <= 0 {
+		return nil, 0
+	}
+	arrival := now + utils.SimulateNetworkBlockHeaderDelay(r.Config)/1000.0
+	interval := float64(r.Config.BeaconBlockInterval)
+	inclusion := math.Ceil(arrival/interval) * interval
+	return blk, (inclusion - now) * 1000.0
+}
+
+// Gadget tracks finality for the blocks of one shard
+type Gadget struct {
+	ShardID int
+	Rule    FinalityRule
+	// Finalisation time of every final block by hash
+	FinalizedAt map[int]float64
+	// Share of malicious members in the shard when each block was produced, by hash
+	MaliciousShare map[int]float64
+}
+
+func NewGadget(shardID int, rule FinalityRule) *Gadget {
+	return &Gadget{
+		ShardID:        shardID,
+		Rule:           rule,
+		FinalizedAt:    make(map[int]float64),
+		MaliciousShare: make(map[int]float64),
+	}
+}
+
+// BlockProduced records the shard composition at the production of blk and asks the rule what
+// becomes final, see FinalityRule
+func (g *Gadget) BlockProduced(tree *forkchoice.BlockTree, head *block.Block, blk *block.Block, members []*node.Node, now float64) (*block.Block, float64) {
+	malicious := 0
+	for _, n := range members {
+		if !n.IsHonest {
+			malicious++
+		}
+	}
+	if len(members) > 0 {
+		g.MaliciousShare[blk.Hash] = float64(malicious) / float64(len(members))
+	}
+	return g.Rule.Decide(tree, head, blk, members, now)
+}
+
+// Finalize marks blk and every ancestor that is not final yet as final at time t, down to the
+// first malicious ancestor. The rule decided on blk alone, so honest votes for it do not vouch
+// for a malicious block below it. It returns the number of blocks that became final.
+func (g *Gadget) Finalize(tree *forkchoice.BlockTree, blk *block.Block, t float64) int {
+	finalized := 0
+	for b := blk; b != nil && b.Hash != tree.Genesis.Hash; b = tree.Parent(b) {
+		if g.IsFinal(b.Hash) || (b != blk && b.IsMalicious) {
+			break
+		}
+		g.FinalizedAt[b.Hash] = t
+		finalized++
+	}
+	return finalized
+}
+
+func (g *Gadget) IsFinal(hash int) bool {
+	_, final := g.FinalizedAt[hash]
+	return final
+}
@@ -0,0 +1,157 @@
+// finality/finality_test.go
+
+package finality
+
+import (
+	"sharding/block"
+	"sharding/config"
+	"sharding/forkchoice"
+	"sharding/node"
+	"testing"
+)
+
+// chain builds a tree holding blocks 1 to n on top of each other, block i with hash i, and a
+// competing block of hash 100 on top of block 1. malicious lists the blocks of malicious producers.
+func chain(n int, malicious ...int) *forkchoice.BlockTree {
+	tree := forkchoice.NewBlockTree(0)
+	bad := make(map[int]bool)
+	for _, id := range malicious {
+		bad[id] = true
+	}
+	for id := 1; id <= n; id++ {
+		tree.AddBlock(&block.Block{ID: id, Hash: id, PreviousHash: id - 1, IsMalicious: bad[id]})
+	}
+	tree.AddBlock(&block.Block{ID: 2, Hash: 100, PreviousHash: 1})
+	return tree
+}
+
+func committee(honest, malicious int) []*node.Node {
+	members := make([]*node.Node, 0, honest+malicious)
+	for i := 0; i < honest+malicious; i++ {
+		members = append(members, &node.Node{ID: i, IsHonest: i < honest})
+	}
+	return members
+}
+
+func TestKConfirmations(t *testing.T) {
+	tree := chain(5)
+	head := tree.Block(5)
+	rule := &KConfirmationsRule{K: 2}
+
+	if final, _ := rule.Decide(tree, head, head, nil, 0); final == nil || final.Hash != 3 {
+		t.Errorf("two blocks on top of block 3 did not finalise it, got %v", final)
+	}
+	if final, _ := rule.Decide(tree, head, tree.Block(100), nil, 0); final != nil {
+		t.Errorf("block off the canonical chain finalised block %d", final.Hash)
+	}
+	// Genesis is final from the start and never reported
+	if final, _ := (&KConfirmationsRule{K: 5}).Decide(tree, head, head, nil, 0); final != nil {
+		t.Errorf("five confirmations over five blocks finalised block %d", final.Hash)
+	}
+}
+
+func TestBFTCommitteeNeedsQuorum(t *testing.T) {
+	cfg := &config.Config{
+		FinalityQuorum:      2.0 / 3.0,
+		MinNetworkDelayMean: 50,
+		MaxNetworkDelayMean: 50,
+		MinGossipFanout:     4,
+		MaxGossipFanout:     4,
+		NetworkBandwidth:    10,
+		VoteSize:            200,
+	}
+	rule := &BFTCommitteeRule{Config: cfg}
+	tree := chain(1)
+	honestBlock := tree.Block(1)
+	maliciousBlock := &block.Block{ID: 1, Hash: 7, IsMalicious: true}
+
+	tests := []struct {
+		honest, malicious int
+		blk               *block.Block
+		final             bool
+	}{
+		{4, 0, honestBlock, true},
+		{3, 1, honestBlock, true},
+		{3, 3, honestBlock, false},
+		{2, 2, honestBlock, false},
+		{1, 3, maliciousBlock, true},
+		{3, 1, maliciousBlock, false},
+		{0, 0, honestBlock, false},
+	}
+	for _, tt := range tests {
+		final, delay := rule.Decide(tree, honestBlock, tt.blk, committee(tt.honest, tt.malicious), 0)
+		if (final != nil) != tt.final {
+			t.Errorf("%d honest and %d malicious members, malicious block %v: final %v, want %v",
+				tt.honest, tt.malicious, tt.blk.IsMalicious, final != nil, tt.final)
+		}
+		// Two voting rounds of at least one 50ms hop each
+		if final != nil && delay < 100 {
+			t.Errorf("finalised after %.1fms, want two rounds of votes", delay)
+		}
+	}
+}
+
+func TestFinalizeStopsAtFinalAncestor(t *testing.T) {
+	tree := chain(5)
+	g := NewGadget(0, &KConfirmationsRule{K: 1})
+
+	if n := g.Finalize(tree, tree.Block(3), 10); n != 3 {
+		t.Errorf("finalising block 3 made %d blocks final, want 3", n)
+	}
+	if n := g.Finalize(tree, tree.Block(5), 20); n != 2 {
+		t.Errorf("finalising block 5 made %d blocks final, want 2", n)
+	}
+	if g.FinalizedAt[1] != 10 || g.FinalizedAt[5] != 20 || g.IsFinal(tree.Genesis.Hash) {
+		t.Errorf("finalisation times %v", g.FinalizedAt)
+	}
+}
+
+func TestGadgetRecordsMaliciousShare(t *testing.T) {
+	tree := chain(1)
+	g := NewGadget(0, &KConfirmationsRule{K: 1})
+	g.BlockProduced(tree, tree.Block(1), tree.Block(1), committee(3, 1), 0)
+	if share := g.MaliciousShare[1]; share != 0.25 {
+		t.Errorf("malicious share %v, want 0.25", share)
+	}
+	if NewFinalityRule(&config.Config{Finality: config.NoFinality}) != nil {
+		t.Error("blocks become final without a finality rule")
+	}
+}
+
+func TestFinalizeNeverReachesMaliciousAncestor(t *testing.T) {
+	tree := chain(5, 2)
+	g := NewGadget(0, &KConfirmationsRule{K: 1})
+
+	if n := g.Finalize(tree, tree.Block(5), 10); n != 3 {
+		t.Errorf("finalising block 5 over malicious block 2 made %d blocks final, want 3", n)
+	}
+	if g.IsFinal(2) || g.IsFinal(1) {
+		t.Error("finality went past the malicious block")
+	}
+	// A malicious block the committee itself votes through is final
+	if n := g.Finalize(tree, tree.Block(2), 20); n != 2 || !g.IsFinal(2) {
+		t.Errorf("finalising malicious block 2 made %d blocks final, want 2", n)
+	}
+}
+
+func TestSettingsFallBackToDefaults(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{},
+		{FinalityQuorum: 1, FinalityStallThreshold: -0.5, FinalityTimeout: -3},
+	} {
+		if q := Quorum(cfg); q != config.FinalityQuorum {
+			t.Errorf("quorum %v from %v, want the default %v", q, cfg.FinalityQuorum, config.FinalityQuorum)
+		}
+		if s := StallThreshold(cfg); s != config.FinalityStallThreshold {
+			t.Errorf("stall threshold %v from %v, want the default", s, cfg.FinalityStallThreshold)
+		}
+		if d := Timeout(cfg); d != config.FinalityTimeout {
+			t.Errorf("timeout %d from %d, want the default", d, cfg.FinalityTimeout)
+		}
+	}
+
+	cfg := &config.Config{FinalityQuorum: 0.5, FinalityStallThreshold: 0.2, FinalityTimeout: 9}
+	if Quorum(cfg) != 0.5 || StallThreshold(cfg) != 0.2 || Timeout(cfg) != 9 {
+		t.Error("settings inside their range were replaced")
+	}
+}
@@ -0,0 +1,342 @@
+// forkchoice/forkchoice.go
+
+package forkchoice
+
+import (
+	"sharding/block"
+	"sharding/config"
+)
+
+// BlockTree keeps every block a node has seen for one shard, indexed by hash.
+// Blocks whose parent is not known yet are parked until the parent arrives.
+type BlockTree struct {
+	Genesis *block.Block
+	entries map[int]*treeEntry
+	pending map[int][]*block.Block
+	// Chain height and stake never change once a block is in, so the best tips are kept as blocks arrive
+	highest  *treeEntry
+	heaviest *treeEntry
+	// Subtree sizes are only maintained once a GHOST rule has asked for them
+	trackSubtrees bool
+	// Hashes proven fraudulent, kept so late arrivals are rejected as well
+	invalid map[int]bool
+}
+
+type treeEntry struct {
+	blk         *block.Block
+	parent      *treeEntry
+	children    []*treeEntry
+	subtreeSize int
+	chainStake  int
+	arrivalRank int
+	anchored    bool
+	invalid     bool
+}
+
+func NewBlockTree(shardID int) *BlockTree {
+	genesis := &treeEntry{blk: block.NewGenesisBlock(shardID), subtreeSize: 1}
+	t := &BlockTree{
+		Genesis:  genesis.blk,
+		entries:  map[int]*treeEntry{genesis.blk.Hash: genesis},
+		pending:  make(map[int][]*block.Block),
+		highest:  genesis,
+		heaviest: genesis,
+		invalid:  make(map[int]bool),
+	}
+	return t
+}
+
+// AddBlock inserts a block and any parked descendants. It returns false if the block
+// was already known or its parent is still missing.
+func (t *BlockTree) AddBlock(blk *block.Block) bool {
+	if t.Contains(blk.Hash) {
+		return false
+	}
+	parent, exists := t.entries[blk.PreviousHash]
+	if !exists {
+		for _, p := range t.pending[blk.PreviousHash] {
+			if p.Hash == blk.Hash {
+				return false
+			}
+		}
+		t.pending[blk.PreviousHash] = append(t.pending[blk.PreviousHash], blk)
+		return false
+	}
+
+	t.insert(blk, parent, false)
+	return true
+}
+
+// AddSyncedBlock inserts a block fetched while syncing from peers. The node never saw the
+// history below the synced range, so a block with an unknown parent hangs off genesis.
+func (t *BlockTree) AddSyncedBlock(blk *block.Block) bool {
+	if t.Contains(blk.Hash) {
+		return false
+	}
+	if parent, exists := t.entries[blk.PreviousHash]; exists {
+		t.insert(blk, parent, false)
+	} else {
+		t.insert(blk, t.entries[t.Genesis.Hash], true)
+	}
+	return true
+}
+
+func (t *BlockTree) insert(blk *block.Block, parent *treeEntry, anchored bool) {
+	entry := &treeEntry{
+		blk:         blk,
+		parent:      parent,
+		subtreeSize: 1,
+		// Blocks missing between a synced block and genesis are assumed to carry the same stake
+		chainStake:  parent.chainStake + blk.ProducerStake*(blk.ID-parent.blk.ID),
+		arrivalRank: len(t.entries),
+		anchored:    anchored,
+		invalid:     parent.invalid || t.invalid[blk.Hash],
+	}
+	t.entries[blk.Hash] = entry
+	parent.children = append(parent.children, entry)
+
+	if !entry.invalid {
+		t.updateBest(entry)
+		if t.trackSubtrees {
+			for p := parent; p != nil; p = p.parent {
+				p.subtreeSize++
+			}
+		}
+	}
+
+	// Attach blocks that were waiting for this one
+	children := t.pending[blk.Hash]
+	delete(t.pending, blk.Hash)
+	for _, child := range children {
+		if !t.Contains(child.Hash) {
+			t.insert(child, entry, false)
+		}
+	}
+}
+
+// updateBest moves the best tips to entry if it beats them. Ties keep the tip that arrived first.
+func (t *BlockTree) updateBest(entry *treeEntry) {
+	if entry.blk.ID > t.highest.blk.ID ||
+		(entry.blk.ID == t.highest.blk.ID && entry.arrivalRank < t.highest.arrivalRank) {
+		t.highest = entry
+	}
+	if entry.chainStake > t.heaviest.chainStake ||
+		(entry.chainStake == t.heaviest.chainStake && entry.arrivalRank < t.heaviest.arrivalRank) {
+		t.heaviest = entry
+	}
+}
+
+// Invalidate marks a block and everything built on it as invalid, so no fork-choice rule
+// follows them any more. It returns the blocks that were in the tree and became invalid.
+func (t *BlockTree) Invalidate(hash int) []*block.Block {
+	t.invalid[hash] = true
+	root, exists := t.entries[hash]
+	if !exists || root.invalid || root.parent == nil {
+		return nil
+	}
+
+	reverted := make([]*block.Block, 0)
+	stack := []*treeEntry{root}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.invalid {
+			continue
+		}
+		e.invalid = true
+		reverted = append(reverted, e.blk)
+		stack = append(stack, e.children...)
+	}
+
+	if t.trackSubtrees {
+		for p := root.parent; p != nil; p = p.parent {
+			p.subtreeSize -= root.subtreeSize
+		}
+	}
+
+	// The best tips may have been reverted, search the remaining valid blocks again
+	genesis := t.entries[t.Genesis.Hash]
+	t.highest, t.heaviest = genesis, genesis
+	for _, e := range t.entries {
+		if !e.invalid {
+			t.updateBest(e)
+		}
+	}
+	return reverted
+}
+
+// CountDescendants returns how many blocks in the tree are built on top of the given block
+func (t *BlockTree) CountDescendants(hash int) int {
+	root, exists := t.entries[hash]
+	if !exists {
+		return 0
+	}
+	count := 0
+	stack := append([]*treeEntry{}, root.children...)
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		count++
+		stack = append(stack, e.children...)
+	}
+	return count
+}
+
+// IsInvalid reports whether a block was proven fraudulent or built on a fraudulent block
+func (t *BlockTree) IsInvalid(hash int) bool {
+	if entry, exists := t.entries[hash]; exists {
+		return entry.invalid
+	}
+	return t.invalid[hash]
+}
+
+func (t *BlockTree) Contains(hash int) bool {
+	_, exists := t.entries[hash]
+	return exists
+}
+
+// Block returns the block with the given hash, or nil if the tree does not hold it
+func (t *BlockTree) Block(hash int) *block.Block {
+	if entry, exists := t.entries[hash]; exists {
+		return entry.blk
+	}
+	return nil
+}
+
+// Parent returns the parent of a block in the tree, or nil for the genesis block
+func (t *BlockTree) Parent(blk *block.Block) *block.Block {
+	entry, exists := t.entries[blk.Hash]
+	if !exists || entry.parent == nil {
+		return nil
+	}
+	return entry.parent.blk
+}
+
+// Children returns the known children of a block in arrival order
+func (t *BlockTree) Children(blk *block.Block) []*block.Block {
+	entry, exists := t.entries[blk.Hash]
+	if !exists {
+		return nil
+	}
+	children := make([]*block.Block, len(entry.children))
+	for i, child := range entry.children {
+		children[i] = child.blk
+	}
+	return children
+}
+
+// IsAnchored reports whether a synced block was attached to genesis because its parent was unknown
+func (t *BlockTree) IsAnchored(blk *block.Block) bool {
+	entry, exists := t.entries[blk.Hash]
+	return exists && entry.anchored
+}
+
+// IsAncestor reports whether ancestor lies on the path from genesis to blk (inclusive)
+func (t *BlockTree) IsAncestor(ancestor, blk *block.Block) bool {
+	for e := t.entries[blk.Hash]; e != nil && e.blk.ID >= ancestor.ID; e = e.parent {
+		if e.blk.Hash == ancestor.Hash {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonAncestor returns the highest block shared by the chains ending in a and b
+func (t *BlockTree) CommonAncestor(a, b *block.Block) *block.Block {
+	ea, eb := t.entries[a.Hash], t.entries[b.Hash]
+	if ea == nil || eb == nil {
+		return t.Genesis
+	}
+	// IDs strictly decrease towards genesis, so always step back on the higher side
+	for ea != eb {
+		if ea.blk.ID >= eb.blk.ID {
+			ea = ea.parent
+		} else {
+			eb = eb.parent
+		}
+	}
+	return ea.blk
+}
+
+// Chain returns the blocks from the first block after genesis up to head
+func (t *BlockTree) Chain(head *block.Block) []*block.Block {
+	chain := make([]*block.Block, 0, head.ID)
+	for e := t.entries[head.Hash]; e != nil && e.parent != nil; e = e.parent {
+		chain = append(chain, e.blk)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Len returns the number of blocks in the tree, genesis excluded
+func (t *BlockTree) Len() int {
+	return len(t.entries) - 1
+}
+
+// ForkChoiceRule picks the head of the canonical chain out of a block tree
+type ForkChoiceRule interface {
+	Head(t *BlockTree) *block.Block
+}
+
+func NewForkChoiceRule(rule config.ForkChoiceRule) ForkChoiceRule {
+	switch rule {
+	case config.GHOST:
+		return &GHOSTRule{}
+	case config.HeaviestStake:
+		return &HeaviestStakeRule{}
+	default:
+		return &LongestChainRule{}
+	}
+}
+
+// LongestChainRule follows the highest block, keeping the first one seen on ties
+type LongestChainRule struct{}
+
+func (r *LongestChainRule) Head(t *BlockTree) *block.Block {
+	return t.highest.blk
+}
+
+// HeaviestStakeRule follows the block whose chain carries the most producer stake. Stake comes from
+// config.StakeDistribution, so with uniform stake it picks the same head as LongestChainRule.
+type HeaviestStakeRule struct{}
+
+func (r *HeaviestStakeRule) Head(t *BlockTree) *block.Block {
+	return t.heaviest.blk
+}
+
+// GHOSTRule walks down from genesis, always entering the child with the heaviest subtree
+type GHOSTRule struct{}
+
+func (r *GHOSTRule) Head(t *BlockTree) *block.Block {
+	if !t.trackSubtrees {
+		t.countSubtrees(t.entries[t.Genesis.Hash])
+		t.trackSubtrees = true
+	}
+
+	head := t.entries[t.Genesis.Hash]
+	for {
+		var best *treeEntry
+		for _, child := range head.children {
+			if !child.invalid && (best == nil || child.subtreeSize > best.subtreeSize) {
+				best = child
+			}
+		}
+		if best == nil {
+			return head.blk
+		}
+		head = best
+	}
+}
+
+func (t *BlockTree) countSubtrees(e *treeEntry) int {
+	if e.invalid {
+		return 0
+	}
+	e.subtreeSize = 1
+	for _, child := range e.children {
+		e.subtreeSize += t.countSubtrees(child)
+	}
+	return e.subtreeSize
+}
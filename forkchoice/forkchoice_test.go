@@ -0,0 +1,152 @@
+// forkchoice/forkchoice_test.go
+
+package forkchoice
+
+import (
+	"sharding/block"
+	"sharding/config"
+	"testing"
+)
+
+// add inserts a block of the given height, hash, parent hash and producer stake
+func add(tree *BlockTree, id, hash, parent, stake int) *block.Block {
+	blk := &block.Block{ID: id, Hash: hash, PreviousHash: parent, ProducerStake: stake}
+	tree.AddBlock(blk)
+	return blk
+}
+
+// forkedTree grows three branches off genesis, each favoured by a different rule:
+//
+//	long:  1 -> 2 -> 3     the highest tip, three blocks of stake 1
+//	heavy: 4               a single block of stake 10
+//	bushy: 5 -> {6, 7, 8}  the largest subtree, four blocks of stake 1
+func forkedTree() *BlockTree {
+	tree := NewBlockTree(0)
+	add(tree, 1, 1, 0, 1)
+	add(tree, 2, 2, 1, 1)
+	add(tree, 3, 3, 2, 1)
+	add(tree, 1, 4, 0, 10)
+	add(tree, 1, 5, 0, 1)
+	add(tree, 2, 6, 5, 1)
+	add(tree, 2, 7, 5, 1)
+	add(tree, 2, 8, 5, 1)
+	return tree
+}
+
+func TestRulesPickTheirOwnBranch(t *testing.T) {
+	tree := forkedTree()
+	for rule, want := range map[config.ForkChoiceRule]int{
+		config.LongestChain:  3,
+		config.HeaviestStake: 4,
+		config.GHOST:         6,
+	} {
+		if head := NewForkChoiceRule(rule).Head(tree); head.Hash != want {
+			t.Errorf("%v: head %d, want %d", rule, head.Hash, want)
+		}
+	}
+}
+
+func TestGHOSTCountsBlocksAddedAfterFirstUse(t *testing.T) {
+	tree := forkedTree()
+	rule := NewForkChoiceRule(config.GHOST)
+	rule.Head(tree)
+
+	// Growing the long branch to five blocks outweighs the bushy subtree of four
+	add(tree, 4, 9, 3, 1)
+	add(tree, 5, 10, 9, 1)
+	if head := rule.Head(tree); head.Hash != 10 {
+		t.Errorf("head %d after extending the long branch, want 10", head.Hash)
+	}
+}
+
+func TestTiesKeepTheFirstTip(t *testing.T) {
+	tree := NewBlockTree(0)
+	add(tree, 1, 1, 0, 2)
+	add(tree, 1, 2, 0, 2)
+	for _, rule := range []config.ForkChoiceRule{config.LongestChain, config.HeaviestStake, config.GHOST} {
+		if head := NewForkChoiceRule(rule).Head(tree); head.Hash != 1 {
+			t.Errorf("%v: head %d on a tie, want the first block 1", rule, head.Hash)
+		}
+	}
+}
+
+func TestOrphanWaitsForItsParent(t *testing.T) {
+	tree := NewBlockTree(0)
+	if tree.AddBlock(&block.Block{ID: 2, Hash: 2, PreviousHash: 1}) {
+		t.Fatal("block with an unknown parent was inserted")
+	}
+	if tree.Contains(2) || tree.Len() != 0 {
+		t.Fatalf("orphan is in the tree before its parent, %d blocks", tree.Len())
+	}
+
+	add(tree, 1, 1, 0, 1)
+	if !tree.Contains(2) || tree.Parent(tree.Block(2)).Hash != 1 {
+		t.Fatal("orphan was not attached when its parent arrived")
+	}
+	if head := NewForkChoiceRule(config.LongestChain).Head(tree); head.Hash != 2 {
+		t.Errorf("head %d, want the attached orphan 2", head.Hash)
+	}
+}
+
+func TestSyncedBlockHangsOffGenesis(t *testing.T) {
+	tree := NewBlockTree(0)
+	add(tree, 1, 1, 0, 3)
+	synced := &block.Block{ID: 5, Hash: 50, PreviousHash: 49, ProducerStake: 1}
+	if !tree.AddSyncedBlock(synced) {
+		t.Fatal("synced block was not inserted")
+	}
+	if !tree.IsAnchored(synced) || tree.Parent(synced) != tree.Genesis {
+		t.Error("synced block with an unknown parent is not anchored at genesis")
+	}
+	// The five missing blocks below it count with its stake, 5 against 3
+	if head := NewForkChoiceRule(config.HeaviestStake).Head(tree); head.Hash != 50 {
+		t.Errorf("heaviest head %d, want the synced block 50", head.Hash)
+	}
+	if ancestor := tree.CommonAncestor(synced, tree.Block(1)); ancestor != tree.Genesis {
+		t.Errorf("common ancestor %d, want genesis", ancestor.Hash)
+	}
+}
+
+func TestInvalidatedBranchIsAbandoned(t *testing.T) {
+	tree := forkedTree()
+	longest := NewForkChoiceRule(config.LongestChain)
+	heaviest := NewForkChoiceRule(config.HeaviestStake)
+	ghost := NewForkChoiceRule(config.GHOST)
+	ghost.Head(tree)
+
+	if reverted := tree.Invalidate(5); len(reverted) != 4 {
+		t.Fatalf("invalidating the bushy branch reverted %d blocks, want 4", len(reverted))
+	}
+	if head := ghost.Head(tree); head.Hash != 3 {
+		t.Errorf("GHOST head %d without the bushy branch, want 3", head.Hash)
+	}
+
+	tree.Invalidate(4)
+	tree.Invalidate(2)
+	// Only genesis and block 1 are left valid
+	for _, rule := range []ForkChoiceRule{longest, heaviest, ghost} {
+		if head := rule.Head(tree); head.Hash != 1 {
+			t.Errorf("%T: head %d, want 1", rule, head.Hash)
+		}
+	}
+	if !tree.IsInvalid(3) || tree.IsInvalid(1) {
+		t.Error("invalidity did not follow the branch below block 2")
+	}
+}
+
+func TestBlockProvenInvalidBeforeItArrives(t *testing.T) {
+	tree := forkedTree()
+	tree.Invalidate(9)
+	add(tree, 4, 9, 3, 1)
+	add(tree, 5, 10, 9, 1)
+
+	if !tree.IsInvalid(9) || !tree.IsInvalid(10) {
+		t.Fatal("late block of a fraudulent hash, or its child, is valid")
+	}
+	if head := NewForkChoiceRule(config.LongestChain).Head(tree); head.Hash != 3 {
+		t.Errorf("head %d, want 3 below the fraudulent block", head.Hash)
+	}
+	if n := tree.CountDescendants(3); n != 2 {
+		t.Errorf("%d descendants of block 3, want 2 whether valid or not", n)
+	}
+}
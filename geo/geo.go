@@ -0,0 +1,162 @@
+// geo/geo.go
+
+package geo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sharding/config"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map places every node in a region. Messages between two nodes take half the round-trip
+// time between their regions.
+type Map struct {
+	Regions []string
+	// RTT[i][j] is the round-trip time from region i to region j in milliseconds
+	RTT        [][]float64
+	NodeRegion map[int]int
+}
+
+// LoadMatrix reads the regions and their RTT matrix. A .json file holds
+// {"regions": [...], "rtt": [[...], ...]}, any other file is a CSV whose header row names the
+// regions after an empty first cell and whose rows start with a region name.
+func LoadMatrix(path string) (*Map, error) {
+	var m *Map
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		m, err = loadJSON(path)
+	} else {
+		m, err = loadCSV(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Regions) == 0 {
+		return nil, fmt.Errorf("region latency matrix has no regions")
+	}
+	if len(m.RTT) != len(m.Regions) {
+		return nil, fmt.Errorf("region latency matrix has %d rows for %d regions", len(m.RTT), len(m.Regions))
+	}
+	for i, row := range m.RTT {
+		if len(row) != len(m.Regions) {
+			return nil, fmt.Errorf("row %s of the region latency matrix has %d columns for %d regions", m.Regions[i], len(row), len(m.Regions))
+		}
+	}
+	m.NodeRegion = make(map[int]int)
+	return m, nil
+}
+
+func loadJSON(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open region latency matrix: %v", err)
+	}
+	var file struct {
+		Regions []string    `json:"regions"`
+		RTT     [][]float64 `json:"rtt"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse region latency matrix: %v", err)
+	}
+	return &Map{Regions: file.Regions, RTT: file.RTT}, nil
+}
+
+func loadCSV(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open region latency matrix: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read region latency matrix: %v", err)
+	}
+	if len(records) == 0 {
+		return &Map{}, nil
+	}
+
+	m := &Map{Regions: make([]string, 0), RTT: make([][]float64, 0)}
+	for _, name := range records[0][1:] {
+		m.Regions = append(m.Regions, strings.TrimSpace(name))
+	}
+	for lineNum, record := range records[1:] {
+		if lineNum >= len(m.Regions) || strings.TrimSpace(record[0]) != m.Regions[lineNum] {
+			return nil, fmt.Errorf("row %d of the region latency matrix does not follow the header order", lineNum+1)
+		}
+		row := make([]float64, 0, len(record)-1)
+		for _, field := range record[1:] {
+			rtt, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RTT in row %d of the region latency matrix: %v", lineNum+1, err)
+			}
+			row = append(row, rtt)
+		}
+		m.RTT = append(m.RTT, row)
+	}
+	return m, nil
+}
+
+// Place assigns each node to a region at random with the given weights, uniformly when
+// weights is empty. Weights beyond the number of regions are ignored.
+func (m *Map) Place(ids []int, weights []float64) {
+	cumulative := make([]float64, len(m.Regions))
+	total := 0.0
+	for i := range m.Regions {
+		w := 1.0
+		if len(weights) > 0 {
+			w = 0
+			if i < len(weights) && weights[i] > 0 {
+				w = weights[i]
+			}
+		}
+		total += w
+		cumulative[i] = total
+	}
+	if total == 0 {
+		// No usable weight, fall back to a uniform placement
+		for i := range cumulative {
+			cumulative[i] = float64(i + 1)
+		}
+		total = float64(len(cumulative))
+	}
+
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+	for _, id := range sorted {
+		target := rand.Float64() * total
+		m.NodeRegion[id] = sort.SearchFloat64s(cumulative, target)
+		if m.NodeRegion[id] >= len(m.Regions) {
+			m.NodeRegion[id] = len(m.Regions) - 1
+		}
+	}
+}
+
+// Latency returns the mean one-way latency between two nodes in milliseconds
+func (m *Map) Latency(a, b int) float64 {
+	return m.RTT[m.NodeRegion[a]][m.NodeRegion[b]] / 2
+}
+
+// Delay returns the time in milliseconds a message of size bytes sent straight from a to b takes
+func (m *Map) Delay(cfg *config.Config, a, b int, size int) float64 {
+	networkDelayStd := cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd)
+
+	// Basic delay calculation
+	delay := m.Latency(a, b) + rand.NormFloat64()*networkDelayStd/1000.0
+
+	// Add transmission delay based on message size
+	transmissionDelay := (float64(size) * 8.0) / (float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+
+	return delay + transmissionDelay
+}
@@ -0,0 +1,108 @@
+// headersync/headersync.go
+
+package headersync
+
+import (
+	"sharding/block"
+)
+
+// Request asks a peer for the headers of a shard from From to To, both included
+type Request struct {
+	Requester int
+	Peer      int
+	ShardID   int
+	From      int
+	To        int
+	SentAt    float64
+}
+
+// Response carries the requested headers the peer holds, at most one batch of them
+type Response struct {
+	Request *Request
+	Headers []*block.BlockHeader
+}
+
+// Serve answers a request from a peer's header chain of the shard
+func Serve(chain map[int]*block.BlockHeader, req *Request, batch int) *Response {
+	headers := make([]*block.BlockHeader, 0)
+	for id := req.From; id <= req.To; id++ {
+		if batch > 0 && len(headers) >= batch {
+			break
+		}
+		if header, exists := chain[id]; exists {
+			headers = append(headers, header)
+		}
+	}
+	return &Response{Request: req, Headers: headers}
+}
+
+// Gap is a range of headers a node found missing below a header whose predecessors it lacks.
+// FilledAt is -1 until the node links its header chain past To.
+type Gap struct {
+	NodeID     int
+	ShardID    int
+	From       int
+	To         int
+	DetectedAt float64
+	FilledAt   float64
+	Requests   int
+	// Attempts counts the requests since the gap last grew or shrank
+	Attempts int
+	// Pending is the request still out for the gap, nil when none is
+	Pending *Request
+}
+
+// Size returns the number of headers missing when the gap was last extended
+func (g *Gap) Size() int {
+	return g.To - g.From + 1
+}
+
+// Tracker keeps every gap detected and the traffic spent filling them
+type Tracker struct {
+	Gaps          []*Gap
+	Requests      int
+	Responses     int
+	HeadersServed int
+	// Requests that timed out before their response arrived
+	TimedOut int
+	open     map[[2]int]*Gap
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		Gaps: make([]*Gap, 0),
+		open: make(map[[2]int]*Gap),
+	}
+}
+
+// Detect records that a node misses the headers from to to of a shard. An open gap of the node
+// is extended instead of opening a new one, which lets the node try again.
+func (t *Tracker) Detect(nodeID, shardID, from, to int, now float64) *Gap {
+	key := [2]int{nodeID, shardID}
+	if gap, exists := t.open[key]; exists {
+		if to > gap.To {
+			gap.To = to
+			gap.Attempts = 0
+		}
+		return gap
+	}
+	gap := &Gap{NodeID: nodeID, ShardID: shardID, From: from, To: to, DetectedAt: now, FilledAt: -1}
+	t.open[key] = gap
+	t.Gaps = append(t.Gaps, gap)
+	return gap
+}
+
+// Open returns the open gap of a node in a shard, or nil
+func (t *Tracker) Open(nodeID, shardID int) *Gap {
+	return t.open[[2]int{nodeID, shardID}]
+}
+
+// Fill closes the open gap of a node in a shard, if any, at time now
+func (t *Tracker) Fill(nodeID, shardID int, now float64) {
+	key := [2]int{nodeID, shardID}
+	if gap, exists := t.open[key]; exists {
+		gap.FilledAt = now
+		gap.Pending = nil
+		delete(t.open, key)
+	}
+}
@@ -0,0 +1,75 @@
+// headersync/headersync_test.go
+
+package headersync
+
+import (
+	"sharding/block"
+	"testing"
+)
+
+func served(resp *Response) []int {
+	ids := make([]int, 0, len(resp.Headers))
+	for _, header := range resp.Headers {
+		ids = append(ids, header.ID)
+	}
+	return ids
+}
+
+func TestServe(t *testing.T) {
+	// The peer lacks header 4
+	chain := make(map[int]*block.BlockHeader)
+	for _, id := range []int{0, 1, 2, 3, 5, 6} {
+		chain[id] = &block.BlockHeader{ID: id}
+	}
+
+	t.Run("whole range", func(t *testing.T) {
+		got := served(Serve(chain, &Request{From: 1, To: 6}, 0))
+		if len(got) != 5 || got[0] != 1 || got[3] != 5 {
+			t.Errorf("served %v, want every held header from 1 to 6", got)
+		}
+	})
+	t.Run("batch limit", func(t *testing.T) {
+		got := served(Serve(chain, &Request{From: 2, To: 6}, 3))
+		// Missing headers are skipped without using up the batch
+		if len(got) != 3 || got[2] != 5 {
+			t.Errorf("served %v, want 2, 3 and 5", got)
+		}
+	})
+	t.Run("nothing held", func(t *testing.T) {
+		if got := served(Serve(chain, &Request{From: 7, To: 9}, 0)); len(got) != 0 {
+			t.Errorf("served %v beyond the peer's tip", got)
+		}
+	})
+	t.Run("answers its request", func(t *testing.T) {
+		req := &Request{Requester: 3, Peer: 8, From: 1, To: 1}
+		if resp := Serve(chain, req, 1); resp.Request != req {
+			t.Error("response lost its request")
+		}
+	})
+}
+
+func TestGapLifecycle(t *testing.T) {
+	tr := NewTracker()
+	gap := tr.Detect(1, 0, 5, 8, 10)
+	gap.Attempts = 2
+
+	// A second detection while the gap is open extends it and resets the attempts
+	if again := tr.Detect(1, 0, 6, 12, 11); again != gap || gap.To != 12 || gap.Attempts != 0 {
+		t.Errorf("detection into an open gap gave %+v", again)
+	}
+	if gap.Size() != 8 || gap.DetectedAt != 10 {
+		t.Errorf("gap of %d headers detected at %v, want 8 at 10", gap.Size(), gap.DetectedAt)
+	}
+	// Other shards and nodes keep their own gaps
+	if tr.Detect(1, 1, 5, 8, 11) == gap || tr.Detect(2, 0, 5, 8, 11) == gap {
+		t.Error("gap shared across shards or nodes")
+	}
+
+	tr.Fill(1, 0, 15)
+	if tr.Open(1, 0) != nil || gap.FilledAt != 15 {
+		t.Errorf("filled gap still open, filled at %v", gap.FilledAt)
+	}
+	if next := tr.Detect(1, 0, 20, 21, 30); next == gap || len(tr.Gaps) != 4 {
+		t.Errorf("new gap after filling reused the old one, %d gaps", len(tr.Gaps))
+	}
+}
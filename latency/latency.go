@@ -0,0 +1,137 @@
+// latency/latency.go
+
+package latency
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sharding/config"
+	"strconv"
+	"strings"
+)
+
+// DelayModel draws the one-way latency of a single network hop in milliseconds, transmission
+// time excluded
+type DelayModel interface {
+	Sample() float64
+}
+
+// NewDelayModel returns the model of the given distribution. Every model centres on a mean drawn
+// between MinNetworkDelayMean and MaxNetworkDelayMean with a spread drawn between
+// MinNetworkDelayStd and MaxNetworkDelayStd, both in milliseconds, except the empirical one
+// which replays cfg.DelayTrace. Without samples the empirical model falls back to the normal one.
+func NewDelayModel(cfg *config.Config, distribution config.DelayDistribution) DelayModel {
+	mean := cfg.MinNetworkDelayMean + rand.Float64()*(cfg.MaxNetworkDelayMean-cfg.MinNetworkDelayMean)
+	std := cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd)
+
+	switch distribution {
+	case config.LogNormalDelay:
+		return &LogNormal{Mean: mean, Std: std}
+	case config.ParetoDelay:
+		shape := cfg.ParetoShape
+		if shape <= 0 {
+			shape = config.ParetoShape
+		}
+		return &Pareto{Mean: mean, Shape: shape}
+	case config.ShiftedExponentialDelay:
+		return &ShiftedExponential{Mean: mean, Std: std}
+	case config.EmpiricalDelay:
+		if len(cfg.DelayTrace) > 0 {
+			return &Empirical{Samples: cfg.DelayTrace}
+		}
+	}
+	return &Normal{Mean: mean, Std: std}
+}
+
+// Normal is a Gaussian with the given mean and standard deviation, cut off at zero
+type Normal struct {
+	Mean float64
+	Std  float64
+}
+
+func (m *Normal) Sample() float64 {
+	return max(0, m.Mean+rand.NormFloat64()*m.Std)
+}
+
+// LogNormal is a right-skewed distribution with the given mean and standard deviation
+type LogNormal struct {
+	Mean float64
+	Std  float64
+}
+
+func (m *LogNormal) Sample() float64 {
+	if m.Mean <= 0 {
+		return 0
+	}
+	sigma2 := math.Log(1 + (m.Std*m.Std)/(m.Mean*m.Mean))
+	mu := math.Log(m.Mean) - sigma2/2
+	return math.Exp(mu + rand.NormFloat64()*math.Sqrt(sigma2))
+}
+
+// Pareto is a heavy-tailed distribution with the given mean. The lower the shape, the heavier
+// the tail. Shapes of 1 or less have no finite mean and are raised just above 1.
+type Pareto struct {
+	Mean  float64
+	Shape float64
+}
+
+func (m *Pareto) Sample() float64 {
+	shape := max(m.Shape, 1.01)
+	scale := m.Mean * (shape - 1) / shape
+	return scale / math.Pow(1-rand.Float64(), 1/shape)
+}
+
+// ShiftedExponential never goes below Mean - Std and adds an exponential tail of mean Std on top
+type ShiftedExponential struct {
+	Mean float64
+	Std  float64
+}
+
+func (m *ShiftedExponential) Sample() float64 {
+	shift := max(0, m.Mean-m.Std)
+	return shift + rand.ExpFloat64()*(m.Mean-shift)
+}
+
+// Empirical replays measured round-trip times, halved into one-way latencies
+type Empirical struct {
+	Samples []float64
+}
+
+func (m *Empirical) Sample() float64 {
+	return m.Samples[rand.Intn(len(m.Samples))] / 2
+}
+
+// LoadTrace reads RTT samples in milliseconds from a file holding one or more per line,
+// separated by commas or whitespace. Fields that are not numbers, like a header, are skipped.
+func LoadTrace(path string) ([]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open delay trace: %v", err)
+	}
+	defer f.Close()
+
+	samples := make([]float64, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t'
+		})
+		for _, field := range fields {
+			rtt, err := strconv.ParseFloat(field, 64)
+			if err != nil || rtt < 0 || math.IsNaN(rtt) || math.IsInf(rtt, 0) {
+				continue
+			}
+			samples = append(samples, rtt)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read delay trace: %v", err)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("delay trace %s holds no RTT samples", path)
+	}
+	return samples, nil
+}
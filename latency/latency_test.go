@@ -0,0 +1,140 @@
+// latency/latency_test.go
+
+package latency
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sharding/config"
+	"testing"
+)
+
+const samples = 200_000
+
+// moments returns the sample mean, standard deviation and minimum of n draws of m
+func moments(m DelayModel, n int) (mean, std, low float64) {
+	low = math.Inf(1)
+	sum, sumSq := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		x := m.Sample()
+		sum += x
+		sumSq += x * x
+		low = math.Min(low, x)
+	}
+	mean = sum / float64(n)
+	return mean, math.Sqrt(sumSq/float64(n) - mean*mean), low
+}
+
+func within(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance*want
+}
+
+func TestModelsMatchMeanAndSpread(t *testing.T) {
+	cases := []struct {
+		name  string
+		model DelayModel
+		std   float64
+		low   float64
+	}{
+		{"normal", &Normal{Mean: 100, Std: 20}, 20, 0},
+		{"log-normal", &LogNormal{Mean: 100, Std: 20}, 20, 0},
+		{"shifted-exponential", &ShiftedExponential{Mean: 100, Std: 20}, 20, 80},
+		// The sample deviation of a tail index of 2.5 converges too slowly to check, the tail
+		// test below covers its spread instead
+		{"pareto", &Pareto{Mean: 100, Shape: 2.5}, 0, 60},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mean, std, low := moments(c.model, samples)
+			if !within(mean, 100, 0.02) {
+				t.Errorf("mean = %.2f, want 100", mean)
+			}
+			if c.std > 0 && !within(std, c.std, 0.1) {
+				t.Errorf("std = %.2f, want %.2f", std, c.std)
+			}
+			if low < c.low {
+				t.Errorf("min = %.2f, want at least %.2f", low, c.low)
+			}
+		})
+	}
+}
+
+func TestNormalStaysNonNegative(t *testing.T) {
+	mean, _, low := moments(&Normal{Mean: 10, Std: 50}, samples)
+	if low < 0 {
+		t.Fatalf("min = %.2f, want no negative latency", low)
+	}
+	if mean <= 10 {
+		t.Fatalf("mean = %.2f, want the cut-off mass to lift it above 10", mean)
+	}
+}
+
+func TestParetoTailIsHeavierThanNormal(t *testing.T) {
+	beyond := func(m DelayModel) float64 {
+		count := 0
+		for i := 0; i < samples; i++ {
+			if m.Sample() > 300 {
+				count++
+			}
+		}
+		return float64(count) / samples
+	}
+	// P(X > 300) = (60 / 300)^2.5 for the Pareto, effectively zero for the normal
+	pareto := beyond(&Pareto{Mean: 100, Shape: 2.5})
+	if want := math.Pow(0.2, 2.5); !within(pareto, want, 0.15) {
+		t.Errorf("pareto tail = %.4f, want %.4f", pareto, want)
+	}
+	if normal := beyond(&Normal{Mean: 100, Std: 20}); normal != 0 {
+		t.Errorf("normal tail = %.4f, want 0", normal)
+	}
+}
+
+func TestNewDelayModel(t *testing.T) {
+	cfg := &config.Config{
+		MinNetworkDelayMean: 100,
+		MaxNetworkDelayMean: 100,
+		MinNetworkDelayStd:  20,
+		MaxNetworkDelayStd:  20,
+	}
+
+	if m, ok := NewDelayModel(cfg, config.NormalDelay).(*Normal); !ok || m.Mean != 100 || m.Std != 20 {
+		t.Errorf("normal model = %+v, want mean 100 and std 20 in milliseconds", m)
+	}
+	if m, ok := NewDelayModel(cfg, config.ParetoDelay).(*Pareto); !ok || m.Shape != config.ParetoShape {
+		t.Errorf("pareto model = %+v, want shape %v when ParetoShape is unset", m, config.ParetoShape)
+	}
+	cfg.ParetoShape = 1.5
+	if m := NewDelayModel(cfg, config.ParetoDelay).(*Pareto); m.Shape != 1.5 {
+		t.Errorf("pareto shape = %v, want 1.5", m.Shape)
+	}
+	if _, ok := NewDelayModel(cfg, config.EmpiricalDelay).(*Normal); !ok {
+		t.Error("empirical model without a trace should fall back to normal")
+	}
+	cfg.DelayTrace = []float64{40, 40}
+	if got := NewDelayModel(cfg, config.EmpiricalDelay).Sample(); got != 20 {
+		t.Errorf("empirical sample = %v, want half the RTT", got)
+	}
+}
+
+func TestLoadTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rtt.csv")
+	if err := os.WriteFile(path, []byte("rtt_ms\n80, 120\n-5;NaN\t200\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0] != 80 || got[1] != 120 || got[2] != 200 {
+		t.Fatalf("LoadTrace = %v, want [80 120 200]", got)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.csv")
+	if err := os.WriteFile(empty, []byte("rtt_ms\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrace(empty); err == nil {
+		t.Error("LoadTrace of a trace without samples should fail")
+	}
+}
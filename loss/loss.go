@@ -0,0 +1,115 @@
+// loss/loss.go
+
+package loss
+
+import (
+	"math/rand"
+	"sharding/config"
+	"sync"
+)
+
+// Traffic counts the messages of one kind sent over lossy links
+type Traffic struct {
+	Messages        int
+	Delivered       int
+	Retransmissions int
+	// ExtraLatency holds the milliseconds retransmissions added to every delivered message that needed them
+	ExtraLatency []float64
+}
+
+// Model gives every link its own loss rate. Senders resend a lost message after a timeout that
+// doubles with every attempt, up to MaxRetransmissions times. Downloads run in parallel
+// goroutines, so the model is safe for concurrent use.
+type Model struct {
+	MinRate            float64
+	MaxRate            float64
+	MaxRetransmissions int
+	// Timeout before the first retransmission in milliseconds
+	Timeout   float64
+	Gossip    Traffic
+	Downloads Traffic
+	// DownloadFallbacks counts downloads that moved on to the next peer after a timeout
+	DownloadFallbacks int
+	rates             map[[2]int]float64
+	mu                sync.Mutex
+}
+
+// NewModel returns the loss model configured in cfg, or nil when links never lose messages
+func NewModel(cfg *config.Config) *Model {
+	if cfg.MaxLinkLossRate <= 0 {
+		return nil
+	}
+	return &Model{
+		MinRate:            cfg.MinLinkLossRate,
+		MaxRate:            max(cfg.MinLinkLossRate, cfg.MaxLinkLossRate),
+		MaxRetransmissions: cfg.MaxRetransmissions,
+		Timeout:            cfg.RetransmissionTimeout,
+		Gossip:             Traffic{ExtraLatency: make([]float64, 0)},
+		Downloads:          Traffic{ExtraLatency: make([]float64, 0)},
+		rates:              make(map[[2]int]float64),
+	}
+}
+
+// Rate returns the loss rate of the link between a and b, drawn the first time it is used
+func (m *Model) Rate(a, b int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rate(a, b)
+}
+
+func (m *Model) rate(a, b int) float64 {
+	if a > b {
+		a, b = b, a
+	}
+	key := [2]int{a, b}
+	rate, exists := m.rates[key]
+	if !exists {
+		rate = m.MinRate + rand.Float64()*(m.MaxRate-m.MinRate)
+		m.rates[key] = rate
+	}
+	return rate
+}
+
+// SendGossip delivers a gossip hop from a to b. It returns the milliseconds retransmissions
+// added and false when every attempt was lost.
+func (m *Model) SendGossip(a, b int) (float64, bool) {
+	return m.send(&m.Gossip, a, b)
+}
+
+// SendDownload delivers a block download from a to b. It returns the milliseconds
+// retransmissions added and false when every attempt was lost.
+func (m *Model) SendDownload(a, b int) (float64, bool) {
+	return m.send(&m.Downloads, a, b)
+}
+
+// RecordFallback counts a download given up on after a timeout
+func (m *Model) RecordFallback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DownloadFallbacks++
+}
+
+func (m *Model) send(traffic *Traffic, a, b int) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rate := m.rate(a, b)
+	traffic.Messages++
+	extra := 0.0
+	backoff := m.Timeout
+	for attempt := 0; attempt <= m.MaxRetransmissions; attempt++ {
+		if rand.Float64() >= rate {
+			traffic.Delivered++
+			if attempt > 0 {
+				traffic.ExtraLatency = append(traffic.ExtraLatency, extra)
+			}
+			return extra, true
+		}
+		if attempt < m.MaxRetransmissions {
+			traffic.Retransmissions++
+			extra += backoff
+			backoff *= 2
+		}
+	}
+	return extra, false
+}
@@ -5,17 +5,19 @@ import (
 	"sharding/config"
 )
 
-func WinLottery(isHonest bool, resources int, currentTime int64, attackStartTime int, attackEndTime int) bool {
-	if currentTime >= int64(attackStartTime) && currentTime <= int64(attackEndTime) && !isHonest {
-		attempts := resources * config.MaliciousNodeMultiplier
-		for i := 0; i < attempts; i++ {
-			if rand.Float64() < config.LotteryWinProbability {
-				return true
-			}
+// WinLottery draws one ticket per resource unit, each winning with the given probability, and
+// reports whether any of them wins. Probabilities of 0 or less fall back to
+// config.LotteryWinProbability.
+func WinLottery(resources int, probability float64) bool {
+	if probability <= 0 {
+		probability = config.LotteryWinProbability
+	}
+	for i := 0; i < resources; i++ {
+		if rand.Float64() < probability {
+			return true
 		}
-		return false
 	}
-	return rand.Float64() < config.LotteryWinProbability
+	return false
 }
 
 func AssignShard(nodeID int, timestamp int64, numShards int) int {
@@ -41,32 +41,177 @@ func main() {
 
 // UserConfig matches the frontend configuration structure
 type UserConfig struct {
-	NumNodes                int     `json:"numNodes"`
-	NumShards               int     `json:"numShards"`
-	NumOperators            int     `json:"numOperators"`
-	SimulationTime          int64   `json:"simulationTime"`
-	TimeStep                int64   `json:"timeStep"`
-	MaliciousNodeRatio      float64 `json:"maliciousNodeRatio"`
-	LotteryWinProbability   float64 `json:"lotteryWinProbability"`
-	MaliciousNodeMultiplier int     `json:"maliciousNodeMultiplier"`
-	BlockProductionInterval int64   `json:"blockProductionInterval"`
-	TransactionsPerBlock    int     `json:"transactionsPerBlock"`
-	BlockSize               int     `json:"blockSize"`
-	BlockHeaderSize         int     `json:"blockHeaderSize"`
-	ERHeaderSize            int     `json:"erHeaderSize"`
-	ERBodySize              int     `json:"erBodySize"`
-	NetworkBandwidth        int64   `json:"networkBandwidth"`
-	MinNetworkDelayMean     float64 `json:"minNetworkDelayMean"`
-	MaxNetworkDelayMean     float64 `json:"maxNetworkDelayMean"`
-	MinNetworkDelayStd      float64 `json:"minNetworkDelayStd"`
-	MaxNetworkDelayStd      float64 `json:"maxNetworkDelayStd"`
-	MinGossipFanout         int     `json:"minGossipFanout"`
-	MaxGossipFanout         int     `json:"maxGossipFanout"`
-	MaxP2PConnections       int     `json:"maxP2PConnections"`
-	TimeOut                 int64   `json:"timeOut"`
-	NumBlocksToDownload     int     `json:"numBlocksToDownload"`
-	AttackStartTime         int64   `json:"attackStartTime"`
-	AttackEndTime           int64   `json:"attackEndTime"`
+	NumNodes                int       `json:"numNodes"`
+	NumShards               int       `json:"numShards"`
+	NumOperators            int       `json:"numOperators"`
+	SimulationTime          int64     `json:"simulationTime"`
+	TimeStep                int64     `json:"timeStep"`
+	MaliciousNodeRatio      float64   `json:"maliciousNodeRatio"`
+	LotteryWinProbability   float64   `json:"lotteryWinProbability"`
+	MaliciousNodeMultiplier int       `json:"maliciousNodeMultiplier"`
+	BlockProductionInterval int64     `json:"blockProductionInterval"`
+	TransactionsPerBlock    int       `json:"transactionsPerBlock"`
+	BlockSize               int       `json:"blockSize"`
+	BlockHeaderSize         int       `json:"blockHeaderSize"`
+	ERHeaderSize            int       `json:"erHeaderSize"`
+	ERBodySize              int       `json:"erBodySize"`
+	NetworkBandwidth        int64     `json:"networkBandwidth"`
+	MinNetworkDelayMean     float64   `json:"minNetworkDelayMean"`
+	MaxNetworkDelayMean     float64   `json:"maxNetworkDelayMean"`
+	MinNetworkDelayStd      float64   `json:"minNetworkDelayStd"`
+	MaxNetworkDelayStd      float64   `json:"maxNetworkDelayStd"`
+	MinGossipFanout         int       `json:"minGossipFanout"`
+	MaxGossipFanout         int       `json:"maxGossipFanout"`
+	MaxP2PConnections       int       `json:"maxP2PConnections"`
+	TimeOut                 int64     `json:"timeOut"`
+	NumBlocksToDownload     int       `json:"numBlocksToDownload"`
+	AttackStartTime         int64     `json:"attackStartTime"`
+	AttackEndTime           int64     `json:"attackEndTime"`
+	StakeDistribution       string    `json:"stakeDistribution"`
+	StakeParetoShape        float64   `json:"stakeParetoShape"`
+	MaxStake                int       `json:"maxStake"`
+	StakeList               []int     `json:"stakeList"`
+	ProducerSelection       string    `json:"producerSelection"`
+	EnableForks             bool      `json:"enableForks"`
+	ConcurrentProducers     int       `json:"concurrentProducers"`
+	ForkChoice              string    `json:"forkChoice"`
+	NumERVerifiers          int       `json:"numERVerifiers"`
+	EnableFraudProofs       bool      `json:"enableFraudProofs"`
+	FraudDetectionProb      float64   `json:"fraudDetectionProb"`
+	FraudDetectionDelay     int64     `json:"fraudDetectionDelay"`
+	FraudProofSize          int       `json:"fraudProofSize"`
+	ChallengePeriod         int64     `json:"challengePeriod"`
+	Workload                string    `json:"workload"`
+	TransactionRate         float64   `json:"transactionRate"`
+	BurstMultiplier         float64   `json:"burstMultiplier"`
+	BurstDuration           int64     `json:"burstDuration"`
+	BurstPeriod             int64     `json:"burstPeriod"`
+	WorkloadTraceFile       string    `json:"workloadTraceFile"`
+	MempoolCapacity         int       `json:"mempoolCapacity"`
+	CrossShardRatio         float64   `json:"crossShardRatio"`
+	MaxShardsPerTx          int       `json:"maxShardsPerTx"`
+	Finality                string    `json:"finality"`
+	FinalityConfirmations   int       `json:"finalityConfirmations"`
+	FinalityQuorum          float64   `json:"finalityQuorum"`
+	VoteSize                int       `json:"voteSize"`
+	BeaconBlockInterval     int64     `json:"beaconBlockInterval"`
+	FinalityStallThreshold  float64   `json:"finalityStallThreshold"`
+	FinalityTimeout         int64     `json:"finalityTimeout"`
+	EnableBeaconChain       bool      `json:"enableBeaconChain"`
+	BeaconCommitteeSize     int       `json:"beaconCommitteeSize"`
+	MaxCrosslinks           int       `json:"maxCrosslinks"`
+	EnableDAS               bool      `json:"enableDAS"`
+	DASDataChunks           int       `json:"dasDataChunks"`
+	DASExtensionFactor      int       `json:"dasExtensionFactor"`
+	DASSamplesPerNode       int       `json:"dasSamplesPerNode"`
+	DASProofSize            int       `json:"dasProofSize"`
+	NumLightNodes           int       `json:"numLightNodes"`
+	DASWithheldFraction     float64   `json:"dasWithheldFraction"`
+	StateSync               string    `json:"stateSync"`
+	GenesisStateSize        int64     `json:"genesisStateSize"`
+	StateGrowthPerBlock     int       `json:"stateGrowthPerBlock"`
+	StateGrowthPerTx        int       `json:"stateGrowthPerTx"`
+	SnapshotInterval        int       `json:"snapshotInterval"`
+	BlockReplayTime         float64   `json:"blockReplayTime"`
+	WitnessSizePerTx        int       `json:"witnessSizePerTx"`
+	Topology                string    `json:"topology"`
+	PeerDegree              int       `json:"peerDegree"`
+	SmallWorldRewiring      float64   `json:"smallWorldRewiring"`
+	RegionLatencyFile       string    `json:"regionLatencyFile"`
+	NodeRegionWeights       []float64 `json:"nodeRegionWeights"`
+	OperatorRegionWeights   []float64 `json:"operatorRegionWeights"`
+	EnableLinkQueues        bool      `json:"enableLinkQueues"`
+	UploadBandwidth         int64     `json:"uploadBandwidth"`
+	DownloadBandwidth       int64     `json:"downloadBandwidth"`
+	MinLinkLossRate         float64   `json:"minLinkLossRate"`
+	MaxLinkLossRate         float64   `json:"maxLinkLossRate"`
+	MaxRetransmissions      int       `json:"maxRetransmissions"`
+	RetransmissionTimeout   float64   `json:"retransmissionTimeout"`
+
+	// Network partitions, each cutting the network for its own time window
+	Partitions    []PartitionConfig `json:"partitions"`
+	PartitionMode string            `json:"partitionMode"`
+
+	// Latency distributions of block, header and download traffic
+	BlockDelayModel    string  `json:"blockDelayModel"`
+	HeaderDelayModel   string  `json:"headerDelayModel"`
+	DownloadDelayModel string  `json:"downloadDelayModel"`
+	ParetoShape        float64 `json:"paretoShape"`
+	DelayTraceFile     string  `json:"delayTraceFile"`
+
+	// Header chain validation and sync
+	EnableHeaderSync  bool  `json:"enableHeaderSync"`
+	HeaderSyncBatch   int   `json:"headerSyncBatch"`
+	HeaderSyncRetries int   `json:"headerSyncRetries"`
+	HeaderSyncTimeout int64 `json:"headerSyncTimeout"`
+
+	// Adversary and compromise estimation. AttackType is "grinding", "takeover", "eclipse",
+	// "adaptive" or "none".
+	AttackType          string  `json:"attackType"`
+	AttackTargetShard   int     `json:"attackTargetShard"`
+	GrindingAttemptCost float64 `json:"grindingAttemptCost"`
+	GrindingBudget      float64 `json:"grindingBudget"`
+	CompromiseThreshold float64 `json:"compromiseThreshold"`
+	Replicas            int     `json:"replicas"`
+	BlockWithholding    string  `json:"blockWithholding"`
+
+	// Byzantine block serving. ServingStrategy is "delay", "honest", "refuse", "slow", "corrupt",
+	// "stale" or "mixed", and the weights follow that order.
+	ServingStrategy        string    `json:"servingStrategy"`
+	ServingStrategyWeights []float64 `json:"servingStrategyWeights"`
+	SlowServeFraction      float64   `json:"slowServeFraction"`
+
+	// Eclipse attack on rotating nodes
+	EclipsePeerSlots int     `json:"eclipsePeerSlots"`
+	EclipseAdvantage float64 `json:"eclipseAdvantage"`
+
+	// Adaptive corruption. CorruptionTarget is "closest" or "fixed".
+	CorruptionDelay  int64  `json:"corruptionDelay"`
+	CorruptionBudget int    `json:"corruptionBudget"`
+	CorruptionTarget string `json:"corruptionTarget"`
+
+	// Fraud verification. Zero values fall back to the defaults.
+	FraudVerifiers         int     `json:"fraudVerifiers"`
+	FraudReexecutionFactor float64 `json:"fraudReexecutionFactor"`
+
+	// Members a shard needs before it can count as compromised, 0 for the default
+	CompromiseMinShardSize int `json:"compromiseMinShardSize"`
+}
+
+// PartitionConfig schedules a network partition. Split is "shard", "region", "list" or "random".
+type PartitionConfig struct {
+	Start    int64   `json:"start"`
+	End      int64   `json:"end"`
+	Split    string  `json:"split"`
+	Groups   []int   `json:"groups"`
+	Nodes    []int   `json:"nodes"`
+	Fraction float64 `json:"fraction"`
+}
+
+func parsePartitions(specs []PartitionConfig) []config.Partition {
+	partitions := make([]config.Partition, 0, len(specs))
+	for _, spec := range specs {
+		partitions = append(partitions, config.Partition{
+			Start:    spec.Start,
+			End:      spec.End,
+			Split:    config.ParsePartitionSplit(spec.Split),
+			Groups:   spec.Groups,
+			Nodes:    spec.Nodes,
+			Fraction: spec.Fraction,
+		})
+	}
+	return partitions
+}
+
+// runReplicas runs the remaining replicas of the configuration and folds their results into the
+// collector of the first run
+func runReplicas(cfg config.Config, collector *metrics.MetricsCollector) {
+	for i := 1; i < cfg.Replicas; i++ {
+		fmt.Printf("Replica %d of %d started.\n", i+1, cfg.Replicas)
+		replica := metrics.NewMetricsCollector()
+		simulation.NewSimulation(cfg, replica).Run()
+		collector.AddReplica(replica)
+	}
 }
 
 func handleSimulationWithConfig(w http.ResponseWriter, r *http.Request) {
@@ -127,8 +272,97 @@ func handleSimulationWithConfig(w http.ResponseWriter, r *http.Request) {
 		MaxP2PConnections:       userConfig.MaxP2PConnections,
 		TimeOut:                 userConfig.TimeOut,
 		NumBlocksToDownload:     userConfig.NumBlocksToDownload,
+		StakeDistribution:       config.ParseStakeDistribution(userConfig.StakeDistribution),
+		StakeParetoShape:        userConfig.StakeParetoShape,
+		MaxStake:                userConfig.MaxStake,
+		StakeList:               userConfig.StakeList,
+		ProducerSelection:       config.ParseProducerSelectionRule(userConfig.ProducerSelection),
+		EnableForks:             userConfig.EnableForks,
+		ConcurrentProducers:     userConfig.ConcurrentProducers,
+		ForkChoice:              config.ParseForkChoiceRule(userConfig.ForkChoice),
+		NumERVerifiers:          userConfig.NumERVerifiers,
+		EnableFraudProofs:       userConfig.EnableFraudProofs,
+		FraudDetectionProb:      userConfig.FraudDetectionProb,
+		FraudDetectionDelay:     userConfig.FraudDetectionDelay,
+		FraudProofSize:          userConfig.FraudProofSize,
+		ChallengePeriod:         userConfig.ChallengePeriod,
+		Workload:                config.ParseWorkloadType(userConfig.Workload),
+		TransactionRate:         userConfig.TransactionRate,
+		BurstMultiplier:         userConfig.BurstMultiplier,
+		BurstDuration:           userConfig.BurstDuration,
+		BurstPeriod:             userConfig.BurstPeriod,
+		WorkloadTraceFile:       userConfig.WorkloadTraceFile,
+		MempoolCapacity:         userConfig.MempoolCapacity,
+		CrossShardRatio:         userConfig.CrossShardRatio,
+		MaxShardsPerTx:          userConfig.MaxShardsPerTx,
+		Finality:                config.ParseFinalityRule(userConfig.Finality),
+		FinalityConfirmations:   userConfig.FinalityConfirmations,
+		FinalityQuorum:          userConfig.FinalityQuorum,
+		VoteSize:                userConfig.VoteSize,
+		BeaconBlockInterval:     userConfig.BeaconBlockInterval,
+		FinalityStallThreshold:  userConfig.FinalityStallThreshold,
+		FinalityTimeout:         userConfig.FinalityTimeout,
+		EnableBeaconChain:       userConfig.EnableBeaconChain,
+		BeaconCommitteeSize:     userConfig.BeaconCommitteeSize,
+		MaxCrosslinks:           userConfig.MaxCrosslinks,
+		EnableDAS:               userConfig.EnableDAS,
+		DASDataChunks:           userConfig.DASDataChunks,
+		DASExtensionFactor:      userConfig.DASExtensionFactor,
+		DASSamplesPerNode:       userConfig.DASSamplesPerNode,
+		DASProofSize:            userConfig.DASProofSize,
+		NumLightNodes:           userConfig.NumLightNodes,
+		DASWithheldFraction:     userConfig.DASWithheldFraction,
+		StateSync:               config.ParseStateSyncStrategy(userConfig.StateSync),
+		GenesisStateSize:        userConfig.GenesisStateSize,
+		StateGrowthPerBlock:     userConfig.StateGrowthPerBlock,
+		StateGrowthPerTx:        userConfig.StateGrowthPerTx,
+		SnapshotInterval:        userConfig.SnapshotInterval,
+		BlockReplayTime:         userConfig.BlockReplayTime,
+		WitnessSizePerTx:        userConfig.WitnessSizePerTx,
+		Topology:                config.ParseTopology(userConfig.Topology),
+		PeerDegree:              userConfig.PeerDegree,
+		SmallWorldRewiring:      userConfig.SmallWorldRewiring,
+		RegionLatencyFile:       userConfig.RegionLatencyFile,
+		NodeRegionWeights:       userConfig.NodeRegionWeights,
+		OperatorRegionWeights:   userConfig.OperatorRegionWeights,
+		EnableLinkQueues:        userConfig.EnableLinkQueues,
+		UploadBandwidth:         userConfig.UploadBandwidth,
+		DownloadBandwidth:       userConfig.DownloadBandwidth,
+		MinLinkLossRate:         userConfig.MinLinkLossRate,
+		MaxLinkLossRate:         userConfig.MaxLinkLossRate,
+		MaxRetransmissions:      userConfig.MaxRetransmissions,
+		RetransmissionTimeout:   userConfig.RetransmissionTimeout,
+		Partitions:              parsePartitions(userConfig.Partitions),
+		PartitionMode:           config.ParsePartitionMode(userConfig.PartitionMode),
+		BlockDelayModel:         config.ParseDelayDistribution(userConfig.BlockDelayModel),
+		HeaderDelayModel:        config.ParseDelayDistribution(userConfig.HeaderDelayModel),
+		DownloadDelayModel:      config.ParseDelayDistribution(userConfig.DownloadDelayModel),
+		ParetoShape:             userConfig.ParetoShape,
+		DelayTraceFile:          userConfig.DelayTraceFile,
+		EnableHeaderSync:        userConfig.EnableHeaderSync,
+		HeaderSyncBatch:         userConfig.HeaderSyncBatch,
+		HeaderSyncRetries:       userConfig.HeaderSyncRetries,
+		HeaderSyncTimeout:       userConfig.HeaderSyncTimeout,
+		AttackType:              config.ParseAttackType(userConfig.AttackType),
+		AttackTargetShard:       userConfig.AttackTargetShard,
+		GrindingAttemptCost:     userConfig.GrindingAttemptCost,
+		GrindingBudget:          userConfig.GrindingBudget,
+		CompromiseThreshold:     userConfig.CompromiseThreshold,
+		Replicas:                userConfig.Replicas,
+		BlockWithholding:        config.ParseWithholdingMode(userConfig.BlockWithholding),
+		ServingStrategy:         config.ParseServingStrategy(userConfig.ServingStrategy),
+		ServingStrategyWeights:  userConfig.ServingStrategyWeights,
+		SlowServeFraction:       userConfig.SlowServeFraction,
+		EclipsePeerSlots:        userConfig.EclipsePeerSlots,
+		EclipseAdvantage:        userConfig.EclipseAdvantage,
+		CorruptionDelay:         userConfig.CorruptionDelay,
+		CorruptionBudget:        userConfig.CorruptionBudget,
+		CorruptionTarget:        config.ParseCorruptionTarget(userConfig.CorruptionTarget),
+		FraudVerifiers:          userConfig.FraudVerifiers,
+		FraudReexecutionFactor:  userConfig.FraudReexecutionFactor,
+		CompromiseMinShardSize:  userConfig.CompromiseMinShardSize,
 		AttackSchedule: map[int64]config.AttackType{
-			userConfig.AttackStartTime: config.GrindingAttack,
+			userConfig.AttackStartTime: config.ParseAttackType(userConfig.AttackType),
 			userConfig.AttackEndTime:   config.NoAttack,
 		},
 	}
@@ -139,6 +373,7 @@ func handleSimulationWithConfig(w http.ResponseWriter, r *http.Request) {
 	// Run the simulation
 	fmt.Println("Simulation started with custom configuration.")
 	sim.Run()
+	runReplicas(cfg, metricsCollector)
 	fmt.Println("Simulation completed.")
 
 	// Generate metrics report
@@ -212,6 +447,95 @@ func handleSimulation(w http.ResponseWriter, r *http.Request) {
 		MaxP2PConnections:       config.MaxP2PConnections,
 		TimeOut:                 config.TimeOut,
 		NumBlocksToDownload:     config.NumBlocksToDownload,
+		StakeDistribution:       config.StakeDistribution,
+		StakeParetoShape:        config.StakeParetoShape,
+		MaxStake:                config.MaxStake,
+		StakeList:               config.StakeList,
+		ProducerSelection:       config.ProducerSelection,
+		EnableForks:             config.EnableForks,
+		ConcurrentProducers:     config.ConcurrentProducers,
+		ForkChoice:              config.ForkChoice,
+		NumERVerifiers:          config.NumERVerifiers,
+		EnableFraudProofs:       config.EnableFraudProofs,
+		FraudDetectionProb:      config.FraudDetectionProb,
+		FraudDetectionDelay:     config.FraudDetectionDelay,
+		FraudProofSize:          config.FraudProofSize,
+		ChallengePeriod:         config.ChallengePeriod,
+		Workload:                config.Workload,
+		TransactionRate:         config.TransactionRate,
+		BurstMultiplier:         config.BurstMultiplier,
+		BurstDuration:           config.BurstDuration,
+		BurstPeriod:             config.BurstPeriod,
+		WorkloadTraceFile:       config.WorkloadTraceFile,
+		MempoolCapacity:         config.MempoolCapacity,
+		CrossShardRatio:         config.CrossShardRatio,
+		MaxShardsPerTx:          config.MaxShardsPerTx,
+		Finality:                config.Finality,
+		FinalityConfirmations:   config.FinalityConfirmations,
+		FinalityQuorum:          config.FinalityQuorum,
+		VoteSize:                config.VoteSize,
+		BeaconBlockInterval:     config.BeaconBlockInterval,
+		FinalityStallThreshold:  config.FinalityStallThreshold,
+		FinalityTimeout:         config.FinalityTimeout,
+		EnableBeaconChain:       config.EnableBeaconChain,
+		BeaconCommitteeSize:     config.BeaconCommitteeSize,
+		MaxCrosslinks:           config.MaxCrosslinks,
+		EnableDAS:               config.EnableDAS,
+		DASDataChunks:           config.DASDataChunks,
+		DASExtensionFactor:      config.DASExtensionFactor,
+		DASSamplesPerNode:       config.DASSamplesPerNode,
+		DASProofSize:            config.DASProofSize,
+		NumLightNodes:           config.NumLightNodes,
+		DASWithheldFraction:     config.DASWithheldFraction,
+		StateSync:               config.StateSync,
+		GenesisStateSize:        config.GenesisStateSize,
+		StateGrowthPerBlock:     config.StateGrowthPerBlock,
+		StateGrowthPerTx:        config.StateGrowthPerTx,
+		SnapshotInterval:        config.SnapshotInterval,
+		BlockReplayTime:         config.BlockReplayTime,
+		WitnessSizePerTx:        config.WitnessSizePerTx,
+		Topology:                config.Topology,
+		PeerDegree:              config.PeerDegree,
+		SmallWorldRewiring:      config.SmallWorldRewiring,
+		RegionLatencyFile:       config.RegionLatencyFile,
+		NodeRegionWeights:       config.NodeRegionWeights,
+		OperatorRegionWeights:   config.OperatorRegionWeights,
+		EnableLinkQueues:        config.EnableLinkQueues,
+		UploadBandwidth:         config.UploadBandwidth,
+		DownloadBandwidth:       config.DownloadBandwidth,
+		MinLinkLossRate:         config.MinLinkLossRate,
+		MaxLinkLossRate:         config.MaxLinkLossRate,
+		MaxRetransmissions:      config.MaxRetransmissions,
+		RetransmissionTimeout:   config.RetransmissionTimeout,
+		Partitions:              config.Partitions,
+		PartitionMode:           config.PartitionMode,
+		BlockDelayModel:         config.BlockDelayModel,
+		HeaderDelayModel:        config.HeaderDelayModel,
+		DownloadDelayModel:      config.DownloadDelayModel,
+		ParetoShape:             config.ParetoShape,
+		DelayTraceFile:          config.DelayTraceFile,
+		EnableHeaderSync:        config.EnableHeaderSync,
+		HeaderSyncBatch:         config.HeaderSyncBatch,
+		HeaderSyncRetries:       config.HeaderSyncRetries,
+		HeaderSyncTimeout:       config.HeaderSyncTimeout,
+		AttackType:              config.ScheduledAttack,
+		AttackTargetShard:       config.AttackTargetShard,
+		GrindingAttemptCost:     config.GrindingAttemptCost,
+		GrindingBudget:          config.GrindingBudget,
+		CompromiseThreshold:     config.CompromiseThreshold,
+		Replicas:                config.Replicas,
+		BlockWithholding:        config.BlockWithholding,
+		ServingStrategy:         config.ServingStrategy,
+		ServingStrategyWeights:  config.ServingStrategyWeights,
+		SlowServeFraction:       config.SlowServeFraction,
+		EclipsePeerSlots:        config.EclipsePeerSlots,
+		EclipseAdvantage:        config.EclipseAdvantage,
+		CorruptionDelay:         config.CorruptionDelay,
+		CorruptionBudget:        config.CorruptionBudget,
+		CorruptionTarget:        config.CorruptionTarget,
+		FraudVerifiers:          config.FraudVerifiers,
+		FraudReexecutionFactor:  config.FraudReexecutionFactor,
+		CompromiseMinShardSize:  config.CompromiseMinShardSize,
 	}
 
 	// Create a new simulation instance with metrics collector
@@ -220,6 +544,7 @@ func handleSimulation(w http.ResponseWriter, r *http.Request) {
 	// Run the simulation
 	fmt.Println("Simulation started.")
 	sim.Run()
+	runReplicas(cfg, metricsCollector)
 	fmt.Println("Simulation completed.")
 
 	// Generate metrics report
@@ -274,12 +599,102 @@ func runLocalSimulation() {
 		MaxP2PConnections:       config.MaxP2PConnections,
 		TimeOut:                 config.TimeOut,
 		NumBlocksToDownload:     config.NumBlocksToDownload,
+		StakeDistribution:       config.StakeDistribution,
+		StakeParetoShape:        config.StakeParetoShape,
+		MaxStake:                config.MaxStake,
+		StakeList:               config.StakeList,
+		ProducerSelection:       config.ProducerSelection,
+		EnableForks:             config.EnableForks,
+		ConcurrentProducers:     config.ConcurrentProducers,
+		ForkChoice:              config.ForkChoice,
+		NumERVerifiers:          config.NumERVerifiers,
+		EnableFraudProofs:       config.EnableFraudProofs,
+		FraudDetectionProb:      config.FraudDetectionProb,
+		FraudDetectionDelay:     config.FraudDetectionDelay,
+		FraudProofSize:          config.FraudProofSize,
+		ChallengePeriod:         config.ChallengePeriod,
+		Workload:                config.Workload,
+		TransactionRate:         config.TransactionRate,
+		BurstMultiplier:         config.BurstMultiplier,
+		BurstDuration:           config.BurstDuration,
+		BurstPeriod:             config.BurstPeriod,
+		WorkloadTraceFile:       config.WorkloadTraceFile,
+		MempoolCapacity:         config.MempoolCapacity,
+		CrossShardRatio:         config.CrossShardRatio,
+		MaxShardsPerTx:          config.MaxShardsPerTx,
+		Finality:                config.Finality,
+		FinalityConfirmations:   config.FinalityConfirmations,
+		FinalityQuorum:          config.FinalityQuorum,
+		VoteSize:                config.VoteSize,
+		BeaconBlockInterval:     config.BeaconBlockInterval,
+		FinalityStallThreshold:  config.FinalityStallThreshold,
+		FinalityTimeout:         config.FinalityTimeout,
+		EnableBeaconChain:       config.EnableBeaconChain,
+		BeaconCommitteeSize:     config.BeaconCommitteeSize,
+		MaxCrosslinks:           config.MaxCrosslinks,
+		EnableDAS:               config.EnableDAS,
+		DASDataChunks:           config.DASDataChunks,
+		DASExtensionFactor:      config.DASExtensionFactor,
+		DASSamplesPerNode:       config.DASSamplesPerNode,
+		DASProofSize:            config.DASProofSize,
+		NumLightNodes:           config.NumLightNodes,
+		DASWithheldFraction:     config.DASWithheldFraction,
+		StateSync:               config.StateSync,
+		GenesisStateSize:        config.GenesisStateSize,
+		StateGrowthPerBlock:     config.StateGrowthPerBlock,
+		StateGrowthPerTx:        config.StateGrowthPerTx,
+		SnapshotInterval:        config.SnapshotInterval,
+		BlockReplayTime:         config.BlockReplayTime,
+		WitnessSizePerTx:        config.WitnessSizePerTx,
+		Topology:                config.Topology,
+		PeerDegree:              config.PeerDegree,
+		SmallWorldRewiring:      config.SmallWorldRewiring,
+		RegionLatencyFile:       config.RegionLatencyFile,
+		NodeRegionWeights:       config.NodeRegionWeights,
+		OperatorRegionWeights:   config.OperatorRegionWeights,
+		EnableLinkQueues:        config.EnableLinkQueues,
+		UploadBandwidth:         config.UploadBandwidth,
+		DownloadBandwidth:       config.DownloadBandwidth,
+		MinLinkLossRate:         config.MinLinkLossRate,
+		MaxLinkLossRate:         config.MaxLinkLossRate,
+		MaxRetransmissions:      config.MaxRetransmissions,
+		RetransmissionTimeout:   config.RetransmissionTimeout,
+		Partitions:              config.Partitions,
+		PartitionMode:           config.PartitionMode,
+		BlockDelayModel:         config.BlockDelayModel,
+		HeaderDelayModel:        config.HeaderDelayModel,
+		DownloadDelayModel:      config.DownloadDelayModel,
+		ParetoShape:             config.ParetoShape,
+		DelayTraceFile:          config.DelayTraceFile,
+		EnableHeaderSync:        config.EnableHeaderSync,
+		HeaderSyncBatch:         config.HeaderSyncBatch,
+		HeaderSyncRetries:       config.HeaderSyncRetries,
+		HeaderSyncTimeout:       config.HeaderSyncTimeout,
+		AttackType:              config.ScheduledAttack,
+		AttackTargetShard:       config.AttackTargetShard,
+		GrindingAttemptCost:     config.GrindingAttemptCost,
+		GrindingBudget:          config.GrindingBudget,
+		CompromiseThreshold:     config.CompromiseThreshold,
+		Replicas:                config.Replicas,
+		BlockWithholding:        config.BlockWithholding,
+		ServingStrategy:         config.ServingStrategy,
+		ServingStrategyWeights:  config.ServingStrategyWeights,
+		SlowServeFraction:       config.SlowServeFraction,
+		EclipsePeerSlots:        config.EclipsePeerSlots,
+		EclipseAdvantage:        config.EclipseAdvantage,
+		CorruptionDelay:         config.CorruptionDelay,
+		CorruptionBudget:        config.CorruptionBudget,
+		CorruptionTarget:        config.CorruptionTarget,
+		FraudVerifiers:          config.FraudVerifiers,
+		FraudReexecutionFactor:  config.FraudReexecutionFactor,
+		CompromiseMinShardSize:  config.CompromiseMinShardSize,
 	}
 
 	// Create and run simulation
 	sim := simulation.NewSimulation(cfg, metricsCollector)
 	fmt.Println("Local simulation started.")
 	sim.Run()
+	runReplicas(cfg, metricsCollector)
 	fmt.Println("Local simulation completed.")
 
 	// Generate metrics report
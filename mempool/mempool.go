@@ -0,0 +1,86 @@
+// mempool/mempool.go
+
+package mempool
+
+import "sharding/block"
+
+// Mempool holds the pending transactions of one shard in arrival order, along with the
+// cross-shard receipts waiting to be credited in the shard
+type Mempool struct {
+	ShardID  int
+	Capacity int
+	pending  []*block.Transaction
+	receipts []*block.Receipt
+	// Counters over the whole run
+	Added           int
+	Dropped         int
+	Included        int
+	AbortedReceipts int
+	// Mempool size seen by every block producer, used for backlog statistics
+	BacklogSamples []int
+}
+
+func NewMempool(shardID int, capacity int) *Mempool {
+	return &Mempool{
+		ShardID:        shardID,
+		Capacity:       capacity,
+		pending:        make([]*block.Transaction, 0),
+		BacklogSamples: make([]int, 0),
+	}
+}
+
+// Add queues a transaction. It returns false and drops the transaction when the mempool is full.
+func (m *Mempool) Add(tx *block.Transaction) bool {
+	if m.Capacity > 0 && len(m.pending) >= m.Capacity {
+		m.Dropped++
+		return false
+	}
+	m.pending = append(m.pending, tx)
+	m.Added++
+	return true
+}
+
+// Take removes up to n of the oldest transactions for a new block
+func (m *Mempool) Take(n int) []*block.Transaction {
+	m.BacklogSamples = append(m.BacklogSamples, len(m.pending))
+	n = min(n, len(m.pending))
+	txs := make([]*block.Transaction, n)
+	copy(txs, m.pending[:n])
+	m.pending = m.pending[n:]
+	m.Included += n
+	return txs
+}
+
+func (m *Mempool) Len() int {
+	return len(m.pending)
+}
+
+// AddReceipt queues a receipt for crediting. Receipts are obligations of the protocol, so the
+// mempool capacity does not apply to them.
+func (m *Mempool) AddReceipt(r *block.Receipt) {
+	m.receipts = append(m.receipts, r)
+}
+
+// TakeReceipts removes up to n receipts that are available at time now, oldest debit first.
+// Receipts whose source block is no longer valid according to isValid are dropped as aborted.
+func (m *Mempool) TakeReceipts(now float64, n int, isValid func(*block.Receipt) bool) []*block.Receipt {
+	taken := make([]*block.Receipt, 0)
+	remaining := m.receipts[:0]
+	for _, r := range m.receipts {
+		switch {
+		case len(taken) >= n || r.AvailableAt > now:
+			remaining = append(remaining, r)
+		case !isValid(r):
+			m.AbortedReceipts++
+		default:
+			taken = append(taken, r)
+		}
+	}
+	m.receipts = remaining
+	return taken
+}
+
+// PendingReceipts returns the number of receipts not credited yet
+func (m *Mempool) PendingReceipts() int {
+	return len(m.receipts)
+}
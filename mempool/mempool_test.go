@@ -0,0 +1,48 @@
+// mempool/mempool_test.go
+
+package mempool
+
+import (
+	"sharding/block"
+	"testing"
+)
+
+func TestFullMempoolDropsNewTransactions(t *testing.T) {
+	m := NewMempool(0, 2)
+	for id := 0; id < 3; id++ {
+		m.Add(&block.Transaction{ID: id})
+	}
+	if m.Len() != 2 || m.Added != 2 || m.Dropped != 1 {
+		t.Errorf("len %d, added %d, dropped %d, want 2, 2 and 1", m.Len(), m.Added, m.Dropped)
+	}
+
+	unbounded := NewMempool(0, 0)
+	for id := 0; id < 100; id++ {
+		if !unbounded.Add(&block.Transaction{ID: id}) {
+			t.Fatalf("mempool without capacity dropped transaction %d", id)
+		}
+	}
+}
+
+func TestTakeOldestFirst(t *testing.T) {
+	m := NewMempool(0, 0)
+	for id := 0; id < 5; id++ {
+		m.Add(&block.Transaction{ID: id})
+	}
+
+	first := m.Take(3)
+	second := m.Take(3)
+	if len(first) != 3 || first[0].ID != 0 || first[2].ID != 2 {
+		t.Errorf("first block took %d transactions starting at %d, want 0 to 2", len(first), first[0].ID)
+	}
+	if len(second) != 2 || second[0].ID != 3 {
+		t.Errorf("second block took %d transactions, want the remaining 2", len(second))
+	}
+	if m.Included != 5 || m.Len() != 0 {
+		t.Errorf("included %d with %d left, want 5 and none", m.Included, m.Len())
+	}
+	// Every producer records the backlog it found
+	if want := []int{5, 2}; len(m.BacklogSamples) != 2 || m.BacklogSamples[0] != want[0] || m.BacklogSamples[1] != want[1] {
+		t.Errorf("backlog samples %v, want %v", m.BacklogSamples, want)
+	}
+}
@@ -0,0 +1,143 @@
+// metrics/bandwidth.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sharding/bandwidth"
+	"sharding/shard"
+	"sort"
+)
+
+// BandwidthResponse reports how long transfers waited for busy links and how busy the links were.
+// Utilization is the share of the simulated time a link spent transmitting.
+type BandwidthResponse struct {
+	Transfers     int                         `json:"transfers"`
+	QueueingDelay LatencyStats                `json:"queueing_delay"`
+	Shards        map[int]ShardBandwidthStats `json:"shards"`
+	Nodes         map[int]*NodeBandwidthStats `json:"nodes"`
+	Busiest       []int                       `json:"busiest_uploaders"`
+}
+
+// ShardBandwidthStats covers the transfers of a shard's blocks and ER bodies and the links of its
+// members at the end of the simulation
+type ShardBandwidthStats struct {
+	QueueingDelay    LatencyStats `json:"queueing_delay"`
+	MeanUploadUtil   float64      `json:"mean_upload_utilization"`
+	MaxUploadUtil    float64      `json:"max_upload_utilization"`
+	MeanDownloadUtil float64      `json:"mean_download_utilization"`
+	MaxDownloadUtil  float64      `json:"max_download_utilization"`
+}
+
+type NodeBandwidthStats struct {
+	Uploads          int     `json:"uploads"`
+	Downloads        int     `json:"downloads"`
+	UploadUtil       float64 `json:"upload_utilization"`
+	DownloadUtil     float64 `json:"download_utilization"`
+	MeanUploadWait   float64 `json:"mean_upload_wait_ms"`
+	MeanDownloadWait float64 `json:"mean_download_wait_ms"`
+}
+
+// CollectBandwidth summarises queueing on the access links of every node that sent or received
+func (mc *MetricsCollector) CollectBandwidth(links *bandwidth.Links, shards map[int]*shard.Shard, simulationTime int64) {
+	response := &BandwidthResponse{
+		Transfers: len(links.Transfers),
+		Shards:    make(map[int]ShardBandwidthStats),
+		Nodes:     make(map[int]*NodeBandwidthStats),
+	}
+	utilization := func(q *bandwidth.Queue) float64 {
+		if q == nil || simulationTime <= 0 {
+			return 0
+		}
+		return math.Min(q.Busy/float64(simulationTime), 1)
+	}
+	nodeStats := func(id int) *NodeBandwidthStats {
+		if _, exists := response.Nodes[id]; !exists {
+			response.Nodes[id] = &NodeBandwidthStats{
+				UploadUtil:   utilization(links.Upload[id]),
+				DownloadUtil: utilization(links.Download[id]),
+			}
+		}
+		return response.Nodes[id]
+	}
+
+	waits := make([]float64, len(links.Transfers))
+	shardWaits := make(map[int][]float64)
+	for i, t := range links.Transfers {
+		waits[i] = t.Wait
+		shardWaits[t.ShardID] = append(shardWaits[t.ShardID], t.Wait)
+		sender, receiver := nodeStats(t.From), nodeStats(t.To)
+		sender.Uploads++
+		sender.MeanUploadWait += t.Wait
+		receiver.Downloads++
+		receiver.MeanDownloadWait += t.Wait
+	}
+	for _, n := range response.Nodes {
+		if n.Uploads > 0 {
+			n.MeanUploadWait /= float64(n.Uploads)
+		}
+		if n.Downloads > 0 {
+			n.MeanDownloadWait /= float64(n.Downloads)
+		}
+	}
+	response.QueueingDelay = NewLatencyStats(waits)
+
+	for id, s := range shards {
+		stats := ShardBandwidthStats{QueueingDelay: NewLatencyStats(shardWaits[id])}
+		for nodeID := range s.Nodes {
+			up, down := utilization(links.Upload[nodeID]), utilization(links.Download[nodeID])
+			stats.MeanUploadUtil += up
+			stats.MeanDownloadUtil += down
+			stats.MaxUploadUtil = math.Max(stats.MaxUploadUtil, up)
+			stats.MaxDownloadUtil = math.Max(stats.MaxDownloadUtil, down)
+		}
+		if len(s.Nodes) > 0 {
+			stats.MeanUploadUtil /= float64(len(s.Nodes))
+			stats.MeanDownloadUtil /= float64(len(s.Nodes))
+		}
+		response.Shards[id] = stats
+	}
+
+	for id := range response.Nodes {
+		response.Busiest = append(response.Busiest, id)
+	}
+	sort.Slice(response.Busiest, func(i, j int) bool {
+		a, b := response.Nodes[response.Busiest[i]], response.Nodes[response.Busiest[j]]
+		if a.UploadUtil != b.UploadUtil {
+			return a.UploadUtil > b.UploadUtil
+		}
+		return response.Busiest[i] < response.Busiest[j]
+	})
+	response.Busiest = response.Busiest[:min(5, len(response.Busiest))]
+	mc.Bandwidth = response
+}
+
+func (mc *MetricsCollector) writeBandwidthMetrics(w io.Writer) {
+	if mc.Bandwidth == nil {
+		return
+	}
+	b := mc.Bandwidth
+	fmt.Fprintf(w, "Bandwidth Metrics:\n")
+	fmt.Fprintf(w, "  Transfers: %d\n", b.Transfers)
+	fmt.Fprintf(w, "  Queueing Delay: %s\n", b.QueueingDelay)
+	shardIDs := make([]int, 0, len(b.Shards))
+	for id := range b.Shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		s := b.Shards[id]
+		fmt.Fprintf(w, "  Shard %d Queueing Delay: %s\n", id, s.QueueingDelay)
+		fmt.Fprintf(w, "  Shard %d Link Utilization: upload %.2f%% mean, %.2f%% max, download %.2f%% mean, %.2f%% max\n",
+			id, s.MeanUploadUtil*100, s.MaxUploadUtil*100, s.MeanDownloadUtil*100, s.MaxDownloadUtil*100)
+	}
+	fmt.Fprintf(w, "  Busiest Uploaders:\n")
+	for _, id := range b.Busiest {
+		n := b.Nodes[id]
+		fmt.Fprintf(w, "    Node %d: upload %.2f%%, download %.2f%%, %d uploads waiting %.2fms on average\n",
+			id, n.UploadUtil*100, n.DownloadUtil*100, n.Uploads, n.MeanUploadWait)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,84 @@
+// metrics/beacon.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/block"
+	"sharding/config"
+)
+
+// BeaconResponse describes how fast shard headers make it into the beacon chain and how much
+// the beacon chain carries. Per-shard figures make runs with different shard counts comparable.
+type BeaconResponse struct {
+	NumShards              int          `json:"num_shards"`
+	BlockInterval          int64        `json:"block_interval"`
+	Blocks                 int          `json:"blocks"`
+	InclusionDelay         LatencyStats `json:"crosslink_inclusion_delay"`
+	PropagationDelay       LatencyStats `json:"propagation_delay"`
+	MeanCrosslinks         float64      `json:"mean_crosslinks_per_block"`
+	MaxCrosslinks          int          `json:"max_crosslinks_per_block"`
+	MeanCrosslinksPerShard float64      `json:"mean_crosslinks_per_block_per_shard"`
+	MeanBlockSize          float64      `json:"mean_block_size_bytes"`
+	MaxBlockSize           int          `json:"max_block_size_bytes"`
+	Load                   float64      `json:"load_bytes_per_time_unit"`
+	PendingHeaders         int          `json:"pending_headers"`
+}
+
+// CollectBeacon records crosslink inclusion delays and the size of every beacon block
+func (mc *MetricsCollector) CollectBeacon(blocks []*block.BeaconBlock, pendingHeaders int, propagationDelays []int64, cfg *config.Config) {
+	response := &BeaconResponse{
+		NumShards:      cfg.NumShards,
+		BlockInterval:  cfg.BeaconBlockInterval,
+		Blocks:         len(blocks),
+		PendingHeaders: pendingHeaders,
+	}
+
+	inclusionDelays := make([]float64, 0)
+	totalCrosslinks, totalSize := 0, 0
+	for _, b := range blocks {
+		for _, header := range b.Crosslinks {
+			inclusionDelays = append(inclusionDelays, float64(b.Timestamp-header.Timestamp)*1000.0)
+		}
+		size := b.Size(cfg.BlockHeaderSize)
+		totalCrosslinks += len(b.Crosslinks)
+		totalSize += size
+		response.MaxCrosslinks = max(response.MaxCrosslinks, len(b.Crosslinks))
+		response.MaxBlockSize = max(response.MaxBlockSize, size)
+	}
+	if len(blocks) > 0 {
+		response.MeanCrosslinks = float64(totalCrosslinks) / float64(len(blocks))
+		response.MeanBlockSize = float64(totalSize) / float64(len(blocks))
+	}
+	if cfg.NumShards > 0 {
+		response.MeanCrosslinksPerShard = response.MeanCrosslinks / float64(cfg.NumShards)
+	}
+	if cfg.SimulationTime > 0 {
+		response.Load = float64(totalSize) / float64(cfg.SimulationTime)
+	}
+
+	delays := make([]float64, len(propagationDelays))
+	for i, d := range propagationDelays {
+		delays[i] = float64(d)
+	}
+	response.InclusionDelay = NewLatencyStats(inclusionDelays)
+	response.PropagationDelay = NewLatencyStats(delays)
+	mc.Beacon = response
+}
+
+func (mc *MetricsCollector) writeBeaconMetrics(w io.Writer) {
+	if mc.Beacon == nil {
+		return
+	}
+	b := mc.Beacon
+	fmt.Fprintf(w, "Beacon Chain Metrics:\n")
+	fmt.Fprintf(w, "  Beacon Blocks: %d, one every %d time units, crosslinking %d shards\n", b.Blocks, b.BlockInterval, b.NumShards)
+	fmt.Fprintf(w, "  Crosslink Inclusion Delay: %s\n", b.InclusionDelay)
+	fmt.Fprintf(w, "  Beacon Block Propagation Delay: %s\n", b.PropagationDelay)
+	fmt.Fprintf(w, "  Crosslinks per Beacon Block: %.2f mean, %d max, %.2f per shard\n", b.MeanCrosslinks, b.MaxCrosslinks, b.MeanCrosslinksPerShard)
+	fmt.Fprintf(w, "  Beacon Block Size: %.2f bytes mean, %d bytes max\n", b.MeanBlockSize, b.MaxBlockSize)
+	fmt.Fprintf(w, "  Beacon Chain Load: %.2f bytes per time unit\n", b.Load)
+	fmt.Fprintf(w, "  Headers Waiting for a Crosslink: %d\n", b.PendingHeaders)
+	fmt.Fprintf(w, "\n")
+}
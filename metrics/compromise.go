@@ -0,0 +1,189 @@
+// metrics/compromise.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/attack"
+	"sharding/compromise"
+	"sort"
+)
+
+// CompromiseResponse reports when each shard had a malicious share above Threshold and, over
+// every replica run, how often a shard got compromised at all
+type CompromiseResponse struct {
+	Threshold    float64                 `json:"threshold"`
+	MinShardSize int                     `json:"min_shard_size"`
+	Shards       map[int]ShardCompromise `json:"shards"`
+	// Takeover is nil when no takeover attack is scheduled
+	Takeover            *TakeoverStats  `json:"takeover,omitempty"`
+	Replicas            int             `json:"replicas"`
+	CompromisedReplicas int             `json:"compromised_replicas"`
+	Probability         float64         `json:"compromise_probability"`
+	ShardProbability    map[int]float64 `json:"shard_compromise_probability"`
+	// MeanFirstCompromise averages the first compromise time of the compromised replicas, -1 when none was
+	MeanFirstCompromise float64 `json:"mean_first_compromise"`
+	shardReplicas       map[int]int
+	firstCompromises    []int64
+}
+
+// ShardCompromise describes one shard of the primary run. FirstCompromise is -1 when the shard
+// never was compromised.
+type ShardCompromise struct {
+	FirstCompromise int64                `json:"first_compromise"`
+	CompromisedTime int64                `json:"compromised_time"`
+	CompromisedRate float64              `json:"compromised_rate"`
+	PeakShare       float64              `json:"peak_malicious_share"`
+	Intervals       []CompromiseInterval `json:"intervals"`
+}
+
+// CompromiseInterval has End -1 when the shard was still compromised at the end of the simulation
+type CompromiseInterval struct {
+	Start     int64   `json:"start"`
+	End       int64   `json:"end"`
+	PeakShare float64 `json:"peak_malicious_share"`
+}
+
+type TakeoverStats struct {
+	TargetShard int `json:"target_shard"`
+	Joined      int `json:"joined"`
+	Discarded   int `json:"discarded_wins"`
+}
+
+// CollectCompromise summarises the compromised intervals of every shard up to now. The run counts
+// as the only replica until AddReplica folds in others.
+func (mc *MetricsCollector) CollectCompromise(tracker *compromise.Tracker, takeover *attack.Takeover, numShards int, now int64) {
+	response := &CompromiseResponse{
+		Threshold:        tracker.Threshold,
+		MinShardSize:     tracker.MinSize,
+		Shards:           make(map[int]ShardCompromise),
+		Replicas:         1,
+		ShardProbability: make(map[int]float64),
+		shardReplicas:    make(map[int]int),
+		firstCompromises: make([]int64, 0),
+	}
+	if takeover != nil {
+		response.Takeover = &TakeoverStats{TargetShard: takeover.TargetShard, Joined: takeover.Joined, Discarded: takeover.Discarded}
+	}
+	firstCompromise := int64(-1)
+	for shardID := 0; shardID < numShards; shardID++ {
+		stats := ShardCompromise{FirstCompromise: -1, PeakShare: tracker.PeakShare[shardID], Intervals: make([]CompromiseInterval, 0)}
+		for _, interval := range tracker.Intervals[shardID] {
+			if stats.FirstCompromise < 0 {
+				stats.FirstCompromise = interval.Start
+			}
+			stats.CompromisedTime += interval.Duration(now)
+			stats.Intervals = append(stats.Intervals, CompromiseInterval{Start: interval.Start, End: interval.End, PeakShare: interval.PeakShare})
+		}
+		if now > 0 {
+			stats.CompromisedRate = float64(stats.CompromisedTime) / float64(now)
+		}
+		if stats.FirstCompromise >= 0 {
+			response.shardReplicas[shardID]++
+			if firstCompromise < 0 || stats.FirstCompromise < firstCompromise {
+				firstCompromise = stats.FirstCompromise
+			}
+		}
+		response.Shards[shardID] = stats
+	}
+	if firstCompromise >= 0 {
+		response.firstCompromises = append(response.firstCompromises, firstCompromise)
+	}
+	response.estimate()
+	mc.Compromise = response
+}
+
+// AddReplica folds the results of another run of the same configuration into the estimates
+// across replicas
+func (mc *MetricsCollector) AddReplica(replica *MetricsCollector) {
+	if mc.SecurityModel != nil && replica.SecurityModel != nil {
+		for shardID, stats := range replica.SecurityModel.Shards {
+			merged := mc.SecurityModel.Shards[shardID]
+			total := merged.Observations + stats.Observations
+			if total > 0 {
+				merged.Size = (merged.Size*float64(merged.Observations) + stats.Size*float64(stats.Observations)) / float64(total)
+				merged.Hypergeometric = (merged.Hypergeometric*float64(merged.Observations) + stats.Hypergeometric*float64(stats.Observations)) / float64(total)
+				merged.Binomial = (merged.Binomial*float64(merged.Observations) + stats.Binomial*float64(stats.Observations)) / float64(total)
+			}
+			merged.Observations = total
+			merged.Exceeded += stats.Exceeded
+			mc.SecurityModel.Shards[shardID] = merged
+		}
+		mc.SecurityModel.estimate()
+	}
+	if mc.Compromise == nil || replica.Compromise == nil {
+		return
+	}
+	mc.Compromise.Replicas += replica.Compromise.Replicas
+	for shardID, count := range replica.Compromise.shardReplicas {
+		mc.Compromise.shardReplicas[shardID] += count
+	}
+	mc.Compromise.firstCompromises = append(mc.Compromise.firstCompromises, replica.Compromise.firstCompromises...)
+	mc.Compromise.estimate()
+}
+
+// estimate turns the replica counts into probabilities of compromise
+func (r *CompromiseResponse) estimate() {
+	r.CompromisedReplicas = len(r.firstCompromises)
+	r.Probability = float64(r.CompromisedReplicas) / float64(r.Replicas)
+	for shardID := range r.Shards {
+		r.ShardProbability[shardID] = float64(r.shardReplicas[shardID]) / float64(r.Replicas)
+	}
+	r.MeanFirstCompromise = -1
+	if len(r.firstCompromises) > 0 {
+		total := int64(0)
+		for _, t := range r.firstCompromises {
+			total += t
+		}
+		r.MeanFirstCompromise = float64(total) / float64(len(r.firstCompromises))
+	}
+}
+
+func (mc *MetricsCollector) writeCompromiseMetrics(w io.Writer) {
+	if mc.Compromise == nil {
+		return
+	}
+	c := mc.Compromise
+	fmt.Fprintf(w, "Shard Compromise Metrics:\n")
+	if c.MinShardSize > 0 {
+		fmt.Fprintf(w, "  Compromise Threshold: %.2f%% malicious, in shards of at least %d members\n", c.Threshold*100, c.MinShardSize)
+	} else {
+		fmt.Fprintf(w, "  Compromise Threshold: %.2f%% malicious\n", c.Threshold*100)
+	}
+	if c.Takeover != nil {
+		fmt.Fprintf(w, "  Takeover of Shard %d: %d malicious nodes joined, %d wins discarded\n", c.Takeover.TargetShard, c.Takeover.Joined, c.Takeover.Discarded)
+	}
+	shardIDs := make([]int, 0, len(c.Shards))
+	for id := range c.Shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		stats := c.Shards[id]
+		fmt.Fprintf(w, "  Shard %d:\n", id)
+		fmt.Fprintf(w, "    Peak Malicious Share: %.2f%%\n", stats.PeakShare*100)
+		if stats.FirstCompromise < 0 {
+			fmt.Fprintf(w, "    Never Compromised\n")
+			continue
+		}
+		fmt.Fprintf(w, "    First Compromise: time %d\n", stats.FirstCompromise)
+		fmt.Fprintf(w, "    Compromised Duration: %d time units (%.2f%% of the run) over %d intervals\n", stats.CompromisedTime, stats.CompromisedRate*100, len(stats.Intervals))
+		for _, interval := range stats.Intervals {
+			if interval.End < 0 {
+				fmt.Fprintf(w, "      From %d until the end, peak %.2f%%\n", interval.Start, interval.PeakShare*100)
+			} else {
+				fmt.Fprintf(w, "      From %d to %d, peak %.2f%%\n", interval.Start, interval.End, interval.PeakShare*100)
+			}
+		}
+	}
+	fmt.Fprintf(w, "  Replicas: %d, %d with a compromised shard\n", c.Replicas, c.CompromisedReplicas)
+	fmt.Fprintf(w, "  Estimated Probability of Compromise: %.2f%%\n", c.Probability*100)
+	for _, id := range shardIDs {
+		fmt.Fprintf(w, "    Shard %d: %.2f%%\n", id, c.ShardProbability[id]*100)
+	}
+	if c.MeanFirstCompromise >= 0 {
+		fmt.Fprintf(w, "  Mean First Compromise: time %.2f\n", c.MeanFirstCompromise)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,188 @@
+// metrics/corruption.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/compromise"
+	"sharding/config"
+	"sharding/corruption"
+	"sort"
+)
+
+// CorruptionResponse reports how many corruptions of an adaptive adversary landed before the
+// lottery rotated their nodes out of the shard, next to what the rotation rate predicts. Times
+// are in simulation time units.
+type CorruptionResponse struct {
+	Delay      int64  `json:"corruption_delay"`
+	Budget     int    `json:"budget"`
+	TargetRule string `json:"target_rule"`
+	Started    int    `json:"started"`
+	Landed     int    `json:"landed"`
+	Escaped    int    `json:"escaped"`
+	Pending    int    `json:"pending"`
+	// Share of the resolved corruptions that landed, and the share the rotation rate predicts
+	LandedRate         float64 `json:"landed_rate"`
+	ExpectedLandedRate float64 `json:"expected_landed_rate"`
+	WinProbability     float64 `json:"lottery_win_probability"`
+	RotationInterval   int64   `json:"rotation_interval"`
+	// Only stays that ended count, so a run shorter than the expected residence understates it
+	MeanResidence     float64 `json:"mean_residence"`
+	ExpectedResidence float64 `json:"expected_residence"`
+	// Time after which half the members of a shard have rotated out. Rotation stays ahead of an
+	// adversary whose delay is at least that long.
+	RotationHalfLife float64 `json:"rotation_half_life"`
+	RotationAhead    bool    `json:"rotation_ahead"`
+	// Lowest win probability at which half the corruptions fail, -1 when none does
+	RequiredWinProbability float64                      `json:"required_win_probability"`
+	Rotation               []RotationPoint              `json:"rotation"`
+	Shards                 map[int]CorruptionShardStats `json:"shards"`
+}
+
+// RotationPoint is the share of corruptions that would land at another lottery win probability
+type RotationPoint struct {
+	WinProbability     float64 `json:"lottery_win_probability"`
+	RotationHalfLife   float64 `json:"rotation_half_life"`
+	ExpectedLandedRate float64 `json:"expected_landed_rate"`
+}
+
+// CorruptionShardStats describes the corruptions in one shard. FirstCompromise is the first time
+// the shard went above the threshold after the adversary first went for it, -1 if it never did.
+type CorruptionShardStats struct {
+	Targeted        int   `json:"targeted_rounds"`
+	Started         int   `json:"started"`
+	Landed          int   `json:"landed"`
+	Escaped         int   `json:"escaped"`
+	FirstCompromise int64 `json:"first_compromise"`
+}
+
+// rotationMultipliers are the multiples of the configured win probability the rotation sweep covers
+var rotationMultipliers = []float64{0.25, 0.5, 1, 2, 4, 8, 16}
+
+// CollectCorruption compares the corruptions of the adaptive adversary with the rotation rate
+func (mc *MetricsCollector) CollectCorruption(adversary *corruption.Adversary, tracker *compromise.Tracker, cfg *config.Config) {
+	// Same fallback as the lottery for configurations that leave the win probability out
+	winProbability := cfg.LotteryWinProbability
+	if winProbability <= 0 {
+		winProbability = config.LotteryWinProbability
+	}
+	interval := cfg.BlockProductionInterval
+	response := &CorruptionResponse{
+		Delay:                  adversary.Delay,
+		Budget:                 adversary.Budget,
+		TargetRule:             adversary.Rule.String(),
+		Started:                len(adversary.Corruptions),
+		Landed:                 adversary.Landed,
+		Escaped:                adversary.Escaped,
+		Pending:                adversary.Pending(),
+		ExpectedLandedRate:     corruption.Survival(winProbability, interval, cfg.NumShards, adversary.Delay),
+		WinProbability:         winProbability,
+		RotationInterval:       interval,
+		ExpectedResidence:      -1,
+		RotationHalfLife:       corruption.HalfLife(winProbability, interval, cfg.NumShards),
+		RequiredWinProbability: corruption.RequiredWinProbability(0.5, interval, cfg.NumShards, adversary.Delay),
+		Rotation:               make([]RotationPoint, 0, len(rotationMultipliers)),
+		Shards:                 make(map[int]CorruptionShardStats),
+	}
+	if resolved := adversary.Landed + adversary.Escaped; resolved > 0 {
+		response.LandedRate = float64(adversary.Landed) / float64(resolved)
+	}
+	if move := corruption.MoveProbability(winProbability, cfg.NumShards); move > 0 {
+		response.ExpectedResidence = float64(interval) / move
+	}
+	response.RotationAhead = response.RotationHalfLife > 0 && float64(adversary.Delay) >= response.RotationHalfLife
+	if len(adversary.Residences) > 0 {
+		total := int64(0)
+		for _, residence := range adversary.Residences {
+			total += residence
+		}
+		response.MeanResidence = float64(total) / float64(len(adversary.Residences))
+	}
+	for _, multiplier := range rotationMultipliers {
+		p := min(1, winProbability*multiplier)
+		response.Rotation = append(response.Rotation, RotationPoint{
+			WinProbability:     p,
+			RotationHalfLife:   corruption.HalfLife(p, interval, cfg.NumShards),
+			ExpectedLandedRate: corruption.Survival(p, interval, cfg.NumShards, adversary.Delay),
+		})
+	}
+
+	firstTargeted := make(map[int]int64)
+	for _, target := range adversary.Targets {
+		stats := response.Shards[target.ShardID]
+		if stats.Targeted == 0 {
+			firstTargeted[target.ShardID] = target.Time
+		}
+		stats.Targeted++
+		response.Shards[target.ShardID] = stats
+	}
+	for _, c := range adversary.Corruptions {
+		stats := response.Shards[c.ShardID]
+		stats.Started++
+		if c.Landed {
+			stats.Landed++
+		}
+		if c.Escaped {
+			stats.Escaped++
+		}
+		response.Shards[c.ShardID] = stats
+	}
+	for shardID, stats := range response.Shards {
+		stats.FirstCompromise = -1
+		for _, interval := range tracker.Intervals[shardID] {
+			if interval.Start >= firstTargeted[shardID] {
+				stats.FirstCompromise = interval.Start
+				break
+			}
+		}
+		response.Shards[shardID] = stats
+	}
+	mc.Corruption = response
+}
+
+func (mc *MetricsCollector) writeCorruptionMetrics(w io.Writer) {
+	if mc.Corruption == nil {
+		return
+	}
+	c := mc.Corruption
+	fmt.Fprintf(w, "Adaptive Corruption Metrics:\n")
+	budget := "no limit"
+	if c.Budget > 0 {
+		budget = fmt.Sprintf("%d", c.Budget)
+	}
+	fmt.Fprintf(w, "  Corruption Delay: %d, Budget: %s, Target: %s shard\n", c.Delay, budget, c.TargetRule)
+	fmt.Fprintf(w, "  Corruptions: %d started, %d landed, %d escaped by rotation, %d pending\n", c.Started, c.Landed, c.Escaped, c.Pending)
+	fmt.Fprintf(w, "  Landed Rate: %.2f%% (expected %.2f%%)\n", c.LandedRate*100, c.ExpectedLandedRate*100)
+	fmt.Fprintf(w, "  Rotation: win probability %.4f every %d, mean residence %.2f (expected %.2f), half-life %.2f\n",
+		c.WinProbability, c.RotationInterval, c.MeanResidence, c.ExpectedResidence, c.RotationHalfLife)
+	if c.RotationAhead {
+		fmt.Fprintf(w, "  Rotation stays ahead: the corruption delay is at least the rotation half-life\n")
+	} else {
+		fmt.Fprintf(w, "  Rotation falls behind: the corruption delay is shorter than the rotation half-life\n")
+	}
+	if c.RequiredWinProbability >= 0 {
+		fmt.Fprintf(w, "  Win Probability for Half the Corruptions to Fail: %.4f\n", c.RequiredWinProbability)
+	} else {
+		fmt.Fprintf(w, "  Win Probability for Half the Corruptions to Fail: none\n")
+	}
+	for _, point := range c.Rotation {
+		fmt.Fprintf(w, "    Win Probability %.4f: half-life %.2f, expected landed rate %.2f%%\n",
+			point.WinProbability, point.RotationHalfLife, point.ExpectedLandedRate*100)
+	}
+	shardIDs := make([]int, 0, len(c.Shards))
+	for shardID := range c.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+	for _, shardID := range shardIDs {
+		stats := c.Shards[shardID]
+		compromised := "never compromised"
+		if stats.FirstCompromise >= 0 {
+			compromised = fmt.Sprintf("compromised at %d", stats.FirstCompromise)
+		}
+		fmt.Fprintf(w, "  Shard %d: targeted in %d rounds, %d started, %d landed, %d escaped, %s\n",
+			shardID, stats.Targeted, stats.Started, stats.Landed, stats.Escaped, compromised)
+	}
+	fmt.Fprintf(w, "\n")
+}
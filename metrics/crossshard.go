@@ -0,0 +1,125 @@
+// metrics/crossshard.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/block"
+	"sharding/shard"
+)
+
+// CrossShardMetrics follows cross-shard transactions from their debit to their last credit,
+// latencies are in milliseconds
+type CrossShardMetrics struct {
+	BlockProductionInterval int64
+	Debited                 int
+	Completed               int
+	PendingReceipts         int
+	AbortedReceipts         int
+	EndToEnd                []float64
+	Debit                   []float64
+	Relay                   []float64
+	InclusionWait           []float64
+	Settlement              []float64
+}
+
+type CrossShardResponse struct {
+	BlockProductionInterval int64        `json:"block_production_interval"`
+	Debited                 int          `json:"debited"`
+	Completed               int          `json:"completed"`
+	PendingReceipts         int          `json:"pending_receipts"`
+	AbortedReceipts         int          `json:"aborted_receipts"`
+	EndToEnd                LatencyStats `json:"end_to_end_latency"`
+	Debit                   LatencyStats `json:"debit_latency"`
+	Relay                   LatencyStats `json:"header_relay_latency"`
+	InclusionWait           LatencyStats `json:"credit_inclusion_latency"`
+	Settlement              LatencyStats `json:"settlement_latency"`
+	// Mean settlement latency in block production intervals
+	SettlementIntervals float64 `json:"settlement_intervals"`
+}
+
+// CollectCrossShard matches the debits and credits of cross-shard transactions on the canonical
+// chains. A transaction completes once every target shard has credited it.
+func (mc *MetricsCollector) CollectCrossShard(shards map[int]*shard.Shard, blockProductionInterval int64) {
+	cs := &CrossShardMetrics{
+		BlockProductionInterval: blockProductionInterval,
+		EndToEnd:                make([]float64, 0),
+		Debit:                   make([]float64, 0),
+		Relay:                   make([]float64, 0),
+		InclusionWait:           make([]float64, 0),
+		Settlement:              make([]float64, 0),
+	}
+	mc.CrossShard = cs
+
+	debited := make(map[int]*block.Transaction)
+	credits := make(map[int]int)
+	lastCredit := make(map[int]int64)
+	for _, s := range shards {
+		cs.PendingReceipts += s.Mempool.PendingReceipts()
+		cs.AbortedReceipts += s.Mempool.AbortedReceipts
+		for _, blk := range s.CanonicalChain() {
+			if blk.IsMalicious {
+				continue
+			}
+			for _, tx := range blk.Transactions {
+				if len(tx.TargetShards) > 0 {
+					debited[tx.ID] = tx
+					cs.Debit = append(cs.Debit, (float64(blk.Timestamp)-tx.CreatedAt)*1000.0)
+				}
+			}
+			for _, r := range blk.Receipts {
+				credits[r.Tx.ID]++
+				lastCredit[r.Tx.ID] = max(lastCredit[r.Tx.ID], blk.Timestamp)
+				cs.Relay = append(cs.Relay, (r.AvailableAt-float64(r.DebitedAt))*1000.0)
+				cs.InclusionWait = append(cs.InclusionWait, (float64(blk.Timestamp)-r.AvailableAt)*1000.0)
+				cs.Settlement = append(cs.Settlement, float64(blk.Timestamp-r.DebitedAt)*1000.0)
+			}
+		}
+	}
+
+	cs.Debited = len(debited)
+	for txID, tx := range debited {
+		if credits[txID] == len(tx.TargetShards) {
+			cs.Completed++
+			cs.EndToEnd = append(cs.EndToEnd, (float64(lastCredit[txID])-tx.CreatedAt)*1000.0)
+		}
+	}
+}
+
+func (mc *MetricsCollector) crossShardResponse() *CrossShardResponse {
+	cs := mc.CrossShard
+	response := &CrossShardResponse{
+		BlockProductionInterval: cs.BlockProductionInterval,
+		Debited:                 cs.Debited,
+		Completed:               cs.Completed,
+		PendingReceipts:         cs.PendingReceipts,
+		AbortedReceipts:         cs.AbortedReceipts,
+		EndToEnd:                NewLatencyStats(cs.EndToEnd),
+		Debit:                   NewLatencyStats(cs.Debit),
+		Relay:                   NewLatencyStats(cs.Relay),
+		InclusionWait:           NewLatencyStats(cs.InclusionWait),
+		Settlement:              NewLatencyStats(cs.Settlement),
+	}
+	if cs.BlockProductionInterval > 0 {
+		response.SettlementIntervals = response.Settlement.Mean / float64(cs.BlockProductionInterval*1000)
+	}
+	return response
+}
+
+func (mc *MetricsCollector) writeCrossShardMetrics(w io.Writer) {
+	if mc.CrossShard == nil {
+		return
+	}
+	response := mc.crossShardResponse()
+	fmt.Fprintf(w, "Cross-Shard Transaction Metrics:\n")
+	fmt.Fprintf(w, "  Transactions: %d debited, %d fully credited\n", response.Debited, response.Completed)
+	fmt.Fprintf(w, "  Receipts: %d pending, %d aborted\n", response.PendingReceipts, response.AbortedReceipts)
+	fmt.Fprintf(w, "  End-to-End Latency: %s\n", response.EndToEnd)
+	fmt.Fprintf(w, "  Debit Latency: %s\n", response.Debit)
+	fmt.Fprintf(w, "  Header Relay Latency: %s\n", response.Relay)
+	fmt.Fprintf(w, "  Credit Inclusion Latency: %s\n", response.InclusionWait)
+	fmt.Fprintf(w, "  Settlement Latency: %s\n", response.Settlement)
+	fmt.Fprintf(w, "  Settlement in Block Production Intervals: %.2f (interval %d)\n", response.SettlementIntervals, response.BlockProductionInterval)
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,122 @@
+// metrics/das.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+	"sharding/das"
+)
+
+// DASRecord is the outcome of data availability sampling for one block
+type DASRecord struct {
+	ShardID              int
+	Withheld             bool
+	Recoverable          bool
+	Samplers             int
+	FailedSamplers       int
+	DetectionProbability float64
+}
+
+// DASResponse compares sampling with downloading full blocks. Byte counts are per block and
+// sampler unless stated otherwise.
+type DASResponse struct {
+	DataChunks            int          `json:"data_chunks"`
+	TotalChunks           int          `json:"total_chunks"`
+	SamplesPerNode        int          `json:"samples_per_node"`
+	PublishBytes          int          `json:"publish_bytes"`
+	SampleBytes           int          `json:"sample_bytes"`
+	FullBlockBytes        int          `json:"full_block_bytes"`
+	BandwidthSaving       float64      `json:"bandwidth_saving"`
+	TotalSampledBytes     int64        `json:"total_sampled_bytes"`
+	TotalFullBytes        int64        `json:"total_full_download_bytes"`
+	SyncSampledBytes      int          `json:"sync_sampled_bytes"`
+	SyncFullBytes         int          `json:"sync_full_download_bytes"`
+	WithheldBlocks        int          `json:"withheld_blocks"`
+	UnrecoverableBlocks   int          `json:"unrecoverable_blocks"`
+	DetectedBlocks        int          `json:"detected_blocks"`
+	BlockDetectionRate    float64      `json:"block_detection_rate"`
+	SamplerDetectionRate  float64      `json:"sampler_detection_rate"`
+	ExpectedDetectionRate float64      `json:"expected_sampler_detection_rate"`
+	FalseAlarms           int          `json:"false_alarms"`
+	SamplingDelay         LatencyStats `json:"sampling_delay"`
+}
+
+// CollectDAS summarises availability detection and the bandwidth sampling used. The full-download
+// baseline is every sampler fetching every block, and a rotated node fetching NumBlocksToDownload
+// full blocks through DownloadLatestKBlocks when syncing.
+func (mc *MetricsCollector) CollectDAS(records []*DASRecord, samplingDelays map[int][]int64, cfg *config.Config) {
+	params := das.NewParams(cfg)
+	response := &DASResponse{
+		DataChunks:       params.DataChunks,
+		TotalChunks:      params.TotalChunks,
+		SamplesPerNode:   params.Samples,
+		PublishBytes:     params.TotalChunks * params.ChunkSize,
+		SampleBytes:      params.Samples * params.SampleSize,
+		FullBlockBytes:   cfg.BlockSize,
+		SyncSampledBytes: cfg.NumBlocksToDownload * params.Samples * params.SampleSize,
+		SyncFullBytes:    cfg.NumBlocksToDownload * cfg.BlockSize,
+	}
+	if cfg.BlockSize > 0 {
+		response.BandwidthSaving = 1 - float64(response.SampleBytes)/float64(cfg.BlockSize)
+	}
+
+	unrecoverableSamplers, failedSamplers := 0, 0
+	expected := 0.0
+	for _, r := range records {
+		response.TotalSampledBytes += int64(r.Samplers * response.SampleBytes)
+		response.TotalFullBytes += int64(r.Samplers * cfg.BlockSize)
+		if r.Withheld {
+			response.WithheldBlocks++
+		}
+		if r.Recoverable {
+			if r.FailedSamplers > 0 {
+				response.FalseAlarms++
+			}
+			continue
+		}
+		response.UnrecoverableBlocks++
+		if r.FailedSamplers > 0 {
+			response.DetectedBlocks++
+		}
+		unrecoverableSamplers += r.Samplers
+		failedSamplers += r.FailedSamplers
+		expected += r.DetectionProbability
+	}
+	if response.UnrecoverableBlocks > 0 {
+		response.BlockDetectionRate = float64(response.DetectedBlocks) / float64(response.UnrecoverableBlocks)
+		response.ExpectedDetectionRate = expected / float64(response.UnrecoverableBlocks)
+	}
+	if unrecoverableSamplers > 0 {
+		response.SamplerDetectionRate = float64(failedSamplers) / float64(unrecoverableSamplers)
+	}
+
+	delays := make([]float64, 0)
+	for _, shardDelays := range samplingDelays {
+		for _, d := range shardDelays {
+			delays = append(delays, float64(d))
+		}
+	}
+	response.SamplingDelay = NewLatencyStats(delays)
+	mc.DAS = response
+}
+
+func (mc *MetricsCollector) writeDASMetrics(w io.Writer) {
+	if mc.DAS == nil {
+		return
+	}
+	d := mc.DAS
+	fmt.Fprintf(w, "Data Availability Sampling Metrics:\n")
+	fmt.Fprintf(w, "  Coding: %d data chunks extended to %d, %d samples per node\n", d.DataChunks, d.TotalChunks, d.SamplesPerNode)
+	fmt.Fprintf(w, "  Withheld Blocks: %d, %d unrecoverable, %d detected (%.2f%%)\n",
+		d.WithheldBlocks, d.UnrecoverableBlocks, d.DetectedBlocks, d.BlockDetectionRate*100)
+	fmt.Fprintf(w, "  Sampler Detection Rate: %.2f%% measured, %.2f%% expected\n", d.SamplerDetectionRate*100, d.ExpectedDetectionRate*100)
+	fmt.Fprintf(w, "  False Alarms: %d\n", d.FalseAlarms)
+	fmt.Fprintf(w, "  Sampling Delay: %s\n", d.SamplingDelay)
+	fmt.Fprintf(w, "  Bandwidth per Block: %d bytes sampled vs %d bytes downloaded (%.2f%% saved), %d bytes published\n",
+		d.SampleBytes, d.FullBlockBytes, d.BandwidthSaving*100, d.PublishBytes)
+	fmt.Fprintf(w, "  Total Bandwidth: %d bytes sampled vs %d bytes for full downloads\n", d.TotalSampledBytes, d.TotalFullBytes)
+	fmt.Fprintf(w, "  Syncing the Latest Blocks: %d bytes sampled vs %d bytes downloaded\n", d.SyncSampledBytes, d.SyncFullBytes)
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,98 @@
+// metrics/eclipse.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/eclipse"
+	"sort"
+)
+
+// EclipseResponse reports how often the adversary cut rotating producers off from every honest
+// peer, by the number of honest operators they kept, and what the producers built on afterwards
+type EclipseResponse struct {
+	PeerSlots         int                         `json:"peer_slots"`
+	SlotShare         float64                     `json:"adversary_slot_share"`
+	Attempts          int                         `json:"attempts"`
+	Eclipsed          int                         `json:"eclipsed"`
+	EclipseRate       float64                     `json:"eclipse_rate"`
+	ByHonestOperators map[int]EclipseOutcomeStats `json:"by_honest_operators"`
+	EclipsedParents   ParentStats                 `json:"eclipsed_parents"`
+	ProtectedParents  ParentStats                 `json:"protected_parents"`
+}
+
+type EclipseOutcomeStats struct {
+	Attempts    int     `json:"attempts"`
+	Eclipsed    int     `json:"eclipsed"`
+	EclipseRate float64 `json:"eclipse_rate"`
+}
+
+// ParentStats counts the blocks producers built off the canonical head or on a malicious parent
+type ParentStats struct {
+	Blocks        int     `json:"blocks"`
+	Stale         int     `json:"stale"`
+	Malicious     int     `json:"malicious"`
+	StaleRate     float64 `json:"stale_rate"`
+	MaliciousRate float64 `json:"malicious_rate"`
+}
+
+// CollectEclipse summarises the eclipse attempts on rotating producers
+func (mc *MetricsCollector) CollectEclipse(adversary *eclipse.Adversary) {
+	response := &EclipseResponse{
+		PeerSlots:         adversary.Slots,
+		SlotShare:         adversary.Share,
+		Attempts:          adversary.Attempts,
+		Eclipsed:          adversary.Eclipsed,
+		ByHonestOperators: make(map[int]EclipseOutcomeStats),
+		EclipsedParents:   newParentStats(adversary.EclipsedParents),
+		ProtectedParents:  newParentStats(adversary.HonestParents),
+	}
+	if adversary.Attempts > 0 {
+		response.EclipseRate = float64(adversary.Eclipsed) / float64(adversary.Attempts)
+	}
+	for operators, outcome := range adversary.ByOperators {
+		stats := EclipseOutcomeStats{Attempts: outcome.Attempts, Eclipsed: outcome.Eclipsed}
+		if outcome.Attempts > 0 {
+			stats.EclipseRate = float64(outcome.Eclipsed) / float64(outcome.Attempts)
+		}
+		response.ByHonestOperators[operators] = stats
+	}
+	mc.Eclipse = response
+}
+
+func newParentStats(parents eclipse.Parents) ParentStats {
+	stats := ParentStats{Blocks: parents.Blocks, Stale: parents.Stale, Malicious: parents.Malicious}
+	if parents.Blocks > 0 {
+		stats.StaleRate = float64(parents.Stale) / float64(parents.Blocks)
+		stats.MaliciousRate = float64(parents.Malicious) / float64(parents.Blocks)
+	}
+	return stats
+}
+
+func (mc *MetricsCollector) writeEclipseMetrics(w io.Writer) {
+	if mc.Eclipse == nil {
+		return
+	}
+	e := mc.Eclipse
+	fmt.Fprintf(w, "Eclipse Attack Metrics:\n")
+	fmt.Fprintf(w, "  Adversary Share of Peer Slots: %.2f%% of %d regular slots\n", e.SlotShare*100, e.PeerSlots)
+	fmt.Fprintf(w, "  Eclipsed Producers: %d of %d attempts (%.2f%%)\n", e.Eclipsed, e.Attempts, e.EclipseRate*100)
+	counts := make([]int, 0, len(e.ByHonestOperators))
+	for count := range e.ByHonestOperators {
+		counts = append(counts, count)
+	}
+	sort.Ints(counts)
+	for _, count := range counts {
+		stats := e.ByHonestOperators[count]
+		fmt.Fprintf(w, "    With %d Honest Operators: %d of %d eclipsed (%.2f%%)\n", count, stats.Eclipsed, stats.Attempts, stats.EclipseRate*100)
+	}
+	for _, parents := range []struct {
+		name  string
+		stats ParentStats
+	}{{"Eclipsed Producers", e.EclipsedParents}, {"Protected Producers", e.ProtectedParents}} {
+		fmt.Fprintf(w, "  %s: %d blocks, %.2f%% on a stale parent, %.2f%% on a malicious parent\n",
+			parents.name, parents.stats.Blocks, parents.stats.StaleRate*100, parents.stats.MaliciousRate*100)
+	}
+	fmt.Fprintf(w, "\n")
+}
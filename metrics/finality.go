@@ -0,0 +1,103 @@
+// metrics/finality.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+	"sharding/finality"
+	"sharding/shard"
+)
+
+// FinalityStats covers the canonical chain of a shard. Blocks produced less than the finality
+// timeout before the end of the run are left out of the stall counts.
+type FinalityStats struct {
+	Rule                   string       `json:"rule"`
+	CanonicalBlocks        int          `json:"canonical_blocks"`
+	Finalized              int          `json:"finalized"`
+	TimeToFinality         LatencyStats `json:"time_to_finality"`
+	StallThreshold         float64      `json:"stall_threshold"`
+	Eligible               int          `json:"stall_eligible_blocks"`
+	Stalled                int          `json:"stalled"`
+	HighMaliciousBlocks    int          `json:"high_malicious_blocks"`
+	HighMaliciousStalled   int          `json:"high_malicious_stalled"`
+	StallRate              float64      `json:"stall_rate"`
+	HighMaliciousStallRate float64      `json:"high_malicious_stall_rate"`
+	OtherStallRate         float64      `json:"other_stall_rate"`
+	MaliciousFinalized     int          `json:"malicious_finalized"`
+	Violations             int          `json:"finalized_off_canonical_chain"`
+}
+
+// CollectFinality measures time-to-finality on the canonical chains and how often finality stalls,
+// separating blocks produced while the malicious share of the shard exceeded FinalityStallThreshold
+func (mc *MetricsCollector) CollectFinality(shards map[int]*shard.Shard, gadgets map[int]*finality.Gadget, cfg *config.Config) {
+	mc.Finality = make(map[int]FinalityStats)
+	threshold, timeout := finality.StallThreshold(cfg), finality.Timeout(cfg)
+	for shardID, gadget := range gadgets {
+		s := shards[shardID]
+		stats := FinalityStats{Rule: cfg.Finality.String(), StallThreshold: threshold}
+		latencies := make([]float64, 0)
+		highMaliciousEligible := 0
+
+		canonical := make(map[int]bool)
+		for _, blk := range s.CanonicalChain() {
+			canonical[blk.Hash] = true
+			stats.CanonicalBlocks++
+			finalizedAt, final := gadget.FinalizedAt[blk.Hash]
+			if final {
+				stats.Finalized++
+				latencies = append(latencies, (finalizedAt-float64(blk.Timestamp))*1000.0)
+				if blk.IsMalicious {
+					stats.MaliciousFinalized++
+				}
+			}
+
+			if blk.Timestamp+timeout > cfg.SimulationTime {
+				continue
+			}
+			stalled := !final || finalizedAt-float64(blk.Timestamp) > float64(timeout)
+			highMalicious := gadget.MaliciousShare[blk.Hash] > threshold
+			stats.Eligible++
+			if highMalicious {
+				highMaliciousEligible++
+			}
+			if stalled {
+				stats.Stalled++
+				if highMalicious {
+					stats.HighMaliciousStalled++
+				}
+			}
+		}
+		for _, blk := range s.Blocks {
+			if gadget.MaliciousShare[blk.Hash] > threshold {
+				stats.HighMaliciousBlocks++
+			}
+			if gadget.IsFinal(blk.Hash) && !canonical[blk.Hash] {
+				stats.Violations++
+			}
+		}
+
+		stats.TimeToFinality = NewLatencyStats(latencies)
+		stats.StallRate = CalculatePercentage(stats.Stalled, stats.Eligible) / 100
+		stats.HighMaliciousStallRate = CalculatePercentage(stats.HighMaliciousStalled, highMaliciousEligible) / 100
+		stats.OtherStallRate = CalculatePercentage(stats.Stalled-stats.HighMaliciousStalled, stats.Eligible-highMaliciousEligible) / 100
+		mc.Finality[shardID] = stats
+	}
+}
+
+func (mc *MetricsCollector) writeFinalityMetrics(w io.Writer) {
+	if len(mc.Finality) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Finality Metrics:\n")
+	for shardID, stats := range mc.Finality {
+		fmt.Fprintf(w, "  Shard %d (%s): %d of %d canonical blocks final, %d malicious blocks final, %d final blocks off the canonical chain\n",
+			shardID, stats.Rule, stats.Finalized, stats.CanonicalBlocks, stats.MaliciousFinalized, stats.Violations)
+		fmt.Fprintf(w, "    Time to Finality: %s\n", stats.TimeToFinality)
+		fmt.Fprintf(w, "    Stalls: %d of %d blocks (%.2f%%)\n", stats.Stalled, stats.Eligible, stats.StallRate*100)
+		fmt.Fprintf(w, "    Stall Rate with Malicious Share above %.2f: %.2f%% (%d stalled, %d produced), otherwise %.2f%%\n",
+			stats.StallThreshold, stats.HighMaliciousStallRate*100, stats.HighMaliciousStalled, stats.HighMaliciousBlocks, stats.OtherStallRate*100)
+	}
+	fmt.Fprintf(w, "\n")
+}
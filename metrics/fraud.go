@@ -0,0 +1,88 @@
+// metrics/fraud.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+)
+
+// FraudRecord follows a malicious block from production to its fraud proof, if any
+type FraudRecord struct {
+	ShardID        int
+	BlockHash      int
+	ProducedAt     int64
+	Detected       bool
+	DetectionDelay float64
+	Reverted       bool
+	BuiltOnTop     int
+}
+
+type FraudProofStats struct {
+	MaliciousBlocks  int          `json:"malicious_blocks"`
+	Detected         int          `json:"detected"`
+	Reverted         int          `json:"reverted"`
+	DetectedTooLate  int          `json:"detected_after_challenge_period"`
+	Undetected       int          `json:"undetected"`
+	TimeToDetection  LatencyStats `json:"time_to_detection"`
+	BuiltOnTop       int          `json:"blocks_built_on_malicious"`
+	MaxBuiltOnTop    int          `json:"max_blocks_built_on_one_malicious"`
+	RevertedBlocks   int          `json:"reverted_blocks"`
+	MeanBuiltOnTop   float64      `json:"mean_blocks_built_on_malicious"`
+	DetectionRatio   float64      `json:"detection_ratio"`
+	RevertedFraction float64      `json:"reverted_ratio"`
+}
+
+// CollectFraudProofs records the outcome of every malicious block produced while fraud proofs are enabled
+func (mc *MetricsCollector) CollectFraudProofs(records map[int]*FraudRecord) {
+	for _, record := range records {
+		mc.FraudRecords[record.ShardID] = append(mc.FraudRecords[record.ShardID], *record)
+	}
+}
+
+func (mc *MetricsCollector) fraudProofStats(shardID int) FraudProofStats {
+	stats := FraudProofStats{}
+	detectionDelays := make([]float64, 0)
+	for _, r := range mc.FraudRecords[shardID] {
+		stats.MaliciousBlocks++
+		stats.BuiltOnTop += r.BuiltOnTop
+		stats.MaxBuiltOnTop = max(stats.MaxBuiltOnTop, r.BuiltOnTop)
+		switch {
+		case r.Reverted:
+			stats.Detected++
+			stats.Reverted++
+			stats.RevertedBlocks += r.BuiltOnTop + 1
+			detectionDelays = append(detectionDelays, r.DetectionDelay)
+		case r.Detected:
+			stats.Detected++
+			stats.DetectedTooLate++
+			detectionDelays = append(detectionDelays, r.DetectionDelay)
+		default:
+			stats.Undetected++
+		}
+	}
+	stats.TimeToDetection = NewLatencyStats(detectionDelays)
+	if stats.MaliciousBlocks > 0 {
+		stats.MeanBuiltOnTop = float64(stats.BuiltOnTop) / float64(stats.MaliciousBlocks)
+		stats.DetectionRatio = float64(stats.Detected) / float64(stats.MaliciousBlocks)
+		stats.RevertedFraction = float64(stats.Reverted) / float64(stats.MaliciousBlocks)
+	}
+	return stats
+}
+
+func (mc *MetricsCollector) writeFraudProofMetrics(w io.Writer) {
+	if len(mc.FraudRecords) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Fraud Proof Metrics:\n")
+	for shardID := range mc.FraudRecords {
+		stats := mc.fraudProofStats(shardID)
+		fmt.Fprintf(w, "  Shard %d: %d malicious blocks, %d detected, %d reverted, %d detected after the challenge period, %d undetected\n",
+			shardID, stats.MaliciousBlocks, stats.Detected, stats.Reverted, stats.DetectedTooLate, stats.Undetected)
+		fmt.Fprintf(w, "    Time to Detection: %s\n", stats.TimeToDetection)
+		fmt.Fprintf(w, "    Blocks Built on Malicious Blocks: %d total, %.2f per malicious block, %d at most\n",
+			stats.BuiltOnTop, stats.MeanBuiltOnTop, stats.MaxBuiltOnTop)
+		fmt.Fprintf(w, "    Reverted Blocks: %d\n", stats.RevertedBlocks)
+	}
+	fmt.Fprintf(w, "\n")
+}
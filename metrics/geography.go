@@ -0,0 +1,121 @@
+// metrics/geography.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/geo"
+	"sharding/network"
+	"sharding/node"
+	"sharding/shard"
+	"sort"
+)
+
+// GeographyResponse breaks placement and delays down by region. Shard members are counted at the
+// end of the simulation.
+type GeographyResponse struct {
+	Regions []RegionStats `json:"regions"`
+	// ShardRegions[shard][region] counts the shard members, operators included, in each region
+	ShardRegions      map[int][]int `json:"shard_regions"`
+	CrossRegionLinks  float64       `json:"cross_region_links"`
+	MeanLinkLatencyMs float64       `json:"mean_link_latency_ms"`
+}
+
+type RegionStats struct {
+	Name          string       `json:"name"`
+	Nodes         int          `json:"nodes"`
+	Operators     int          `json:"operators"`
+	DownloadDelay LatencyStats `json:"block_download_delay"`
+	ReceiveDelay  LatencyStats `json:"block_receive_delay"`
+}
+
+// CollectGeography reports where nodes are and how long they wait for blocks in every region.
+// Receive delays and link statistics need a peer graph, graph is nil without one.
+func (mc *MetricsCollector) CollectGeography(geography *geo.Map, shards map[int]*shard.Shard, operators map[int]*node.Node, downloadDelays map[int][]int64, gossips map[int][]*network.Gossip, graph *network.Graph) {
+	response := &GeographyResponse{
+		Regions:      make([]RegionStats, len(geography.Regions)),
+		ShardRegions: make(map[int][]int),
+	}
+	for i, name := range geography.Regions {
+		response.Regions[i].Name = name
+	}
+	for id, region := range geography.NodeRegion {
+		if _, isOperator := operators[id]; isOperator {
+			response.Regions[region].Operators++
+		} else {
+			response.Regions[region].Nodes++
+		}
+	}
+	for id, s := range shards {
+		counts := make([]int, len(geography.Regions))
+		for nodeID := range s.Nodes {
+			counts[geography.NodeRegion[nodeID]]++
+		}
+		response.ShardRegions[id] = counts
+	}
+
+	receiveDelays := make(map[int][]float64)
+	for _, shardGossips := range gossips {
+		for _, g := range shardGossips {
+			for id := range g.Audience {
+				if t, ok := g.ReceivedAt[id]; ok {
+					region := geography.NodeRegion[id]
+					receiveDelays[region] = append(receiveDelays[region], (t-g.Start)*1000.0)
+				}
+			}
+		}
+	}
+	for region := range response.Regions {
+		delays := make([]float64, len(downloadDelays[region]))
+		for i, d := range downloadDelays[region] {
+			delays[i] = float64(d)
+		}
+		response.Regions[region].DownloadDelay = NewLatencyStats(delays)
+		response.Regions[region].ReceiveDelay = NewLatencyStats(receiveDelays[region])
+	}
+
+	if graph != nil && len(graph.Links) > 0 {
+		crossRegion, totalLatency := 0, 0.0
+		for key, link := range graph.Links {
+			if geography.NodeRegion[key[0]] != geography.NodeRegion[key[1]] {
+				crossRegion++
+			}
+			totalLatency += link.Mean
+		}
+		response.CrossRegionLinks = float64(crossRegion) / float64(len(graph.Links))
+		response.MeanLinkLatencyMs = totalLatency / float64(len(graph.Links))
+	}
+	mc.Geography = response
+}
+
+func (mc *MetricsCollector) writeGeographyMetrics(w io.Writer) {
+	if mc.Geography == nil {
+		return
+	}
+	g := mc.Geography
+	fmt.Fprintf(w, "Geography Metrics:\n")
+	for _, r := range g.Regions {
+		fmt.Fprintf(w, "  Region %s: %d nodes, %d operators\n", r.Name, r.Nodes, r.Operators)
+		fmt.Fprintf(w, "    Block Download Delay: %s\n", r.DownloadDelay)
+		if r.ReceiveDelay.Count > 0 {
+			fmt.Fprintf(w, "    Block Receive Delay: %s\n", r.ReceiveDelay)
+		}
+	}
+	shardIDs := make([]int, 0, len(g.ShardRegions))
+	for id := range g.ShardRegions {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		fmt.Fprintf(w, "  Members of Shard %d by Region:", id)
+		for region, count := range g.ShardRegions[id] {
+			fmt.Fprintf(w, " %s %d", g.Regions[region].Name, count)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	if g.MeanLinkLatencyMs > 0 {
+		fmt.Fprintf(w, "  Peer Links: %.2f%% across regions, %.2fms mean latency\n", g.CrossRegionLinks*100, g.MeanLinkLatencyMs)
+	}
+	fmt.Fprintf(w, "\n")
+}
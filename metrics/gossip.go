@@ -0,0 +1,111 @@
+// metrics/gossip.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sharding/network"
+)
+
+// GossipResponse describes the peer graph and how blocks spread over it. Receive delays are
+// counted from block production to the first copy reaching a node.
+type GossipResponse struct {
+	Topology         string       `json:"topology"`
+	Nodes            int          `json:"nodes"`
+	Links            int          `json:"links"`
+	MeanDegree       float64      `json:"mean_degree"`
+	MinDegree        int          `json:"min_degree"`
+	MaxDegree        int          `json:"max_degree"`
+	Messages         int          `json:"messages"`
+	Coverage         float64      `json:"coverage"`
+	AudienceCoverage float64      `json:"audience_coverage"`
+	ReceiveDelay     LatencyStats `json:"receive_delay"`
+	AudienceDelay    LatencyStats `json:"audience_receive_delay"`
+	MeanHops         float64      `json:"mean_hops"`
+	MaxHops          int          `json:"max_hops"`
+	SentPerNode      float64      `json:"sent_per_node"`
+	DuplicateRatio   float64      `json:"duplicate_ratio"`
+}
+
+// CollectGossip summarises the peer graph and every block gossiped over it
+func (mc *MetricsCollector) CollectGossip(graph *network.Graph, gossips map[int][]*network.Gossip) {
+	response := &GossipResponse{
+		Topology: graph.Topology.String(),
+		Nodes:    len(graph.Peers),
+		Links:    len(graph.Links),
+	}
+	if len(graph.Peers) > 0 {
+		response.MinDegree = math.MaxInt
+		for _, peers := range graph.Peers {
+			response.MinDegree = min(response.MinDegree, len(peers))
+			response.MaxDegree = max(response.MaxDegree, len(peers))
+		}
+		response.MeanDegree = 2 * float64(len(graph.Links)) / float64(len(graph.Peers))
+	}
+
+	receiveDelays := make([]float64, 0)
+	audienceDelays := make([]float64, 0)
+	coverage, audienceCoverage := 0.0, 0.0
+	totalHops, received, sent, duplicates := 0, 0, 0, 0
+	for _, shardGossips := range gossips {
+		for _, g := range shardGossips {
+			response.Messages++
+			reachedAudience := 0
+			for id, t := range g.ReceivedAt {
+				if id == g.Origin {
+					continue
+				}
+				delay := (t - g.Start) * 1000.0
+				receiveDelays = append(receiveDelays, delay)
+				if g.Audience[id] {
+					audienceDelays = append(audienceDelays, delay)
+					reachedAudience++
+				}
+				totalHops += g.Hops[id]
+				response.MaxHops = max(response.MaxHops, g.Hops[id])
+				received++
+			}
+			if len(graph.Peers) > 1 {
+				coverage += float64(len(g.ReceivedAt)-1) / float64(len(graph.Peers)-1)
+			}
+			if len(g.Audience) > 0 {
+				audienceCoverage += float64(reachedAudience) / float64(len(g.Audience))
+			}
+			sent += g.Sent
+			duplicates += g.Duplicates
+		}
+	}
+	if response.Messages > 0 {
+		response.Coverage = coverage / float64(response.Messages)
+		response.AudienceCoverage = audienceCoverage / float64(response.Messages)
+		response.SentPerNode = float64(sent) / float64(response.Messages*max(response.Nodes, 1))
+	}
+	if received > 0 {
+		response.MeanHops = float64(totalHops) / float64(received)
+	}
+	if sent > 0 {
+		response.DuplicateRatio = float64(duplicates) / float64(sent)
+	}
+	response.ReceiveDelay = NewLatencyStats(receiveDelays)
+	response.AudienceDelay = NewLatencyStats(audienceDelays)
+	mc.Gossip = response
+}
+
+func (mc *MetricsCollector) writeGossipMetrics(w io.Writer) {
+	if mc.Gossip == nil {
+		return
+	}
+	g := mc.Gossip
+	fmt.Fprintf(w, "Gossip Metrics:\n")
+	fmt.Fprintf(w, "  Peer Graph: %s, %d nodes, %d links, degree %.2f mean, %d min, %d max\n",
+		g.Topology, g.Nodes, g.Links, g.MeanDegree, g.MinDegree, g.MaxDegree)
+	fmt.Fprintf(w, "  Gossiped Blocks: %d\n", g.Messages)
+	fmt.Fprintf(w, "  Coverage: %.2f%% of the network, %.2f%% of the shard members\n", g.Coverage*100, g.AudienceCoverage*100)
+	fmt.Fprintf(w, "  Receive Delay: %s\n", g.ReceiveDelay)
+	fmt.Fprintf(w, "  Shard Member Receive Delay: %s\n", g.AudienceDelay)
+	fmt.Fprintf(w, "  Hops: %.2f mean, %d max\n", g.MeanHops, g.MaxHops)
+	fmt.Fprintf(w, "  Copies Sent per Node and Block: %.2f, %.2f%% duplicates\n", g.SentPerNode, g.DuplicateRatio*100)
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,150 @@
+// metrics/grinding.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/attack"
+)
+
+// GrindingSample is the make-up of the grinding target shard after one lottery round. Attempts and
+// Cost are the adversary's effort in that round, 0 while the attack is inactive.
+type GrindingSample struct {
+	Time               int64   `json:"time"`
+	Active             bool    `json:"attack_active"`
+	TargetMembers      int     `json:"target_members"`
+	TargetMalicious    int     `json:"target_malicious"`
+	TargetShare        float64 `json:"target_malicious_share"`
+	NetworkShare       float64 `json:"network_malicious_share"`
+	OverRepresentation float64 `json:"over_representation"`
+	Attempts           int     `json:"attempts"`
+	Cost               float64 `json:"cost"`
+}
+
+// GrindingResponse reports the effort of the grinding adversary and how far it packed malicious
+// nodes into the target shard. Shares before and at the end of the attack are -1 when the attack
+// did not start or end within the simulation.
+type GrindingResponse struct {
+	TargetShard          int              `json:"target_shard"`
+	Rounds               int              `json:"rounds"`
+	Attempts             int              `json:"attempts"`
+	MeanAttemptsPerRound float64          `json:"mean_attempts_per_round"`
+	ComputeSpent         float64          `json:"compute_spent"`
+	Budget               float64          `json:"budget_per_round"`
+	TargetWins           int              `json:"target_wins"`
+	DiscardedWins        int              `json:"discarded_wins"`
+	ShareBefore          float64          `json:"share_before"`
+	MeanShareDuring      float64          `json:"mean_share_during"`
+	PeakShare            float64          `json:"peak_share"`
+	PeakTime             int64            `json:"peak_time"`
+	ShareAtEnd           float64          `json:"share_at_end"`
+	FinalShare           float64          `json:"final_share"`
+	NetworkShare         float64          `json:"network_share"`
+	MeanOverRepresented  float64          `json:"mean_over_representation"`
+	PeakOverRepresented  float64          `json:"peak_over_representation"`
+	Samples              []GrindingSample `json:"samples"`
+}
+
+// CollectGrinding summarises the grinding adversary's effort and the malicious share of its target
+// shard over time
+func (mc *MetricsCollector) CollectGrinding(grinder *attack.Grinder, samples []GrindingSample) {
+	response := &GrindingResponse{
+		TargetShard:  grinder.TargetShard,
+		Rounds:       len(grinder.Rounds),
+		Attempts:     grinder.Attempts,
+		ComputeSpent: grinder.Spent,
+		Budget:       grinder.Budget,
+		ShareBefore:  -1,
+		ShareAtEnd:   -1,
+		Samples:      make([]GrindingSample, len(samples)),
+	}
+	for _, round := range grinder.Rounds {
+		response.TargetWins += round.TargetWins
+		response.DiscardedWins += round.DiscardedWins
+	}
+	if len(grinder.Rounds) > 0 {
+		response.MeanAttemptsPerRound = float64(grinder.Attempts) / float64(len(grinder.Rounds))
+	}
+
+	active, networkTotal, shareTotal, ratioTotal := 0, 0.0, 0.0, 0.0
+	for i, sample := range samples {
+		if sample.NetworkShare > 0 {
+			sample.OverRepresentation = sample.TargetShare / sample.NetworkShare
+		}
+		response.Samples[i] = sample
+		networkTotal += sample.NetworkShare
+		if !sample.Active {
+			if active == 0 {
+				response.ShareBefore = sample.TargetShare
+			}
+			continue
+		}
+		active++
+		shareTotal += sample.TargetShare
+		ratioTotal += sample.OverRepresentation
+		response.ShareAtEnd = sample.TargetShare
+		if sample.TargetShare > response.PeakShare {
+			response.PeakShare = sample.TargetShare
+			response.PeakTime = sample.Time
+		}
+		response.PeakOverRepresented = max(response.PeakOverRepresented, sample.OverRepresentation)
+	}
+	if active > 0 {
+		response.MeanShareDuring = shareTotal / float64(active)
+		response.MeanOverRepresented = ratioTotal / float64(active)
+	}
+	if len(samples) > 0 {
+		response.FinalShare = samples[len(samples)-1].TargetShare
+		response.NetworkShare = networkTotal / float64(len(samples))
+	}
+	// The attack still ran when the simulation ended
+	if len(samples) > 0 && samples[len(samples)-1].Active {
+		response.ShareAtEnd = -1
+	}
+	mc.Grinding = response
+}
+
+func (mc *MetricsCollector) writeGrindingMetrics(w io.Writer) {
+	if mc.Grinding == nil {
+		return
+	}
+	g := mc.Grinding
+	fmt.Fprintf(w, "Grinding Attack Metrics:\n")
+	fmt.Fprintf(w, "  Target Shard: %d\n", g.TargetShard)
+	fmt.Fprintf(w, "  Grinding Rounds: %d\n", g.Rounds)
+	fmt.Fprintf(w, "  Lottery Attempts: %d (mean %.2f per round)\n", g.Attempts, g.MeanAttemptsPerRound)
+	if g.Budget > 0 {
+		fmt.Fprintf(w, "  Compute Spent: %.2f units (budget %.2f per round)\n", g.ComputeSpent, g.Budget)
+	} else {
+		fmt.Fprintf(w, "  Compute Spent: %.2f units (no budget)\n", g.ComputeSpent)
+	}
+	fmt.Fprintf(w, "  Winning Tickets: %d kept for the target shard, %d discarded\n", g.TargetWins, g.DiscardedWins)
+	fmt.Fprintf(w, "  Network Malicious Share: %.2f%%\n", g.NetworkShare*100)
+	fmt.Fprintf(w, "  Target Shard Malicious Share:\n")
+	if g.ShareBefore >= 0 {
+		fmt.Fprintf(w, "    Before Attack: %.2f%%\n", g.ShareBefore*100)
+	}
+	fmt.Fprintf(w, "    During Attack: mean %.2f%%, peak %.2f%% at time %d\n", g.MeanShareDuring*100, g.PeakShare*100, g.PeakTime)
+	if g.ShareAtEnd >= 0 {
+		fmt.Fprintf(w, "    End of Attack: %.2f%%\n", g.ShareAtEnd*100)
+	}
+	fmt.Fprintf(w, "    End of Simulation: %.2f%%\n", g.FinalShare*100)
+	fmt.Fprintf(w, "  Over-Representation: mean %.2fx, peak %.2fx\n", g.MeanOverRepresented, g.PeakOverRepresented)
+
+	// Keep the time series to about 20 rows, the response carries every round
+	step := max(1, len(g.Samples)/20)
+	fmt.Fprintf(w, "  Target Shard Over Time:\n")
+	for i, sample := range g.Samples {
+		if i%step != 0 && i != len(g.Samples)-1 {
+			continue
+		}
+		marker := ""
+		if sample.Active {
+			marker = " [grinding]"
+		}
+		fmt.Fprintf(w, "    Time %d: %d/%d malicious (%.2f%%, %.2fx network)%s\n",
+			sample.Time, sample.TargetMalicious, sample.TargetMembers, sample.TargetShare*100, sample.OverRepresentation, marker)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,122 @@
+// metrics/headerlag.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HeaderLagSample is how far the header chains of the nodes trailed a shard's tip at one time
+type HeaderLagSample struct {
+	Time int64   `json:"time"`
+	Mean float64 `json:"mean_lag"`
+	Max  int     `json:"max_lag"`
+}
+
+// NodeHeaderLag accumulates the header lag samples of one node over every shard
+type NodeHeaderLag struct {
+	Samples int
+	Total   int
+	Max     int
+}
+
+// HeaderLagResponse reports how many blocks the nodes' header chains trailed the shard tips over
+// time. Producers syncing with stale headers download a range that stops short of the tip.
+type HeaderLagResponse struct {
+	Shards             map[int]ShardHeaderLag     `json:"shards"`
+	Nodes              map[int]NodeHeaderLagStats `json:"nodes"`
+	MostLagging        []int                      `json:"most_lagging_nodes"`
+	ProducerSyncs      int                        `json:"producer_syncs"`
+	StaleProducerSyncs int                        `json:"stale_producer_syncs"`
+	ProducerMeanLag    float64                    `json:"producer_mean_lag"`
+	ProducerMaxLag     int                        `json:"producer_max_lag"`
+}
+
+type ShardHeaderLag struct {
+	MeanLag float64           `json:"mean_lag"`
+	MaxLag  int               `json:"max_lag"`
+	Samples []HeaderLagSample `json:"samples"`
+}
+
+type NodeHeaderLagStats struct {
+	MeanLag float64 `json:"mean_lag"`
+	MaxLag  int     `json:"max_lag"`
+}
+
+// CollectHeaderLag summarises the sampled header lag of every shard and node, and the lag
+// producers had when they synced before producing
+func (mc *MetricsCollector) CollectHeaderLag(samples map[int][]HeaderLagSample, nodes map[int]*NodeHeaderLag, producerLags []int) {
+	response := &HeaderLagResponse{
+		Shards:        make(map[int]ShardHeaderLag),
+		Nodes:         make(map[int]NodeHeaderLagStats),
+		MostLagging:   make([]int, 0),
+		ProducerSyncs: len(producerLags),
+	}
+	for shardID, shardSamples := range samples {
+		stats := ShardHeaderLag{Samples: shardSamples}
+		for _, sample := range shardSamples {
+			stats.MeanLag += sample.Mean
+			stats.MaxLag = max(stats.MaxLag, sample.Max)
+		}
+		if len(shardSamples) > 0 {
+			stats.MeanLag /= float64(len(shardSamples))
+		}
+		response.Shards[shardID] = stats
+	}
+
+	ids := make([]int, 0, len(nodes))
+	for id, lag := range nodes {
+		if lag.Samples == 0 {
+			continue
+		}
+		response.Nodes[id] = NodeHeaderLagStats{MeanLag: float64(lag.Total) / float64(lag.Samples), MaxLag: lag.Max}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := response.Nodes[ids[i]], response.Nodes[ids[j]]
+		if a.MeanLag != b.MeanLag {
+			return a.MeanLag > b.MeanLag
+		}
+		return ids[i] < ids[j]
+	})
+	response.MostLagging = ids[:min(5, len(ids))]
+
+	total := 0
+	for _, lag := range producerLags {
+		total += lag
+		response.ProducerMaxLag = max(response.ProducerMaxLag, lag)
+		if lag > 0 {
+			response.StaleProducerSyncs++
+		}
+	}
+	if len(producerLags) > 0 {
+		response.ProducerMeanLag = float64(total) / float64(len(producerLags))
+	}
+	mc.HeaderLag = response
+}
+
+func (mc *MetricsCollector) writeHeaderLagMetrics(w io.Writer) {
+	if mc.HeaderLag == nil {
+		return
+	}
+	h := mc.HeaderLag
+	fmt.Fprintf(w, "Header Lag Metrics:\n")
+	shardIDs := make([]int, 0, len(h.Shards))
+	for id := range h.Shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		s := h.Shards[id]
+		fmt.Fprintf(w, "  Shard %d Header Lag: mean %.2f blocks, max %d blocks over %d samples\n", id, s.MeanLag, s.MaxLag, len(s.Samples))
+	}
+	fmt.Fprintf(w, "  Producers Syncing with Stale Headers: %d of %d (mean lag %.2f blocks, max %d blocks)\n",
+		h.StaleProducerSyncs, h.ProducerSyncs, h.ProducerMeanLag, h.ProducerMaxLag)
+	fmt.Fprintf(w, "  Most Lagging Nodes:\n")
+	for _, id := range h.MostLagging {
+		fmt.Fprintf(w, "    Node %d: mean %.2f blocks, max %d blocks\n", id, h.Nodes[id].MeanLag, h.Nodes[id].MaxLag)
+	}
+	fmt.Fprintf(w, "\n")
+}
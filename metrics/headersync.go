@@ -0,0 +1,77 @@
+// metrics/headersync.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/headersync"
+	"sort"
+)
+
+// HeaderSyncResponse reports the gaps nodes found in their header chains and the requests spent
+// filling them. FillTime runs from detection until the chain links past the gap.
+type HeaderSyncResponse struct {
+	Gaps          int          `json:"gaps"`
+	Filled        int          `json:"filled"`
+	Unfilled      int          `json:"unfilled"`
+	GapsPerShard  map[int]int  `json:"gaps_per_shard"`
+	MeanGapSize   float64      `json:"mean_gap_size"`
+	MaxGapSize    int          `json:"max_gap_size"`
+	FillTime      LatencyStats `json:"fill_time"`
+	Requests      int          `json:"requests"`
+	Responses     int          `json:"responses"`
+	TimedOut      int          `json:"timed_out_requests"`
+	HeadersServed int          `json:"headers_served"`
+}
+
+// CollectHeaderSync summarises the header gaps detected and how fast they were filled
+func (mc *MetricsCollector) CollectHeaderSync(tracker *headersync.Tracker) {
+	response := &HeaderSyncResponse{
+		Gaps:          len(tracker.Gaps),
+		GapsPerShard:  make(map[int]int),
+		Requests:      tracker.Requests,
+		Responses:     tracker.Responses,
+		TimedOut:      tracker.TimedOut,
+		HeadersServed: tracker.HeadersServed,
+	}
+	fillTimes := make([]float64, 0)
+	totalSize := 0
+	for _, gap := range tracker.Gaps {
+		response.GapsPerShard[gap.ShardID]++
+		totalSize += gap.Size()
+		response.MaxGapSize = max(response.MaxGapSize, gap.Size())
+		if gap.FilledAt >= 0 {
+			response.Filled++
+			fillTimes = append(fillTimes, (gap.FilledAt-gap.DetectedAt)*1000.0)
+		} else {
+			response.Unfilled++
+		}
+	}
+	if len(tracker.Gaps) > 0 {
+		response.MeanGapSize = float64(totalSize) / float64(len(tracker.Gaps))
+	}
+	response.FillTime = NewLatencyStats(fillTimes)
+	mc.HeaderSync = response
+}
+
+func (mc *MetricsCollector) writeHeaderSyncMetrics(w io.Writer) {
+	if mc.HeaderSync == nil {
+		return
+	}
+	h := mc.HeaderSync
+	fmt.Fprintf(w, "Header Sync Metrics:\n")
+	fmt.Fprintf(w, "  Header Gaps: %d detected, %d filled, %d still open\n", h.Gaps, h.Filled, h.Unfilled)
+	shardIDs := make([]int, 0, len(h.GapsPerShard))
+	for id := range h.GapsPerShard {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		fmt.Fprintf(w, "  Shard %d Header Gaps: %d\n", id, h.GapsPerShard[id])
+	}
+	fmt.Fprintf(w, "  Gap Size: mean %.2f headers, max %d headers\n", h.MeanGapSize, h.MaxGapSize)
+	fmt.Fprintf(w, "  Gap Fill Time: %s\n", h.FillTime)
+	fmt.Fprintf(w, "  Header Requests: %d sent, %d answered, %d timed out, %d headers served\n", h.Requests, h.Responses, h.TimedOut, h.HeadersServed)
+	fmt.Fprintf(w, "\n")
+}
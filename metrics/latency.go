@@ -0,0 +1,58 @@
+// metrics/latency.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+)
+
+// LatencyResponse reports the delay distribution of each kind of traffic and the delays it produced
+type LatencyResponse struct {
+	Block    TrafficLatency `json:"block_broadcast"`
+	Header   TrafficLatency `json:"header_broadcast"`
+	Download TrafficLatency `json:"block_download"`
+	// TraceSamples is the number of RTT samples the empirical model draws from
+	TraceSamples int `json:"trace_samples"`
+}
+
+type TrafficLatency struct {
+	Model string       `json:"model"`
+	Delay LatencyStats `json:"delay"`
+}
+
+// CollectLatency summarises the block, header and download delays collected so far under their delay models
+func (mc *MetricsCollector) CollectLatency(cfg *config.Config) {
+	network := mc.CurrentMetrics.NetworkMetrics
+	flatten := func(delays map[int][]float64) []float64 {
+		all := make([]float64, 0)
+		for _, d := range delays {
+			all = append(all, d...)
+		}
+		return all
+	}
+	mc.Latency = &LatencyResponse{
+		Block:        TrafficLatency{Model: cfg.BlockDelayModel.String(), Delay: NewLatencyStats(flatten(network.BlockBroadcastDelays))},
+		Header:       TrafficLatency{Model: cfg.HeaderDelayModel.String(), Delay: NewLatencyStats(network.BlockHeaderDelays)},
+		Download:     TrafficLatency{Model: cfg.DownloadDelayModel.String(), Delay: NewLatencyStats(flatten(network.BlockDownloadDelays))},
+		TraceSamples: len(cfg.DelayTrace),
+	}
+}
+
+func (mc *MetricsCollector) writeLatencyMetrics(w io.Writer) {
+	if mc.Latency == nil {
+		return
+	}
+	fmt.Fprintf(w, "Latency Distribution Metrics:\n")
+	for _, traffic := range []struct {
+		name  string
+		stats TrafficLatency
+	}{{"Block Broadcast", mc.Latency.Block}, {"Header Broadcast", mc.Latency.Header}, {"Block Download", mc.Latency.Download}} {
+		fmt.Fprintf(w, "  %s (%s): %s\n", traffic.name, traffic.stats.Model, traffic.stats.Delay)
+	}
+	if mc.Latency.TraceSamples > 0 {
+		fmt.Fprintf(w, "  RTT Trace Samples: %d\n", mc.Latency.TraceSamples)
+	}
+	fmt.Fprintf(w, "\n")
+}
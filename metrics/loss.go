@@ -0,0 +1,69 @@
+// metrics/loss.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/loss"
+)
+
+// LossResponse reports how many messages got through lossy links and how much later
+type LossResponse struct {
+	Gossip            TrafficStats `json:"gossip"`
+	Downloads         TrafficStats `json:"downloads"`
+	DownloadFallbacks int          `json:"download_fallbacks"`
+}
+
+// TrafficStats describes one kind of message. ExtraLatency only covers the delivered messages
+// that needed retransmissions, MeanExtraLatency spreads it over every delivered message.
+type TrafficStats struct {
+	Messages         int          `json:"messages"`
+	Delivered        int          `json:"delivered"`
+	DeliveryRatio    float64      `json:"delivery_ratio"`
+	Retransmissions  int          `json:"retransmissions"`
+	ExtraLatency     LatencyStats `json:"extra_latency"`
+	MeanExtraLatency float64      `json:"mean_extra_latency_ms"`
+}
+
+// CollectLoss summarises deliveries and retransmissions over lossy links
+func (mc *MetricsCollector) CollectLoss(model *loss.Model) {
+	stats := func(t loss.Traffic) TrafficStats {
+		s := TrafficStats{
+			Messages:        t.Messages,
+			Delivered:       t.Delivered,
+			Retransmissions: t.Retransmissions,
+			ExtraLatency:    NewLatencyStats(t.ExtraLatency),
+		}
+		if t.Messages > 0 {
+			s.DeliveryRatio = float64(t.Delivered) / float64(t.Messages)
+		}
+		if t.Delivered > 0 {
+			s.MeanExtraLatency = s.ExtraLatency.Mean * float64(s.ExtraLatency.Count) / float64(t.Delivered)
+		}
+		return s
+	}
+	mc.Loss = &LossResponse{
+		Gossip:            stats(model.Gossip),
+		Downloads:         stats(model.Downloads),
+		DownloadFallbacks: model.DownloadFallbacks,
+	}
+}
+
+func (mc *MetricsCollector) writeLossMetrics(w io.Writer) {
+	if mc.Loss == nil {
+		return
+	}
+	fmt.Fprintf(w, "Packet Loss Metrics:\n")
+	for _, traffic := range []struct {
+		name  string
+		stats TrafficStats
+	}{{"Gossip Hops", mc.Loss.Gossip}, {"Block Downloads", mc.Loss.Downloads}} {
+		s := traffic.stats
+		fmt.Fprintf(w, "  %s: %d of %d delivered (%.2f%%), %d retransmissions\n",
+			traffic.name, s.Delivered, s.Messages, s.DeliveryRatio*100, s.Retransmissions)
+		fmt.Fprintf(w, "  %s Extra Latency: %.2fms per delivered message, retransmitted ones %s\n", traffic.name, s.MeanExtraLatency, s.ExtraLatency)
+	}
+	fmt.Fprintf(w, "  Downloads Timed Out and Retried with Another Peer: %d\n", mc.Loss.DownloadFallbacks)
+	fmt.Fprintf(w, "\n")
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"sharding/config"
 	"sharding/node"
@@ -25,6 +26,16 @@ type ShardMetrics struct {
 	HonestBlocks    int
 	MaliciousBlocks int
 	BlockIndexes    []int
+	ProducerCounts  map[int]int
+	// Fork statistics over the shard's canonical chain
+	CanonicalBlocks          int
+	CanonicalHonestBlocks    int
+	CanonicalMaliciousBlocks int
+	OrphanedBlocks           int
+	UncleBlocks              int
+	ReorgDepths              map[int]int
+	// Transactions in the honest blocks of the canonical chain
+	CanonicalTransactions int
 }
 
 type TimeWindowMetrics struct {
@@ -39,23 +50,94 @@ type TimeWindowMetrics struct {
 }
 
 type MetricsCollector struct {
-	CurrentMetrics TimeWindowMetrics
-	Logs           []string
+	CurrentMetrics    TimeWindowMetrics
+	ExecutionReceipts ExecutionReceiptMetrics
+	FraudRecords      map[int][]FraudRecord
+	// Workload is nil when blocks are assumed to be full
+	Workload *WorkloadMetrics
+	// CrossShard is nil when no transaction crosses shards
+	CrossShard *CrossShardMetrics
+	// Finality is empty when shard blocks never become final
+	Finality map[int]FinalityStats
+	// Beacon is nil without a beacon chain
+	Beacon *BeaconResponse
+	// DAS is nil without data availability sampling
+	DAS *DASResponse
+	// StateSync is nil when rotated nodes skip state sync
+	StateSync *StateSyncResponse
+	// Gossip is nil when block propagation is estimated
+	Gossip *GossipResponse
+	// Geography is nil when link latencies ignore where nodes are
+	Geography *GeographyResponse
+	// Bandwidth is nil when transfers never contend for links
+	Bandwidth *BandwidthResponse
+	// Loss is nil when links never lose messages
+	Loss      *LossResponse
+	HeaderLag *HeaderLagResponse
+	// HeaderSync is nil when header chains are not validated
+	HeaderSync *HeaderSyncResponse
+	// Latency is nil when every delay model is normal
+	Latency *LatencyResponse
+	// Grinding is nil when no grinding attack is scheduled
+	Grinding      *GrindingResponse
+	Compromise    *CompromiseResponse
+	SecurityModel *SecurityModelResponse
+	// Withholding is nil when malicious producers publish their block bodies
+	Withholding *WithholdingResponse
+	// Serving is nil when no malicious peer answered a block request
+	Serving *ServingResponse
+	// Eclipse is nil when no eclipse attack is scheduled
+	Eclipse *EclipseResponse
+	// Corruption is nil when no adaptive corruption attack is scheduled
+	Corruption *CorruptionResponse
+	// Partitions is empty when no partition was scheduled
+	Partitions []PartitionStats
+	Logs       []string
 }
 
 type SimulationResponse struct {
-	TransactionSize      int                  `json:"transaction_size_bytes"`
-	TransactionsPerBlock int                  `json:"transactions_per_block"`
-	BlockSize            int                  `json:"block_size_kb"`
-	BlockProduction      map[int]ShardStats   `json:"block_production"`
-	NetworkMetrics       NetworkStatsResponse `json:"network_metrics"`
-	Performance          PerformanceStats     `json:"performance"`
+	TransactionSize      int                      `json:"transaction_size_bytes"`
+	TransactionsPerBlock int                      `json:"transactions_per_block"`
+	BlockSize            int                      `json:"block_size_kb"`
+	BlockProduction      map[int]ShardStats       `json:"block_production"`
+	NetworkMetrics       NetworkStatsResponse     `json:"network_metrics"`
+	ExecutionReceipts    ExecutionReceiptResponse `json:"execution_receipts"`
+	FraudProofs          map[int]FraudProofStats  `json:"fraud_proofs"`
+	Workload             *WorkloadResponse        `json:"workload,omitempty"`
+	CrossShard           *CrossShardResponse      `json:"cross_shard,omitempty"`
+	Finality             map[int]FinalityStats    `json:"finality,omitempty"`
+	Beacon               *BeaconResponse          `json:"beacon_chain,omitempty"`
+	DAS                  *DASResponse             `json:"data_availability,omitempty"`
+	StateSync            *StateSyncResponse       `json:"state_sync,omitempty"`
+	Gossip               *GossipResponse          `json:"gossip,omitempty"`
+	Geography            *GeographyResponse       `json:"geography,omitempty"`
+	Bandwidth            *BandwidthResponse       `json:"bandwidth,omitempty"`
+	Loss                 *LossResponse            `json:"packet_loss,omitempty"`
+	Partitions           []PartitionStats         `json:"partitions,omitempty"`
+	Latency              *LatencyResponse         `json:"latency,omitempty"`
+	HeaderLag            *HeaderLagResponse       `json:"header_lag,omitempty"`
+	HeaderSync           *HeaderSyncResponse      `json:"header_sync,omitempty"`
+	Grinding             *GrindingResponse        `json:"grinding_attack,omitempty"`
+	Compromise           *CompromiseResponse      `json:"compromise,omitempty"`
+	SecurityModel        *SecurityModelResponse   `json:"security_model,omitempty"`
+	Withholding          *WithholdingResponse     `json:"block_withholding,omitempty"`
+	Serving              *ServingResponse         `json:"byzantine_serving,omitempty"`
+	Eclipse              *EclipseResponse         `json:"eclipse_attack,omitempty"`
+	Corruption           *CorruptionResponse      `json:"adaptive_corruption,omitempty"`
+	Performance          PerformanceStats         `json:"performance"`
 }
 
 type ShardStats struct {
-	MaliciousBlocks int `json:"malicious_blocks"`
-	HonestBlocks    int `json:"honest_blocks"`
-	TotalBlocks     int `json:"total_blocks"`
+	MaliciousBlocks  int         `json:"malicious_blocks"`
+	HonestBlocks     int         `json:"honest_blocks"`
+	TotalBlocks      int         `json:"total_blocks"`
+	ProducerCounts   map[int]int `json:"producer_counts"`
+	ProducerFairness float64     `json:"producer_fairness"`
+	CanonicalBlocks  int         `json:"canonical_blocks"`
+	OrphanRate       float64     `json:"orphan_rate"`
+	UncleRate        float64     `json:"uncle_rate"`
+	ChainQuality     float64     `json:"chain_quality"`
+	ReorgDepths      map[int]int `json:"reorg_depths"`
 }
 
 type NetworkStatsResponse struct {
@@ -64,6 +146,16 @@ type NetworkStatsResponse struct {
 	BlockDownloadDelays  map[int]float64 `json:"block_download_delays_ms"`
 }
 
+// LatencyStats summarises a latency distribution in milliseconds
+type LatencyStats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean_ms"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
 type PerformanceStats struct {
 	TPS float64 `json:"transactions_per_second"`
 }
@@ -79,7 +171,13 @@ func NewMetricsCollector() *MetricsCollector {
 				AverageDownloadDelay: make(map[int]float64),
 			},
 		},
-		Logs: make([]string, 0),
+		ExecutionReceipts: ExecutionReceiptMetrics{
+			HeaderDelays: make(map[int][]float64),
+			BodyDelays:   make(map[int][]float64),
+			Receipts:     make(map[int][]ReceiptCoverage),
+		},
+		FraudRecords: make(map[int][]FraudRecord),
+		Logs:         make([]string, 0),
 	}
 }
 
@@ -137,7 +235,8 @@ func (mc *MetricsCollector) Collect(
 	// Update shard statistics
 	for shardID, s := range shards {
 		stats := &ShardMetrics{
-			BlockIndexes: make([]int, 0),
+			BlockIndexes:   make([]int, 0),
+			ProducerCounts: make(map[int]int),
 		}
 		mc.CurrentMetrics.ShardStats[shardID] = stats
 
@@ -152,6 +251,7 @@ func (mc *MetricsCollector) Collect(
 		stats.MaliciousBlocks = 0
 		for idx := range s.Blocks {
 			stats.BlockIndexes = append(stats.BlockIndexes, s.Blocks[idx].ID)
+			stats.ProducerCounts[s.Blocks[idx].ProducerID]++
 			if s.Blocks[idx].IsMalicious {
 				stats.MaliciousBlocks++
 			} else {
@@ -161,6 +261,37 @@ func (mc *MetricsCollector) Collect(
 		// Sort the block indexes
 		sort.Ints(stats.BlockIndexes)
 
+		// Blocks off the canonical chain are orphans, orphans hanging directly off it are uncles
+		canonical := make(map[int]bool)
+		for _, blk := range s.CanonicalChain() {
+			canonical[blk.Hash] = true
+			stats.CanonicalBlocks++
+			if blk.IsMalicious {
+				stats.CanonicalMaliciousBlocks++
+			} else {
+				stats.CanonicalHonestBlocks++
+				stats.CanonicalTransactions += transactionCount(blk)
+			}
+		}
+		canonical[s.Tree.Genesis.Hash] = true
+		for _, blk := range s.Blocks {
+			if canonical[blk.Hash] {
+				continue
+			}
+			stats.OrphanedBlocks++
+			if canonical[blk.PreviousHash] {
+				stats.UncleBlocks++
+			}
+		}
+
+		// Reorgs seen by the nodes, as depth -> count
+		stats.ReorgDepths = make(map[int]int)
+		for _, n := range nodes {
+			for _, depth := range n.ReorgDepths[shardID] {
+				stats.ReorgDepths[depth]++
+			}
+		}
+
 		// Update total blocks count
 		mc.CurrentMetrics.TotalBlocks += stats.HonestBlocks + stats.MaliciousBlocks
 	}
@@ -169,6 +300,42 @@ func (mc *MetricsCollector) Collect(
 	mc.Logs = append(mc.Logs, logs...)
 }
 
+// Percentile returns the p-th percentile (0-100) of values using nearest-rank
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func NewLatencyStats(values []float64) LatencyStats {
+	stats := LatencyStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	stats.Mean = sum / float64(len(sorted))
+	stats.P50 = Percentile(sorted, 50)
+	stats.P95 = Percentile(sorted, 95)
+	stats.P99 = Percentile(sorted, 99)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
+
+func (s LatencyStats) String() string {
+	return fmt.Sprintf("mean %.2fms, p50 %.2fms, p95 %.2fms, p99 %.2fms, max %.2fms (%d samples)", s.Mean, s.P50, s.P95, s.P99, s.Max, s.Count)
+}
+
 func (mc *MetricsCollector) calculateAverages() {
 	// Calculate broadcast delays per shard
 	for shardID, delays := range mc.CurrentMetrics.NetworkMetrics.BlockBroadcastDelays {
@@ -213,6 +380,29 @@ func (mc *MetricsCollector) GenerateReport() error {
 
 	fmt.Fprintln(f, "=== Simulation Report ===")
 	writeTimeWindowMetrics(f, "Simulation Metrics", mc.CurrentMetrics)
+	mc.writeHeaderLagMetrics(f)
+	mc.writeHeaderSyncMetrics(f)
+	mc.writeGrindingMetrics(f)
+	mc.writeCompromiseMetrics(f)
+	mc.writeSecurityModelMetrics(f)
+	mc.writeWithholdingMetrics(f)
+	mc.writeServingMetrics(f)
+	mc.writeEclipseMetrics(f)
+	mc.writeCorruptionMetrics(f)
+	mc.writeExecutionReceiptMetrics(f)
+	mc.writeFraudProofMetrics(f)
+	mc.writeWorkloadMetrics(f)
+	mc.writeCrossShardMetrics(f)
+	mc.writeFinalityMetrics(f)
+	mc.writeBeaconMetrics(f)
+	mc.writeDASMetrics(f)
+	mc.writeStateSyncMetrics(f)
+	mc.writeGossipMetrics(f)
+	mc.writeGeographyMetrics(f)
+	mc.writeBandwidthMetrics(f)
+	mc.writeLossMetrics(f)
+	mc.writePartitionMetrics(f)
+	mc.writeLatencyMetrics(f)
 
 	// Write logs
 	fmt.Fprintln(f, "=== Event Logs ===")
@@ -223,6 +413,24 @@ func (mc *MetricsCollector) GenerateReport() error {
 	return nil
 }
 
+// JainFairnessIndex returns (sum x)^2 / (n * sum x^2) over the per-node production counts.
+// It is 1 when every producer made the same number of blocks and 1/n when a single node made all of them.
+func JainFairnessIndex(counts map[int]int) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	sum := 0.0
+	sumSquares := 0.0
+	for _, c := range counts {
+		sum += float64(c)
+		sumSquares += float64(c) * float64(c)
+	}
+	if sumSquares == 0 {
+		return 0
+	}
+	return sum * sum / (float64(len(counts)) * sumSquares)
+}
+
 // Helper function to calculate percentage
 func CalculatePercentage(part, total int) float64 {
 	if total == 0 {
@@ -305,17 +513,60 @@ func writeTimeWindowMetrics(w io.Writer, title string, metrics TimeWindowMetrics
 		totalBlockDownDelay = totalBlockDownDelay / float64(shardCount)
 	}
 	fmt.Fprintf(w, "  Average Block Download Delay: %.2fms\n", totalBlockDownDelay)
-	// Add TPS calculation
-	totalBlocks := 0
+	// Add TPS calculation, orphaned blocks carry no transactions
+	totalTransactions := 0
 	for _, stats := range metrics.ShardStats {
-		totalBlocks += stats.HonestBlocks
+		totalTransactions += stats.CanonicalTransactions
 	}
-	totalTransactions := totalBlocks * config.TransactionsPerBlock
 	fmt.Println("Total txn:", totalTransactions)
 	tps := float64(totalTransactions) / float64(config.SimulationTime)
 	fmt.Fprintf(w, "Performance Metrics:\n")
 	fmt.Fprintf(w, "  Transactions Per Second (TPS): %.2f\n\n", tps)
 
+	// Per-node block production counts
+	fmt.Fprintf(w, "Block Producer Statistics:\n")
+	for shardID, stats := range metrics.ShardStats {
+		minCount, maxCount := 0, 0
+		producerIDs := make([]int, 0, len(stats.ProducerCounts))
+		for producerID, count := range stats.ProducerCounts {
+			producerIDs = append(producerIDs, producerID)
+			if minCount == 0 || count < minCount {
+				minCount = count
+			}
+			maxCount = max(maxCount, count)
+		}
+		sort.Ints(producerIDs)
+		fmt.Fprintf(w, "  Shard %d: %d distinct producers, min %d, max %d blocks per producer, Jain fairness index %.3f\n",
+			shardID, len(producerIDs), minCount, maxCount, JainFairnessIndex(stats.ProducerCounts))
+		for _, producerID := range producerIDs {
+			fmt.Fprintf(w, "    Node %d: %d blocks\n", producerID, stats.ProducerCounts[producerID])
+		}
+	}
+	fmt.Fprintf(w, "\n")
+
+	// Fork statistics
+	fmt.Fprintf(w, "Fork Statistics:\n")
+	for shardID, stats := range metrics.ShardStats {
+		totalBlocks := stats.HonestBlocks + stats.MaliciousBlocks
+		reorgs := 0
+		depths := make([]int, 0, len(stats.ReorgDepths))
+		for depth, count := range stats.ReorgDepths {
+			reorgs += count
+			depths = append(depths, depth)
+		}
+		sort.Ints(depths)
+		fmt.Fprintf(w, "  Shard %d: %d canonical of %d blocks, orphan rate %.2f%%, uncle rate %.2f%%\n",
+			shardID, stats.CanonicalBlocks, totalBlocks,
+			CalculatePercentage(stats.OrphanedBlocks, totalBlocks), CalculatePercentage(stats.UncleBlocks, totalBlocks))
+		fmt.Fprintf(w, "    Chain quality: %.2f%% honest canonical blocks, %.2f%% honest blocks produced\n",
+			CalculatePercentage(stats.CanonicalHonestBlocks, stats.CanonicalBlocks), CalculatePercentage(stats.HonestBlocks, totalBlocks))
+		fmt.Fprintf(w, "    Reorgs: %d\n", reorgs)
+		for _, depth := range depths {
+			fmt.Fprintf(w, "      Depth %d: %d\n", depth, stats.ReorgDepths[depth])
+		}
+	}
+	fmt.Fprintf(w, "\n")
+
 	// Printing the block indexes for each shard
 	fmt.Fprintf(w, "Block Index Chains:\n")
 	for shardID, stats := range metrics.ShardStats {
@@ -337,21 +588,77 @@ func (mc *MetricsCollector) GetSimulationResponse() SimulationResponse {
 			BlockHeaderDelay:     mc.CurrentMetrics.NetworkMetrics.AverageHeaderDelay,
 			BlockDownloadDelays:  mc.CurrentMetrics.NetworkMetrics.AverageDownloadDelay,
 		},
+		ExecutionReceipts: ExecutionReceiptResponse{
+			BodyDelays:   make(map[int]LatencyStats),
+			Coverage:     make(map[int]float64),
+			FullCoverage: make(map[int]float64),
+			Unverified:   make(map[int]int),
+		},
+		FraudProofs: make(map[int]FraudProofStats),
+	}
+
+	for shardID := range mc.FraudRecords {
+		response.FraudProofs[shardID] = mc.fraudProofStats(shardID)
+	}
+	if mc.Workload != nil {
+		response.Workload = mc.workloadResponse()
+	}
+	if mc.CrossShard != nil {
+		response.CrossShard = mc.crossShardResponse()
+	}
+	response.Finality = mc.Finality
+	response.Beacon = mc.Beacon
+	response.DAS = mc.DAS
+	response.StateSync = mc.StateSync
+	response.Gossip = mc.Gossip
+	response.Geography = mc.Geography
+	response.Bandwidth = mc.Bandwidth
+	response.Loss = mc.Loss
+	response.Partitions = mc.Partitions
+	response.Latency = mc.Latency
+	response.HeaderLag = mc.HeaderLag
+	response.HeaderSync = mc.HeaderSync
+	response.Grinding = mc.Grinding
+	response.Compromise = mc.Compromise
+	response.SecurityModel = mc.SecurityModel
+	response.Withholding = mc.Withholding
+	response.Serving = mc.Serving
+	response.Eclipse = mc.Eclipse
+	response.Corruption = mc.Corruption
+
+	allHeaderDelays := make([]float64, 0)
+	for _, delays := range mc.ExecutionReceipts.HeaderDelays {
+		allHeaderDelays = append(allHeaderDelays, delays...)
+	}
+	response.ExecutionReceipts.HeaderDelay = NewLatencyStats(allHeaderDelays)
+	for shardID := range mc.ExecutionReceipts.Receipts {
+		coverage, fullCoverage, unverified := mc.receiptCoverage(shardID)
+		response.ExecutionReceipts.BodyDelays[shardID] = NewLatencyStats(mc.ExecutionReceipts.BodyDelays[shardID])
+		response.ExecutionReceipts.Coverage[shardID] = coverage
+		response.ExecutionReceipts.FullCoverage[shardID] = fullCoverage
+		response.ExecutionReceipts.Unverified[shardID] = unverified
 	}
 
-	// Calculate total blocks and populate shard stats
-	totalBlocks := 0
+	// Calculate total transactions and populate shard stats
+	totalTransactions := 0
 	for shardID, stats := range mc.CurrentMetrics.ShardStats {
-		totalBlocks += stats.HonestBlocks
+		totalTransactions += stats.CanonicalTransactions
+		producedBlocks := stats.HonestBlocks + stats.MaliciousBlocks
 		response.BlockProduction[shardID] = ShardStats{
-			MaliciousBlocks: stats.MaliciousBlocks,
-			HonestBlocks:    stats.HonestBlocks,
-			TotalBlocks:     stats.HonestBlocks + stats.MaliciousBlocks,
+			MaliciousBlocks:  stats.MaliciousBlocks,
+			HonestBlocks:     stats.HonestBlocks,
+			TotalBlocks:      stats.HonestBlocks + stats.MaliciousBlocks,
+			ProducerCounts:   stats.ProducerCounts,
+			ProducerFairness: JainFairnessIndex(stats.ProducerCounts),
+			CanonicalBlocks:  stats.CanonicalBlocks,
+			OrphanRate:       CalculatePercentage(stats.OrphanedBlocks, producedBlocks) / 100,
+			UncleRate:        CalculatePercentage(stats.UncleBlocks, producedBlocks) / 100,
+			ChainQuality:     CalculatePercentage(stats.CanonicalHonestBlocks, stats.CanonicalBlocks) / 100,
+			ReorgDepths:      stats.ReorgDepths,
 		}
 	}
 
 	// Calculate TPS
-	totalTransactions := totalBlocks * config.TransactionsPerBlock
 	tps := float64(totalTransactions) / float64(config.SimulationTime)
 	response.Performance = PerformanceStats{
 		TPS: tps,
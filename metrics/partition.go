@@ -0,0 +1,93 @@
+// metrics/partition.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+	"sharding/partition"
+	"sort"
+)
+
+// PartitionStats describes one scheduled partition: what crossed it and how far the two sides
+// drifted apart before it healed. Agreement times are in time units after healing, -1 when
+// the nodes still disagreed at the end of the simulation.
+type PartitionStats struct {
+	Start             int64       `json:"start"`
+	End               int64       `json:"end"`
+	Split             string      `json:"split"`
+	Mode              string      `json:"mode"`
+	Isolated          int         `json:"isolated_nodes"`
+	Healed            bool        `json:"healed"`
+	Dropped           int         `json:"dropped_messages"`
+	Held              int         `json:"held_messages"`
+	HeaderSpread      map[int]int `json:"header_spread_at_heal"`
+	ForkDepth         map[int]int `json:"fork_depth_at_heal,omitempty"`
+	HeadersAgreeAfter float64     `json:"headers_agree_after"`
+	// ChainsAgreeAfter is nil without forks, when shard chains never diverge and are not tracked
+	ChainsAgreeAfter *float64 `json:"chains_agree_after,omitempty"`
+}
+
+// CollectPartitions records the traffic cut by every scheduled partition and how the network recovered
+func (mc *MetricsCollector) CollectPartitions(partitions []*partition.Partition, cfg *config.Config) {
+	mc.Partitions = make([]PartitionStats, 0, len(partitions))
+	for _, p := range partitions {
+		var chainsAgreeAfter *float64
+		if cfg.EnableForks {
+			chainsAgreeAfter = &p.ChainsAgreeAfter
+		}
+		mc.Partitions = append(mc.Partitions, PartitionStats{
+			Start:             p.Start,
+			End:               p.End,
+			Split:             p.Partition.Split.String(),
+			Mode:              cfg.PartitionMode.String(),
+			Isolated:          len(p.Isolated),
+			Healed:            p.Healed,
+			Dropped:           p.Dropped,
+			Held:              p.Held,
+			HeaderSpread:      p.HeaderSpread,
+			ForkDepth:         p.ForkDepth,
+			HeadersAgreeAfter: p.HeadersAgreeAfter,
+			ChainsAgreeAfter:  chainsAgreeAfter,
+		})
+	}
+}
+
+func (mc *MetricsCollector) writePartitionMetrics(w io.Writer) {
+	if len(mc.Partitions) == 0 {
+		return
+	}
+	agreement := func(after float64) string {
+		if after < 0 {
+			return "never"
+		}
+		return fmt.Sprintf("%.0f time units after healing", after)
+	}
+	fmt.Fprintf(w, "Network Partition Metrics:\n")
+	for i, p := range mc.Partitions {
+		fmt.Fprintf(w, "  Partition %d: %d nodes isolated by %s from time %d to %d, %d messages dropped, %d held\n",
+			i, p.Isolated, p.Split, p.Start, p.End, p.Dropped, p.Held)
+		if !p.Healed {
+			fmt.Fprintf(w, "  Partition %d: still active at the end of the simulation\n", i)
+			continue
+		}
+		shardIDs := make([]int, 0, len(p.HeaderSpread))
+		for id := range p.HeaderSpread {
+			shardIDs = append(shardIDs, id)
+		}
+		sort.Ints(shardIDs)
+		for _, id := range shardIDs {
+			if depth, exists := p.ForkDepth[id]; exists {
+				fmt.Fprintf(w, "  Partition %d Shard %d at Heal: header spread %d blocks, fork depth %d blocks\n", i, id, p.HeaderSpread[id], depth)
+			} else {
+				fmt.Fprintf(w, "  Partition %d Shard %d at Heal: header spread %d blocks\n", i, id, p.HeaderSpread[id])
+			}
+		}
+		fmt.Fprintf(w, "  Partition %d Header Chains Agree: %s\n", i, agreement(p.HeadersAgreeAfter))
+		if p.ChainsAgreeAfter != nil {
+			fmt.Fprintf(w, "  Partition %d Shard Chains Agree: %s\n", i, agreement(*p.ChainsAgreeAfter))
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
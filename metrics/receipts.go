@@ -0,0 +1,86 @@
+// metrics/receipts.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReceiptCoverage tracks how many of the verifiers an execution receipt body was sent to verified it
+type ReceiptCoverage struct {
+	ShardID   int
+	Verifiers int
+	Verified  int
+}
+
+type ExecutionReceiptMetrics struct {
+	HeaderDelays map[int][]float64
+	BodyDelays   map[int][]float64
+	Receipts     map[int][]ReceiptCoverage
+}
+
+type ExecutionReceiptResponse struct {
+	HeaderDelay  LatencyStats         `json:"header_delay"`
+	BodyDelays   map[int]LatencyStats `json:"body_delays"`
+	Coverage     map[int]float64      `json:"verification_coverage"`
+	FullCoverage map[int]float64      `json:"fully_verified_ratio"`
+	Unverified   map[int]int          `json:"unverified_receipts"`
+}
+
+// CollectExecutionReceipts records ER propagation delays and the verification outcome of every receipt
+func (mc *MetricsCollector) CollectExecutionReceipts(headerDelays map[int][]int64, bodyDelays map[int][]int64, receipts map[int]*ReceiptCoverage) {
+	for shardID, delays := range headerDelays {
+		for _, delay := range delays {
+			mc.ExecutionReceipts.HeaderDelays[shardID] = append(mc.ExecutionReceipts.HeaderDelays[shardID], float64(delay))
+		}
+	}
+	for shardID, delays := range bodyDelays {
+		for _, delay := range delays {
+			mc.ExecutionReceipts.BodyDelays[shardID] = append(mc.ExecutionReceipts.BodyDelays[shardID], float64(delay))
+		}
+	}
+	for _, receipt := range receipts {
+		mc.ExecutionReceipts.Receipts[receipt.ShardID] = append(mc.ExecutionReceipts.Receipts[receipt.ShardID], *receipt)
+	}
+}
+
+// receiptCoverage returns the mean share of verifiers that verified each receipt of a shard,
+// the share of receipts verified by all of their verifiers and the number verified by none
+func (mc *MetricsCollector) receiptCoverage(shardID int) (float64, float64, int) {
+	receipts := mc.ExecutionReceipts.Receipts[shardID]
+	if len(receipts) == 0 {
+		return 0, 0, 0
+	}
+	coverage := 0.0
+	fullyVerified := 0
+	unverified := 0
+	for _, r := range receipts {
+		if r.Verifiers > 0 {
+			coverage += float64(r.Verified) / float64(r.Verifiers)
+		}
+		if r.Verified == r.Verifiers {
+			fullyVerified++
+		}
+		if r.Verified == 0 {
+			unverified++
+		}
+	}
+	return coverage / float64(len(receipts)), float64(fullyVerified) / float64(len(receipts)), unverified
+}
+
+func (mc *MetricsCollector) writeExecutionReceiptMetrics(w io.Writer) {
+	fmt.Fprintf(w, "Execution Receipt Metrics:\n")
+	allHeaderDelays := make([]float64, 0)
+	for _, delays := range mc.ExecutionReceipts.HeaderDelays {
+		allHeaderDelays = append(allHeaderDelays, delays...)
+	}
+	fmt.Fprintf(w, "  ER Header Propagation: %s\n", NewLatencyStats(allHeaderDelays))
+	for shardID, receipts := range mc.ExecutionReceipts.Receipts {
+		coverage, fullCoverage, unverified := mc.receiptCoverage(shardID)
+		fmt.Fprintf(w, "  Shard %d: %d receipts\n", shardID, len(receipts))
+		fmt.Fprintf(w, "    ER Body Delivery: %s\n", NewLatencyStats(mc.ExecutionReceipts.BodyDelays[shardID]))
+		fmt.Fprintf(w, "    Verification Coverage: %.2f%%, fully verified %.2f%%, unverified %d\n", coverage*100, fullCoverage*100, unverified)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,145 @@
+// metrics/security.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/compromise"
+	"sharding/config"
+	"sharding/node"
+	"sharding/security"
+	"sort"
+)
+
+// SecurityModelResponse sets the closed-form probability that a randomly sampled shard has a
+// malicious share above the threshold next to how often the simulated shards did. Both sides count
+// the same members: the regular nodes and the operators, which the lottery never moves but which
+// belong to their shard.
+type SecurityModelResponse struct {
+	Population int     `json:"population"`
+	Malicious  int     `json:"malicious"`
+	Threshold  float64 `json:"threshold"`
+	// Nominal is the bound for the shard size once every node is assigned
+	Nominal                ShardBound `json:"nominal"`
+	AnyShardHypergeometric float64    `json:"any_shard_hypergeometric"`
+	AnyShardBinomial       float64    `json:"any_shard_binomial"`
+	// Shards compares every shard's simulated frequency with the bound at the sizes it actually had
+	Shards             map[int]ShardBound `json:"shards"`
+	SimulatedFrequency float64            `json:"simulated_frequency"`
+	ExpectedFrequency  float64            `json:"expected_frequency"`
+}
+
+// ShardBound holds the hypergeometric and binomial tails for a shard. For observed shards the
+// tails are averaged over the sizes the shard had, and BoundRatio divides the simulated frequency
+// by the hypergeometric tail, above 1 where the adversary beats random sampling.
+type ShardBound struct {
+	Size               float64 `json:"size"`
+	Hypergeometric     float64 `json:"hypergeometric"`
+	Binomial           float64 `json:"binomial"`
+	Observations       int     `json:"observations,omitempty"`
+	Exceeded           int     `json:"exceeded,omitempty"`
+	SimulatedFrequency float64 `json:"simulated_frequency,omitempty"`
+	BoundRatio         float64 `json:"bound_ratio,omitempty"`
+}
+
+// CollectSecurityModel computes the random-sampling bound for the nodes and operators of the run
+// and compares it with the compromise frequencies the tracker observed over the same members
+func (mc *MetricsCollector) CollectSecurityModel(tracker *compromise.Tracker, cfg *config.Config, nodes map[int]*node.Node, operators map[int]*node.Node) {
+	population, malicious := 0, 0
+	for _, members := range []map[int]*node.Node{nodes, operators} {
+		for _, n := range members {
+			population++
+			if !n.IsHonest {
+				malicious++
+			}
+		}
+	}
+	ratio := 0.0
+	if population > 0 {
+		ratio = float64(malicious) / float64(population)
+	}
+	bound := func(size int) security.Bound {
+		return security.NewBound(population, malicious, size, ratio, tracker.Threshold)
+	}
+
+	nominalSize := population / cfg.NumShards
+	nominal := bound(nominalSize)
+	response := &SecurityModelResponse{
+		Population:             population,
+		Malicious:              malicious,
+		Threshold:              tracker.Threshold,
+		Nominal:                ShardBound{Size: float64(nominalSize), Hypergeometric: nominal.Hypergeometric, Binomial: nominal.Binomial},
+		AnyShardHypergeometric: security.AnyShard(nominal.Hypergeometric, cfg.NumShards),
+		AnyShardBinomial:       security.AnyShard(nominal.Binomial, cfg.NumShards),
+		Shards:                 make(map[int]ShardBound),
+	}
+	for shardID := 0; shardID < cfg.NumShards; shardID++ {
+		stats := ShardBound{
+			Size:         tracker.MeanSize(shardID),
+			Observations: tracker.Observations[shardID],
+			Exceeded:     tracker.Exceeded[shardID],
+		}
+		for size, count := range tracker.Sizes[shardID] {
+			b := bound(size)
+			stats.Hypergeometric += b.Hypergeometric * float64(count)
+			stats.Binomial += b.Binomial * float64(count)
+		}
+		if stats.Observations > 0 {
+			stats.Hypergeometric /= float64(stats.Observations)
+			stats.Binomial /= float64(stats.Observations)
+		}
+		response.Shards[shardID] = stats
+	}
+	response.estimate()
+	mc.SecurityModel = response
+}
+
+// estimate derives the simulated frequencies from the observation counts
+func (r *SecurityModelResponse) estimate() {
+	observations, exceeded, expected := 0, 0, 0.0
+	for shardID, stats := range r.Shards {
+		stats.SimulatedFrequency, stats.BoundRatio = 0, 0
+		if stats.Observations > 0 {
+			stats.SimulatedFrequency = float64(stats.Exceeded) / float64(stats.Observations)
+		}
+		if stats.Hypergeometric > 0 {
+			stats.BoundRatio = stats.SimulatedFrequency / stats.Hypergeometric
+		}
+		r.Shards[shardID] = stats
+		observations += stats.Observations
+		exceeded += stats.Exceeded
+		expected += stats.Hypergeometric * float64(stats.Observations)
+	}
+	if observations > 0 {
+		r.SimulatedFrequency = float64(exceeded) / float64(observations)
+		r.ExpectedFrequency = expected / float64(observations)
+	}
+}
+
+func (mc *MetricsCollector) writeSecurityModelMetrics(w io.Writer) {
+	if mc.SecurityModel == nil {
+		return
+	}
+	m := mc.SecurityModel
+	fmt.Fprintf(w, "Analytical Security Model:\n")
+	fmt.Fprintf(w, "  Population: %d nodes, %d malicious, threshold %.2f%%\n", m.Population, m.Malicious, m.Threshold*100)
+	fmt.Fprintf(w, "  Random Shard of %.0f Members: hypergeometric %.4e, binomial %.4e\n", m.Nominal.Size, m.Nominal.Hypergeometric, m.Nominal.Binomial)
+	fmt.Fprintf(w, "  Any Shard Compromised: hypergeometric %.4e, binomial %.4e\n", m.AnyShardHypergeometric, m.AnyShardBinomial)
+	shardIDs := make([]int, 0, len(m.Shards))
+	for id := range m.Shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		stats := m.Shards[id]
+		fmt.Fprintf(w, "  Shard %d (mean size %.2f): simulated %.4e (%d of %d observations), hypergeometric %.4e, binomial %.4e",
+			id, stats.Size, stats.SimulatedFrequency, stats.Exceeded, stats.Observations, stats.Hypergeometric, stats.Binomial)
+		if stats.BoundRatio > 0 {
+			fmt.Fprintf(w, ", %.2fx the bound", stats.BoundRatio)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "  All Shards: simulated %.4e, expected from random sampling %.4e\n", m.SimulatedFrequency, m.ExpectedFrequency)
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,123 @@
+// metrics/serving.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+	"sharding/node"
+	"sharding/serving"
+	"sort"
+)
+
+// ServingRecord accumulates the block requests peers answered during producer syncs, by the
+// strategy they answered with
+type ServingRecord struct {
+	Delays    map[config.ServingStrategyType][]float64
+	Delivered map[config.ServingStrategyType]int
+}
+
+func NewServingRecord() *ServingRecord {
+	return &ServingRecord{
+		Delays:    make(map[config.ServingStrategyType][]float64),
+		Delivered: make(map[config.ServingStrategyType]int),
+	}
+}
+
+func (r *ServingRecord) Record(outcomes []serving.Outcome) {
+	for _, outcome := range outcomes {
+		r.Delays[outcome.Strategy] = append(r.Delays[outcome.Strategy], outcome.Delay)
+		if outcome.Delivered {
+			r.Delivered[outcome.Strategy]++
+		}
+	}
+}
+
+// ServingResponse breaks the block requests of producer syncs down by the strategy the peer
+// answered with, honest peers included. Assigned counts the malicious nodes given each strategy.
+type ServingResponse struct {
+	Strategies map[string]ServingStats `json:"strategies"`
+	Assigned   map[string]int          `json:"assigned_nodes"`
+}
+
+// ServingStats describes the requests answered with one strategy. Delay is the time requesters
+// waited on the peer, whether or not they got a valid block, and TimeShare its share of the
+// time spent on every request.
+type ServingStats struct {
+	Requests  int          `json:"requests"`
+	Delivered int          `json:"delivered"`
+	Failed    int          `json:"failed"`
+	Delay     LatencyStats `json:"delay"`
+	TimeShare float64      `json:"time_share"`
+}
+
+// CollectServing summarises the block requests by serving strategy. It leaves Serving nil while
+// only honest peers answered.
+func (mc *MetricsCollector) CollectServing(record *ServingRecord, nodes map[int]*node.Node, operators map[int]*node.Node) {
+	byzantine := false
+	for strategy := range record.Delays {
+		byzantine = byzantine || strategy != config.ServeHonestly
+	}
+	if !byzantine {
+		return
+	}
+	response := &ServingResponse{
+		Strategies: make(map[string]ServingStats),
+		Assigned:   make(map[string]int),
+	}
+	for _, group := range []map[int]*node.Node{nodes, operators} {
+		for _, n := range group {
+			if !n.IsHonest {
+				response.Assigned[n.Serving.String()]++
+			}
+		}
+	}
+	totals := make(map[config.ServingStrategyType]float64)
+	allTime := 0.0
+	for strategy, delays := range record.Delays {
+		for _, d := range delays {
+			totals[strategy] += d
+		}
+		allTime += totals[strategy]
+	}
+	for strategy, delays := range record.Delays {
+		stats := ServingStats{
+			Requests:  len(delays),
+			Delivered: record.Delivered[strategy],
+			Failed:    len(delays) - record.Delivered[strategy],
+			Delay:     NewLatencyStats(delays),
+		}
+		if allTime > 0 {
+			stats.TimeShare = totals[strategy] / allTime
+		}
+		response.Strategies[strategy.String()] = stats
+	}
+	mc.Serving = response
+}
+
+func (mc *MetricsCollector) writeServingMetrics(w io.Writer) {
+	if mc.Serving == nil {
+		return
+	}
+	fmt.Fprintf(w, "Byzantine Serving Metrics:\n")
+	names := make([]string, 0, len(mc.Serving.Assigned))
+	for name := range mc.Serving.Assigned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  Malicious Nodes Serving %s: %d\n", name, mc.Serving.Assigned[name])
+	}
+	names = names[:0]
+	for name := range mc.Serving.Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := mc.Serving.Strategies[name]
+		fmt.Fprintf(w, "  Strategy %s: %d requests, %d delivered, %d failed, %.2f%% of the download time\n", name, stats.Requests, stats.Delivered, stats.Failed, stats.TimeShare*100)
+		fmt.Fprintf(w, "    Delay: %s\n", stats.Delay)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,120 @@
+// metrics/statesync.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/block"
+	"sharding/config"
+	"sharding/shard"
+	"sharding/statesync"
+	"sort"
+)
+
+// StateSyncRecord is the state sync a node went through after rotating into a shard
+type StateSyncRecord struct {
+	NodeID    int
+	ShardID   int
+	Height    int
+	StateSize int64
+	Bytes     int64
+	Delay     float64
+}
+
+// StateSyncResponse describes what rotated nodes downloaded before they could produce, and what
+// every strategy would cost at the final height of the shards
+type StateSyncResponse struct {
+	Strategy       string               `json:"strategy"`
+	Syncs          int                  `json:"syncs"`
+	SyncDelay      LatencyStats         `json:"sync_delay"`
+	MeanBytes      float64              `json:"mean_bytes"`
+	TotalBytes     int64                `json:"total_bytes"`
+	MeanHeight     float64              `json:"mean_height"`
+	SkippedWinners int                  `json:"skipped_syncing_winners"`
+	MissedSlots    int                  `json:"missed_slots"`
+	StateSize      map[int]int64        `json:"final_state_size"`
+	Strategies     []StateSyncCostStats `json:"strategies"`
+}
+
+// StateSyncCostStats is the mean cost of a strategy over the shards, without network latency
+type StateSyncCostStats struct {
+	Strategy       string  `json:"strategy"`
+	Bytes          float64 `json:"bytes"`
+	ReplayedBlocks float64 `json:"replayed_blocks"`
+	Duration       float64 `json:"duration_ms"`
+}
+
+// CollectStateSync summarises the syncs of rotated nodes. skippedWinners counts lottery winners
+// passed over because they were still syncing and missedSlots the production events left without
+// a producer because of it.
+func (mc *MetricsCollector) CollectStateSync(records []*StateSyncRecord, skippedWinners int, missedSlots int, shards map[int]*shard.Shard, cfg *config.Config) {
+	response := &StateSyncResponse{
+		Strategy:       cfg.StateSync.String(),
+		Syncs:          len(records),
+		SkippedWinners: skippedWinners,
+		MissedSlots:    missedSlots,
+		StateSize:      make(map[int]int64),
+	}
+
+	delays := make([]float64, len(records))
+	totalHeight := 0
+	for i, r := range records {
+		delays[i] = r.Delay
+		response.TotalBytes += r.Bytes
+		totalHeight += r.Height
+	}
+	if len(records) > 0 {
+		response.MeanBytes = float64(response.TotalBytes) / float64(len(records))
+		response.MeanHeight = float64(totalHeight) / float64(len(records))
+	}
+	response.SyncDelay = NewLatencyStats(delays)
+
+	chains := make(map[int][]*block.Block)
+	for id, s := range shards {
+		chains[id] = s.CanonicalChain()
+		response.StateSize[id] = statesync.StateSize(cfg, chains[id])
+	}
+	for _, strategy := range []config.StateSyncStrategy{config.FullStateSync, config.SnapshotSync, config.StatelessSync} {
+		stats := StateSyncCostStats{Strategy: strategy.String()}
+		for _, chain := range chains {
+			cost := statesync.NewStrategyFor(strategy, cfg).Cost(chain)
+			stats.Bytes += float64(cost.Bytes)
+			stats.ReplayedBlocks += float64(cost.ReplayedBlocks)
+			stats.Duration += cost.Duration(cfg)
+		}
+		if len(chains) > 0 {
+			stats.Bytes /= float64(len(chains))
+			stats.ReplayedBlocks /= float64(len(chains))
+			stats.Duration /= float64(len(chains))
+		}
+		response.Strategies = append(response.Strategies, stats)
+	}
+	mc.StateSync = response
+}
+
+func (mc *MetricsCollector) writeStateSyncMetrics(w io.Writer) {
+	if mc.StateSync == nil {
+		return
+	}
+	s := mc.StateSync
+	fmt.Fprintf(w, "State Sync Metrics:\n")
+	fmt.Fprintf(w, "  Strategy: %s, %d syncs at a mean height of %.2f blocks\n", s.Strategy, s.Syncs, s.MeanHeight)
+	fmt.Fprintf(w, "  Sync Delay: %s\n", s.SyncDelay)
+	fmt.Fprintf(w, "  Downloaded: %.2f bytes mean, %d bytes total\n", s.MeanBytes, s.TotalBytes)
+	fmt.Fprintf(w, "  Lottery Winners Skipped While Syncing: %d\n", s.SkippedWinners)
+	fmt.Fprintf(w, "  Production Slots Missed While Syncing: %d\n", s.MissedSlots)
+	shardIDs := make([]int, 0, len(s.StateSize))
+	for id := range s.StateSize {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		fmt.Fprintf(w, "  Final State Size of Shard %d: %d bytes\n", id, s.StateSize[id])
+	}
+	fmt.Fprintf(w, "  Strategy Costs at the Final Height:\n")
+	for _, c := range s.Strategies {
+		fmt.Fprintf(w, "    %s: %.0f bytes, %.2f blocks replayed, %.2fms\n", c.Strategy, c.Bytes, c.ReplayedBlocks, c.Duration)
+	}
+	fmt.Fprintf(w, "\n")
+}
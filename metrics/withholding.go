@@ -0,0 +1,123 @@
+// metrics/withholding.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/config"
+	"sort"
+)
+
+// WithholdingRecord accumulates the blocks malicious producers withheld and what they cost the
+// producers syncing after them
+type WithholdingRecord struct {
+	WithheldBlocks map[int]int
+	MissingBlocks  map[int]int
+	StalledSlots   map[int]int
+	Downloads      int
+	// Downloads that hit at least one peer withholding a block, and the time lost to them in milliseconds
+	Affected   int
+	Refusals   int
+	WastedTime []float64
+}
+
+func NewWithholdingRecord() *WithholdingRecord {
+	return &WithholdingRecord{
+		WithheldBlocks: make(map[int]int),
+		MissingBlocks:  make(map[int]int),
+		StalledSlots:   make(map[int]int),
+		WastedTime:     make([]float64, 0),
+	}
+}
+
+// RecordDownload adds the outcome of one producer sync
+func (r *WithholdingRecord) RecordDownload(shardID, refusals, missing int, wastedTime float64) {
+	r.Downloads++
+	if refusals == 0 {
+		return
+	}
+	r.Affected++
+	r.Refusals += refusals
+	r.MissingBlocks[shardID] += missing
+	r.WastedTime = append(r.WastedTime, wastedTime)
+}
+
+// WithholdingResponse reports the block bodies malicious producers held back, the downloads that
+// came back without them and the slots honest producers lost because they could not extend them.
+// WastedTime covers the downloads that waited on a withholding peer.
+type WithholdingResponse struct {
+	Mode              string                        `json:"mode"`
+	WithheldBlocks    int                           `json:"withheld_blocks"`
+	MissingBlocks     int                           `json:"missing_blocks"`
+	StalledSlots      int                           `json:"stalled_slots"`
+	Shards            map[int]WithholdingShardStats `json:"shards"`
+	Downloads         int                           `json:"downloads"`
+	AffectedDownloads int                           `json:"affected_downloads"`
+	Refusals          int                           `json:"refusals"`
+	WastedTime        LatencyStats                  `json:"wasted_time"`
+	TotalWastedTime   float64                       `json:"total_wasted_time_ms"`
+}
+
+type WithholdingShardStats struct {
+	WithheldBlocks int `json:"withheld_blocks"`
+	MissingBlocks  int `json:"missing_blocks"`
+	StalledSlots   int `json:"stalled_slots"`
+}
+
+// CollectWithholding summarises the withheld blocks and the downloads and slots they cost
+func (mc *MetricsCollector) CollectWithholding(record *WithholdingRecord, mode config.WithholdingMode) {
+	response := &WithholdingResponse{
+		Mode:              mode.String(),
+		Shards:            make(map[int]WithholdingShardStats),
+		Downloads:         record.Downloads,
+		AffectedDownloads: record.Affected,
+		Refusals:          record.Refusals,
+		WastedTime:        NewLatencyStats(record.WastedTime),
+	}
+	shardIDs := make(map[int]bool)
+	for _, counts := range []map[int]int{record.WithheldBlocks, record.MissingBlocks, record.StalledSlots} {
+		for shardID := range counts {
+			shardIDs[shardID] = true
+		}
+	}
+	for shardID := range shardIDs {
+		stats := WithholdingShardStats{
+			WithheldBlocks: record.WithheldBlocks[shardID],
+			MissingBlocks:  record.MissingBlocks[shardID],
+			StalledSlots:   record.StalledSlots[shardID],
+		}
+		response.WithheldBlocks += stats.WithheldBlocks
+		response.MissingBlocks += stats.MissingBlocks
+		response.StalledSlots += stats.StalledSlots
+		response.Shards[shardID] = stats
+	}
+	for _, wasted := range record.WastedTime {
+		response.TotalWastedTime += wasted
+	}
+	mc.Withholding = response
+}
+
+func (mc *MetricsCollector) writeWithholdingMetrics(w io.Writer) {
+	if mc.Withholding == nil {
+		return
+	}
+	h := mc.Withholding
+	fmt.Fprintf(w, "Block Withholding Metrics:\n")
+	fmt.Fprintf(w, "  Withholding Mode: %s\n", h.Mode)
+	fmt.Fprintf(w, "  Withheld Blocks: %d\n", h.WithheldBlocks)
+	fmt.Fprintf(w, "  Missing Blocks: %d across %d of %d producer downloads\n", h.MissingBlocks, h.AffectedDownloads, h.Downloads)
+	fmt.Fprintf(w, "  Withholding Peers Waited On: %d\n", h.Refusals)
+	fmt.Fprintf(w, "  Wasted Download Time: %.2fms in total, per affected download %s\n", h.TotalWastedTime, h.WastedTime)
+	fmt.Fprintf(w, "  Stalled Production Slots: %d\n", h.StalledSlots)
+	shardIDs := make([]int, 0, len(h.Shards))
+	for id := range h.Shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		stats := h.Shards[id]
+		fmt.Fprintf(w, "  Shard %d: %d withheld, %d missing, %d stalled slots\n", id, stats.WithheldBlocks, stats.MissingBlocks, stats.StalledSlots)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,141 @@
+// metrics/workload.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sharding/block"
+	"sharding/config"
+	"sharding/shard"
+)
+
+// WorkloadMetrics follows the generated transactions through the shard mempools
+type WorkloadMetrics struct {
+	Duration           int64
+	Generated          map[int]int
+	Dropped            map[int]int
+	Committed          map[int]int
+	Backlog            map[int]int
+	BacklogSamples     map[int][]int
+	InclusionLatencies map[int][]float64
+}
+
+type MempoolStats struct {
+	Generated        int          `json:"generated"`
+	Committed        int          `json:"committed"`
+	Dropped          int          `json:"dropped"`
+	Backlog          int          `json:"final_backlog"`
+	MeanBacklog      float64      `json:"mean_backlog"`
+	MaxBacklog       int          `json:"max_backlog"`
+	Throughput       float64      `json:"throughput"`
+	InclusionLatency LatencyStats `json:"inclusion_latency"`
+}
+
+type WorkloadResponse struct {
+	OfferedLoad      float64              `json:"offered_load"`
+	Throughput       float64              `json:"throughput"`
+	InclusionLatency LatencyStats         `json:"inclusion_latency"`
+	Shards           map[int]MempoolStats `json:"shards"`
+}
+
+// CollectWorkload records how the generated transactions made it into the canonical chains.
+// duration is the simulated time the workload ran for.
+func (mc *MetricsCollector) CollectWorkload(shards map[int]*shard.Shard, duration int64) {
+	mc.Workload = &WorkloadMetrics{
+		Duration:           duration,
+		Generated:          make(map[int]int),
+		Dropped:            make(map[int]int),
+		Committed:          make(map[int]int),
+		Backlog:            make(map[int]int),
+		BacklogSamples:     make(map[int][]int),
+		InclusionLatencies: make(map[int][]float64),
+	}
+	for shardID, s := range shards {
+		mc.Workload.Generated[shardID] = s.Mempool.Added + s.Mempool.Dropped
+		mc.Workload.Dropped[shardID] = s.Mempool.Dropped
+		mc.Workload.Backlog[shardID] = s.Mempool.Len()
+		mc.Workload.BacklogSamples[shardID] = s.Mempool.BacklogSamples
+		latencies := make([]float64, 0)
+		for _, blk := range s.CanonicalChain() {
+			if blk.IsMalicious {
+				continue
+			}
+			for _, tx := range blk.Transactions {
+				latencies = append(latencies, (float64(blk.Timestamp)-tx.CreatedAt)*1000.0)
+			}
+		}
+		mc.Workload.Committed[shardID] = len(latencies)
+		mc.Workload.InclusionLatencies[shardID] = latencies
+	}
+}
+
+// transactionCount returns the transactions a block carries. Blocks built without a workload
+// are assumed to be full.
+func transactionCount(blk *block.Block) int {
+	if blk.Transactions == nil {
+		return config.TransactionsPerBlock
+	}
+	return len(blk.Transactions)
+}
+
+func (mc *MetricsCollector) mempoolStats(shardID int) MempoolStats {
+	w := mc.Workload
+	stats := MempoolStats{
+		Generated:        w.Generated[shardID],
+		Committed:        w.Committed[shardID],
+		Dropped:          w.Dropped[shardID],
+		Backlog:          w.Backlog[shardID],
+		InclusionLatency: NewLatencyStats(w.InclusionLatencies[shardID]),
+	}
+	if samples := w.BacklogSamples[shardID]; len(samples) > 0 {
+		total := 0
+		for _, backlog := range samples {
+			total += backlog
+			stats.MaxBacklog = max(stats.MaxBacklog, backlog)
+		}
+		stats.MeanBacklog = float64(total) / float64(len(samples))
+	}
+	if w.Duration > 0 {
+		stats.Throughput = float64(stats.Committed) / float64(w.Duration)
+	}
+	return stats
+}
+
+func (mc *MetricsCollector) workloadResponse() *WorkloadResponse {
+	w := mc.Workload
+	response := &WorkloadResponse{Shards: make(map[int]MempoolStats)}
+	generated, committed := 0, 0
+	allLatencies := make([]float64, 0)
+	for shardID := range w.Generated {
+		stats := mc.mempoolStats(shardID)
+		response.Shards[shardID] = stats
+		generated += stats.Generated
+		committed += stats.Committed
+		allLatencies = append(allLatencies, w.InclusionLatencies[shardID]...)
+	}
+	if w.Duration > 0 {
+		response.OfferedLoad = float64(generated) / float64(w.Duration)
+		response.Throughput = float64(committed) / float64(w.Duration)
+	}
+	response.InclusionLatency = NewLatencyStats(allLatencies)
+	return response
+}
+
+func (mc *MetricsCollector) writeWorkloadMetrics(w io.Writer) {
+	if mc.Workload == nil {
+		return
+	}
+	response := mc.workloadResponse()
+	fmt.Fprintf(w, "Workload Metrics:\n")
+	fmt.Fprintf(w, "  Offered Load: %.2f transactions per time unit\n", response.OfferedLoad)
+	fmt.Fprintf(w, "  Throughput: %.2f transactions per time unit\n", response.Throughput)
+	fmt.Fprintf(w, "  Inclusion Latency: %s\n", response.InclusionLatency)
+	for shardID, stats := range response.Shards {
+		fmt.Fprintf(w, "  Shard %d: %d generated, %d committed, %d dropped, %d still pending\n",
+			shardID, stats.Generated, stats.Committed, stats.Dropped, stats.Backlog)
+		fmt.Fprintf(w, "    Mempool Backlog: %.2f mean, %d max at block production\n", stats.MeanBacklog, stats.MaxBacklog)
+		fmt.Fprintf(w, "    Inclusion Latency: %s\n", stats.InclusionLatency)
+	}
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,233 @@
+// network/network.go
+
+package network
+
+import (
+	"math/rand"
+	"sharding/config"
+	"sharding/geo"
+	"sort"
+)
+
+// Link is a connection between two peers. Every message over it takes Mean milliseconds
+// with a jitter of Std, plus the time to transmit the message.
+type Link struct {
+	Mean float64
+	Std  float64
+}
+
+// Graph is the peer graph of the network. Links are undirected and keyed by the lower ID first.
+type Graph struct {
+	Topology config.TopologyType
+	Peers    map[int][]int
+	Links    map[[2]int]*Link
+	// geography is nil when link latencies are drawn uniformly
+	geography *geo.Map
+}
+
+// NewGraph connects the given node IDs using the topology configured in cfg. Link latencies come
+// from the regions of both ends when geography is set. It returns nil when propagation is
+// estimated instead of simulated.
+func NewGraph(cfg *config.Config, ids []int, geography *geo.Map) *Graph {
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+	// Shuffle so that neither ring position nor attachment order follows node IDs
+	rand.Shuffle(len(sorted), func(i, j int) {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	})
+
+	g := &Graph{
+		Topology:  cfg.Topology,
+		Peers:     make(map[int][]int),
+		Links:     make(map[[2]int]*Link),
+		geography: geography,
+	}
+	for _, id := range sorted {
+		g.Peers[id] = make([]int, 0, cfg.PeerDegree)
+	}
+
+	switch cfg.Topology {
+	case config.RandomRegular:
+		g.connectRandomRegular(cfg, sorted)
+	case config.SmallWorld:
+		g.connectSmallWorld(cfg, sorted)
+	case config.ScaleFree:
+		g.connectScaleFree(cfg, sorted)
+	default:
+		return nil
+	}
+	return g
+}
+
+// connectRandomRegular pairs up PeerDegree link stubs per node at random. Self-loops and
+// duplicate links are dropped, so a few nodes end up just below the degree.
+func (g *Graph) connectRandomRegular(cfg *config.Config, ids []int) {
+	stubs := make([]int, 0, len(ids)*cfg.PeerDegree)
+	for _, id := range ids {
+		for i := 0; i < cfg.PeerDegree; i++ {
+			stubs = append(stubs, id)
+		}
+	}
+	rand.Shuffle(len(stubs), func(i, j int) {
+		stubs[i], stubs[j] = stubs[j], stubs[i]
+	})
+	for i := 0; i+1 < len(stubs); i += 2 {
+		g.connect(cfg, stubs[i], stubs[i+1])
+	}
+}
+
+// connectSmallWorld builds a Watts-Strogatz graph: a ring where every node links to its
+// PeerDegree nearest neighbours, each link rewired to a random node with SmallWorldRewiring.
+func (g *Graph) connectSmallWorld(cfg *config.Config, ids []int) {
+	n := len(ids)
+	for i, id := range ids {
+		for offset := 1; offset <= cfg.PeerDegree/2 && offset < n; offset++ {
+			peer := ids[(i+offset)%n]
+			if rand.Float64() < cfg.SmallWorldRewiring {
+				peer = ids[rand.Intn(n)]
+			}
+			g.connect(cfg, id, peer)
+		}
+	}
+}
+
+// connectScaleFree builds a Barabasi-Albert graph: nodes join one by one and link to
+// PeerDegree/2 existing nodes picked with probability proportional to their degree.
+func (g *Graph) connectScaleFree(cfg *config.Config, ids []int) {
+	m := max(1, cfg.PeerDegree/2)
+	// Every link adds both endpoints, so drawing from it favours well connected nodes
+	endpoints := make([]int, 0, 2*m*len(ids))
+	for i, id := range ids {
+		if i <= m {
+			// The first nodes form a clique to start from
+			for _, peer := range ids[:i] {
+				if g.connect(cfg, id, peer) {
+					endpoints = append(endpoints, id, peer)
+				}
+			}
+			continue
+		}
+		for added, attempts := 0, 0; added < m && attempts < 10*m; attempts++ {
+			peer := endpoints[rand.Intn(len(endpoints))]
+			if g.connect(cfg, id, peer) {
+				endpoints = append(endpoints, id, peer)
+				added++
+			}
+		}
+	}
+}
+
+// connect adds a link between a and b with its own latency. It returns false for self-loops
+// and links that already exist.
+func (g *Graph) connect(cfg *config.Config, a, b int) bool {
+	key := linkKey(a, b)
+	if a == b || g.Links[key] != nil {
+		return false
+	}
+	link := &Link{
+		Mean: cfg.MinNetworkDelayMean + rand.Float64()*(cfg.MaxNetworkDelayMean-cfg.MinNetworkDelayMean),
+		Std:  cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd),
+	}
+	if g.geography != nil {
+		link.Mean = g.geography.Latency(a, b)
+	}
+	g.Links[key] = link
+	g.Peers[a] = append(g.Peers[a], b)
+	g.Peers[b] = append(g.Peers[b], a)
+	return true
+}
+
+func linkKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// Link returns the link between a and b, or nil if they are not peers
+func (g *Graph) Link(a, b int) *Link {
+	return g.Links[linkKey(a, b)]
+}
+
+// LinkDelay returns the time in milliseconds a message of size bytes takes from a to b
+func (g *Graph) LinkDelay(cfg *config.Config, a, b int, size int) float64 {
+	link := g.Link(a, b)
+	if link == nil {
+		return 0
+	}
+	// Per-message jitter
+	delay := link.Mean + rand.NormFloat64()*link.Std/1000.0
+
+	// Transmission delay (size in bits / bandwidth in bps)
+	transmissionDelay := (float64(size) * 8.0) / (float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+
+	return delay + transmissionDelay
+}
+
+// Relays picks the peers a node forwards a message to: a random fanout between MinGossipFanout and
+// MaxGossipFanout, never sending the message back to the peer it came from
+func (g *Graph) Relays(cfg *config.Config, id int, from int) []int {
+	candidates := make([]int, 0, len(g.Peers[id]))
+	for _, peer := range g.Peers[id] {
+		if peer != from {
+			candidates = append(candidates, peer)
+		}
+	}
+	fanout := cfg.MinGossipFanout + rand.Intn(cfg.MaxGossipFanout-cfg.MinGossipFanout+1)
+	if fanout >= len(candidates) {
+		return candidates
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	return candidates[:fanout]
+}
+
+// Gossip follows one message spreading hop by hop from its origin through the peer graph
+type Gossip struct {
+	Payload interface{}
+	Size    int
+	Origin  int
+	Start   float64
+	// Audience are the nodes the message is meant for, the other nodes only relay it
+	Audience   map[int]bool
+	ReceivedAt map[int]float64
+	Hops       map[int]int
+	Sent       int
+	Duplicates int
+	// Lost counts hops dropped after every retransmission was lost
+	Lost int
+}
+
+func NewGossip(payload interface{}, size int, origin int, start float64, audience map[int]bool) *Gossip {
+	return &Gossip{
+		Payload:    payload,
+		Size:       size,
+		Origin:     origin,
+		Start:      start,
+		Audience:   audience,
+		ReceivedAt: map[int]float64{origin: start},
+		Hops:       map[int]int{origin: 0},
+	}
+}
+
+// Hop is a gossip message in flight over one link
+type Hop struct {
+	Gossip *Gossip
+	From   int
+	To     int
+	Count  int
+}
+
+// Receive records the arrival of a hop at time t. It returns false for a duplicate, which the
+// receiver drops without relaying it.
+func (g *Gossip) Receive(h *Hop, t float64) bool {
+	if _, seen := g.ReceivedAt[h.To]; seen {
+		g.Duplicates++
+		return false
+	}
+	g.ReceivedAt[h.To] = t
+	g.Hops[h.To] = h.Count
+	return true
+}
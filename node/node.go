@@ -1,12 +1,19 @@
 package node
 
 import (
+	"math"
 	"math/rand"
+	"sharding/bandwidth"
 	"sharding/block"
 	"sharding/config"
 	"sharding/event"
+	"sharding/forkchoice"
+	"sharding/geo"
+	"sharding/loss"
 	"sharding/lottery"
+	"sharding/serving"
 	"sharding/utils"
+	"sort"
 	"sync"
 )
 
@@ -18,39 +25,114 @@ type Node struct {
 	Resources        int
 	Blockchain       map[int]map[int]*block.Block
 	BlockHeaderChain map[int]map[int]*block.BlockHeader
+	BlockTrees       map[int]*forkchoice.BlockTree
+	ForkChoice       forkchoice.ForkChoiceRule
+	ReorgDepths      map[int][]int
+	ERHeaders        map[int]map[int]*block.ExecutionReceiptHeader
+	VerifiedERs      map[int]bool
+	// With fraud proofs nodes cannot tell a malicious block apart until it is challenged
+	AcceptOptimistically bool
+	ChallengePeriod      int64
+	// Latest header of every shard learnt from the beacon chain
+	ShardTips map[int]*block.BlockHeader
+	// Neighbours in the peer graph, empty when propagation is estimated
+	Peers []int
+	// Region of the node on the geography map, -1 when geography is ignored
+	Region    int
+	Geography *geo.Map
+	// Access links shared by all transfers of the node, nil when transfers never contend
+	Links *bandwidth.Links
+	// Loss is nil when links never lose messages
+	Loss *loss.Model
+	// Time at which the node holds the state of a shard it rotated into
+	StateSyncedAt map[int]float64
+	heads         map[int]*block.Block
+	// Highest header ID received per shard, so the header tip is known without scanning the chain
+	headerTips map[int]int
+	// With header validation the header tip is the last header linked back to genesis
+	ValidateHeaders bool
+	linkedTips      map[int]int
+	// Serving is how the node answers block downloads, always honestly for honest nodes
+	Serving config.ServingStrategyType
+	// Every header received per shard keyed by hash, competing headers of one height included,
+	// so the header chain can switch to a competing branch
+	BlockHeaders map[int]map[int]*block.BlockHeader
+	// Headers received per shard keyed by the hash of their parent
+	headerChildren map[int]map[int][]*block.BlockHeader
 }
 
 func NewNode(cfg *config.Config, id int, isOperator bool) *Node {
 	n := &Node{
-		ID:               id,
-		IsHonest:         true,
-		IsOperator:       isOperator,
-		AssignedShard:    -1,
-		Resources:        1,
-		Blockchain:       make(map[int]map[int]*block.Block),
-		BlockHeaderChain: make(map[int]map[int]*block.BlockHeader),
+		ID:                   id,
+		IsHonest:             true,
+		IsOperator:           isOperator,
+		AssignedShard:        -1,
+		Resources:            drawStake(cfg, id),
+		Blockchain:           make(map[int]map[int]*block.Block),
+		BlockHeaderChain:     make(map[int]map[int]*block.BlockHeader),
+		BlockTrees:           make(map[int]*forkchoice.BlockTree),
+		ForkChoice:           forkchoice.NewForkChoiceRule(cfg.ForkChoice),
+		ReorgDepths:          make(map[int][]int),
+		ERHeaders:            make(map[int]map[int]*block.ExecutionReceiptHeader),
+		VerifiedERs:          make(map[int]bool),
+		AcceptOptimistically: cfg.EnableFraudProofs,
+		ChallengePeriod:      cfg.ChallengePeriod,
+		ShardTips:            make(map[int]*block.BlockHeader),
+		StateSyncedAt:        make(map[int]float64),
+		Region:               -1,
+		heads:                make(map[int]*block.Block),
+		headerTips:           make(map[int]int),
+		ValidateHeaders:      cfg.EnableHeaderSync,
+		linkedTips:           make(map[int]int),
+		Serving:              config.ServeHonestly,
+		BlockHeaders:         make(map[int]map[int]*block.BlockHeader),
+		headerChildren:       make(map[int]map[int][]*block.BlockHeader),
 	}
 
 	for i := 0; i < cfg.NumShards; i++ {
 		n.Blockchain[i] = make(map[int]*block.Block)
-		n.BlockHeaderChain[i] = make(map[int]*block.BlockHeader)
-		n.BlockHeaderChain[i][0] = &block.BlockHeader{ID: 0}
+		n.initHeaderChain(i)
+		n.BlockTrees[i] = forkchoice.NewBlockTree(i)
 	}
 
 	if rand.Float64() < cfg.MaliciousNodeRatio {
 		n.IsHonest = false
+		n.Serving = serving.Assign(cfg)
 	}
 
 	return n
 }
 
-func (n *Node) ParticipateInLottery(currentTime int64, numShards int) (bool, int) {
+// drawStake returns the stake of a new node under cfg.StakeDistribution, never below one unit
+func drawStake(cfg *config.Config, id int) int {
+	switch cfg.StakeDistribution {
+	case config.ParetoStake:
+		shape := cfg.StakeParetoShape
+		if shape <= 0 {
+			shape = config.StakeParetoShape
+		}
+		// Inverse transform sampling with the smallest stake of one unit as scale
+		stake := math.Floor(math.Pow(1-rand.Float64(), -1/shape))
+		limit := float64(math.MaxInt32)
+		if cfg.MaxStake > 0 {
+			limit = float64(cfg.MaxStake)
+		}
+		return max(1, int(math.Min(stake, limit)))
+	case config.ListStake:
+		if len(cfg.StakeList) > 0 {
+			return max(1, cfg.StakeList[id%len(cfg.StakeList)])
+		}
+	}
+	return 1
+}
+
+func (n *Node) ParticipateInLottery(currentTime int64, numShards int, winProbability float64) (bool, int) {
 	// if n.IsAssignedToShard() {
 	// 	fmt.Println("Called")
 	// 	return false, -1
 	// }
 
-	win := lottery.WinLottery(n.IsHonest, 1, currentTime, config.AttackStartTime, config.AttackEndTime) // Each LotteryEvent represents one attempt
+	win := lottery.WinLottery(1, winProbability) // Each LotteryEvent represents one attempt
 	if win {
 		// Assign a shard based on the winning ticket
 		newShardID := lottery.AssignShard(n.ID, currentTime, numShards)
@@ -63,16 +145,23 @@ func (n *Node) IsAssignedToShard() bool {
 	return n.AssignedShard != -1
 }
 
-func (n *Node) CreateBlock(previousBlockID int, currentTime int64) *block.Block {
-	blkID := previousBlockID + 1
-	blk := block.NewBlock(blkID, n.AssignedShard, n.ID, previousBlockID, currentTime)
+func (n *Node) CreateBlock(parent *block.Block, hash int, currentTime int64) *block.Block {
+	blkID := parent.ID + 1
+	blk := block.NewBlock(blkID, hash, parent.ShardID, n.ID, parent.Hash, currentTime)
+	blk.ProducerStake = n.Resources
 	blk.IsMalicious = !n.IsHonest // Mark if block is malicious
 	return blk
 }
 
-func (n *Node) CreateBlockHeader(previousBlockID int, currentTime int64) *block.BlockHeader {
-	blkID := previousBlockID + 1
-	blkHeader := block.NewBlockHeader(blkID, n.AssignedShard, n.ID, previousBlockID, currentTime)
+// CreateBlockHeader returns the header of blk, linked to the parent the block was built on
+func (n *Node) CreateBlockHeader(blk *block.Block) *block.BlockHeader {
+	previousID := blk.ID - 1
+	if parent := n.BlockTrees[blk.ShardID].Block(blk.PreviousHash); parent != nil {
+		previousID = parent.ID
+	}
+	blkHeader := block.NewBlockHeader(blk.ID, blk.ShardID, n.ID, previousID, blk.Timestamp)
+	blkHeader.Hash = blk.Hash
+	blkHeader.PreviousHash = blk.PreviousHash
 	return blkHeader
 }
 
@@ -81,9 +170,14 @@ func (n *Node) BroadcastBlock(cfg *config.Config, blk *block.Block, peers []*Nod
 	delay := 0.0
 	for _, peerNode := range peers {
 		if peerNode.ID != n.ID {
-			delay += utils.SimulateNetworkBlockDelay(cfg, len(peers))
+			peerDelay := utils.SimulateNetworkBlockDelay(cfg, len(peers))
+			if n.Links != nil {
+				// The producer pushes the block to every peer over its own upload link
+				peerDelay += n.Links.Reserve(n.ID, peerNode.ID, blk.ShardID, cfg.BlockSize, float64(currentTime))
+			}
+			delay += peerDelay
 			e := &event.Event{
-				Timestamp: float64(currentTime),
+				Timestamp: float64(currentTime) + peerDelay/1000.0,
 				Type:      event.MessageEvent,
 				NodeID:    peerNode.ID,
 				Data:      blk,
@@ -94,15 +188,18 @@ func (n *Node) BroadcastBlock(cfg *config.Config, blk *block.Block, peers []*Nod
 	return events, delay
 }
 
+// BroadcastBlockHeader sends a block header to every peer. Each peer gets its own delay and
+// only adds the header to its chain when the event is delivered.
 func (n *Node) BroadcastBlockHeader(cfg *config.Config, blk *block.BlockHeader, peers []*Node, currentTime int64) ([]*event.Event, float64) {
 	events := make([]*event.Event, 0)
 	delay := 0.0
 	for _, peerNode := range peers {
 		if peerNode.ID != n.ID {
-			peerNode.HandleBlockHeader(blk)
-			delay += utils.SimulateNetworkBlockHeaderDelay(cfg)
+			// Peers only learn the header once its message is delivered
+			peerDelay := utils.SimulateNetworkBlockHeaderDelay(cfg)
+			delay += peerDelay
 			e := &event.Event{
-				Timestamp: float64(currentTime) + delay,
+				Timestamp: float64(currentTime) + peerDelay/1000.0,
 				Type:      event.MessageEvent,
 				NodeID:    peerNode.ID,
 				Data:      blk,
@@ -113,6 +210,82 @@ func (n *Node) BroadcastBlockHeader(cfg *config.Config, blk *block.BlockHeader,
 	return events, delay
 }
 
+// BroadcastERHeader gossips an execution receipt header to the whole network.
+// Every peer gets its own delay, so the events carry individual arrival times.
+func (n *Node) BroadcastERHeader(cfg *config.Config, erHeader *block.ExecutionReceiptHeader, peers []*Node, currentTime int64) []*event.Event {
+	events := make([]*event.Event, 0, len(peers))
+	for _, peerNode := range peers {
+		if peerNode.ID != n.ID {
+			e := &event.Event{
+				Timestamp: float64(currentTime) + utils.SimulateNetworkERHeaderDelay(cfg)/1000.0,
+				Type:      event.MessageEvent,
+				NodeID:    peerNode.ID,
+				Data:      erHeader,
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// SendERBody sends the execution receipt body directly to each verifier of the shard
+func (n *Node) SendERBody(cfg *config.Config, erBody *block.ExecutionReceiptBody, verifiers []*Node, currentTime int64) []*event.Event {
+	events := make([]*event.Event, 0, len(verifiers))
+	for _, verifier := range verifiers {
+		if verifier.ID != n.ID {
+			delay := utils.SimulateNetworkERBodyDelay(cfg)
+			if n.Geography != nil {
+				delay = n.Geography.Delay(cfg, n.ID, verifier.ID, cfg.ERBodySize)
+			}
+			if n.Links != nil {
+				delay += n.Links.Reserve(n.ID, verifier.ID, erBody.Header.ShardID, cfg.ERBodySize, float64(currentTime))
+			}
+			e := &event.Event{
+				Timestamp: float64(currentTime) + delay/1000.0,
+				Type:      event.MessageEvent,
+				NodeID:    verifier.ID,
+				Data:      erBody,
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// BroadcastBeaconBlock sends a beacon block to the whole network, every peer with its own delay
+func (n *Node) BroadcastBeaconBlock(cfg *config.Config, beaconBlock *block.BeaconBlock, peers []*Node, currentTime int64) []*event.Event {
+	events := make([]*event.Event, 0, len(peers))
+	for _, peerNode := range peers {
+		if peerNode.ID != n.ID {
+			e := &event.Event{
+				Timestamp: float64(currentTime) + utils.SimulateNetworkBeaconBlockDelay(cfg, beaconBlock.Size(cfg.BlockHeaderSize))/1000.0,
+				Type:      event.MessageEvent,
+				NodeID:    peerNode.ID,
+				Data:      beaconBlock,
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// BroadcastFraudProof gossips a fraud proof to the whole network, starting when the proof was raised
+func (n *Node) BroadcastFraudProof(cfg *config.Config, proof *block.FraudProof, peers []*Node) []*event.Event {
+	events := make([]*event.Event, 0, len(peers))
+	for _, peerNode := range peers {
+		if peerNode.ID != n.ID {
+			e := &event.Event{
+				Timestamp: proof.Timestamp + utils.SimulateNetworkFraudProofDelay(cfg)/1000.0,
+				Type:      event.MessageEvent,
+				NodeID:    peerNode.ID,
+				Data:      proof,
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
 func (n *Node) ProcessMessage(e *event.Event) {
 	switch msg := e.Data.(type) {
 	case *block.Block:
@@ -120,6 +293,14 @@ func (n *Node) ProcessMessage(e *event.Event) {
 
 	case *block.BlockHeader:
 		n.HandleBlockHeader(msg)
+	case *block.ExecutionReceiptHeader:
+		n.HandleERHeader(msg)
+	case *block.ExecutionReceiptBody:
+		n.HandleERBody(msg)
+	case *block.FraudProof:
+		n.HandleFraudProof(msg)
+	case *block.BeaconBlock:
+		n.HandleBeaconBlock(msg)
 	default:
 		// Handle other message types if necessary
 	}
@@ -129,46 +310,285 @@ func (n *Node) HandleBlock(blk *block.Block) {
 	if _, exists := n.Blockchain[blk.ShardID]; !exists {
 		n.Blockchain[blk.ShardID] = make(map[int]*block.Block)
 	}
+	if !n.accepts(blk) {
+		return
+	}
 	if _, exists := n.Blockchain[blk.ShardID][blk.ID]; !exists {
-		if !blk.IsMalicious {
-			n.Blockchain[blk.ShardID][blk.ID] = blk
+		n.Blockchain[blk.ShardID][blk.ID] = blk
+	}
+	n.addToBlockTree(blk, false)
+}
+
+// accepts reports whether the node takes a block in. Without fraud proofs malicious blocks are
+// recognised on sight, with them a block is only refused once it is known to be invalid.
+func (n *Node) accepts(blk *block.Block) bool {
+	if n.AcceptOptimistically {
+		tree := n.BlockTree(blk.ShardID)
+		return !tree.IsInvalid(blk.Hash) && !tree.IsInvalid(blk.PreviousHash)
+	}
+	// Withholding producers and their colluders keep the bodies they hold back from the others
+	return !blk.IsMalicious || (blk.Withheld && !n.IsHonest)
+}
+
+// HandleFraudProof reverts the proven block and everything built on it, as long as the
+// challenge was raised within the challenge period of the block
+func (n *Node) HandleFraudProof(proof *block.FraudProof) {
+	if proof.Timestamp-float64(proof.BlockTimestamp) > float64(n.ChallengePeriod) {
+		return
+	}
+	tree := n.BlockTree(proof.ShardID)
+	reverted := tree.Invalidate(proof.BlockHash)
+	if len(reverted) == 0 {
+		return
+	}
+	for _, blk := range reverted {
+		if stored, exists := n.Blockchain[proof.ShardID][blk.ID]; exists && stored.Hash == blk.Hash {
+			delete(n.Blockchain[proof.ShardID], blk.ID)
+		}
+	}
+
+	head := n.ForkChoice.Head(tree)
+	n.heads[proof.ShardID] = head
+	for b := head; b != nil && b.ID > 0; b = tree.Parent(b) {
+		if stored, exists := n.Blockchain[proof.ShardID][b.ID]; exists && stored.Hash == b.Hash {
+			break
 		}
+		n.Blockchain[proof.ShardID][b.ID] = b
+	}
+}
+
+// BlockTree returns the node's block tree for a shard
+func (n *Node) BlockTree(shardID int) *forkchoice.BlockTree {
+	if _, exists := n.BlockTrees[shardID]; !exists {
+		n.BlockTrees[shardID] = forkchoice.NewBlockTree(shardID)
 	}
+	return n.BlockTrees[shardID]
 }
 
+// HeadBlock returns the head of the node's canonical chain for a shard
+func (n *Node) HeadBlock(shardID int) *block.Block {
+	if head, exists := n.heads[shardID]; exists {
+		return head
+	}
+	n.heads[shardID] = n.ForkChoice.Head(n.BlockTree(shardID))
+	return n.heads[shardID]
+}
+
+// addToBlockTree inserts a block into the node's tree and follows the fork-choice head.
+// A head switch to another branch is recorded as a reorg and the by-height Blockchain view
+// is rewritten along the new canonical chain.
+func (n *Node) addToBlockTree(blk *block.Block, synced bool) {
+	tree := n.BlockTree(blk.ShardID)
+	oldHead := n.HeadBlock(blk.ShardID)
+
+	added := false
+	if synced {
+		added = tree.AddSyncedBlock(blk)
+	} else {
+		added = tree.AddBlock(blk)
+	}
+	if !added {
+		return
+	}
+
+	newHead := n.ForkChoice.Head(tree)
+	n.heads[blk.ShardID] = newHead
+	if newHead.Hash == oldHead.Hash {
+		return
+	}
+	ancestor := tree.CommonAncestor(oldHead, newHead)
+	skippedGap := false
+	for b := newHead; b != nil && b.Hash != ancestor.Hash; b = tree.Parent(b) {
+		n.Blockchain[blk.ShardID][b.ID] = b
+		skippedGap = skippedGap || tree.IsAnchored(b)
+	}
+	// Jumping onto a synced range that hangs off genesis is catching up, not a reorg
+	if ancestor.Hash != oldHead.Hash && !skippedGap {
+		n.ReorgDepths[blk.ShardID] = append(n.ReorgDepths[blk.ShardID], oldHead.ID-ancestor.ID)
+	}
+}
+
+// HandleBlockHeader stores a header under its hash and moves the node's header chain along. The
+// chain holds one header per height: the linked branch up to the linked tip, and above it the
+// first header received at each height until a linked one takes its place.
 func (n *Node) HandleBlockHeader(blk *block.BlockHeader) {
-	if _, exists := n.BlockHeaderChain[blk.ShardID]; !exists {
-		n.BlockHeaderChain[blk.ShardID] = make(map[int]*block.BlockHeader)
+	shardID := blk.ShardID
+	if _, exists := n.BlockHeaderChain[shardID]; !exists {
+		n.initHeaderChain(shardID)
 	}
-	if _, exists := n.BlockHeaderChain[blk.ShardID][blk.ID]; !exists {
-		n.BlockHeaderChain[blk.ShardID][blk.ID] = blk
+	if _, known := n.BlockHeaders[shardID][blk.Hash]; known {
+		return
+	}
+	n.BlockHeaders[shardID][blk.Hash] = blk
+	n.headerChildren[shardID][blk.PreviousHash] = append(n.headerChildren[shardID][blk.PreviousHash], blk)
+	n.headerTips[shardID] = max(n.headerTips[shardID], blk.ID)
+
+	chain := n.BlockHeaderChain[shardID]
+	if _, exists := chain[blk.ID]; !exists {
+		chain[blk.ID] = blk
+	}
+
+	// Headers only link once every predecessor down to genesis is known, so descendants received
+	// before the header link along with it, the first one received first. A branch outgrowing the
+	// linked chain moves the chain over to it.
+	head := blk
+	for child := n.firstChild(shardID, head); child != nil; child = n.firstChild(shardID, head) {
+		head = child
+	}
+	if head.ID > n.linkedTips[shardID] && n.switchBranch(shardID, head) {
+		n.linkedTips[shardID] = head.ID
+	}
+}
+
+// initHeaderChain starts the header chain of a shard at its genesis header, which has hash 0
+func (n *Node) initHeaderChain(shardID int) {
+	genesis := &block.BlockHeader{ID: 0, ShardID: shardID}
+	n.BlockHeaderChain[shardID] = map[int]*block.BlockHeader{0: genesis}
+	n.BlockHeaders[shardID] = map[int]*block.BlockHeader{0: genesis}
+	n.headerChildren[shardID] = make(map[int][]*block.BlockHeader)
+}
+
+// firstChild returns the first header received that builds on header, nil when there is none
+func (n *Node) firstChild(shardID int, header *block.BlockHeader) *block.BlockHeader {
+	for _, child := range n.headerChildren[shardID][header.Hash] {
+		if child.PreviousID == header.ID {
+			return child
+		}
+	}
+	return nil
+}
+
+// switchBranch replaces the headers of a shard's header chain with header and its ancestors, down
+// to the first one on the linked chain. It returns false when one of them was never received.
+func (n *Node) switchBranch(shardID int, header *block.BlockHeader) bool {
+	chain := n.BlockHeaderChain[shardID]
+	branch := make([]*block.BlockHeader, 0)
+	for h := header; ; {
+		if current, exists := chain[h.ID]; exists && current.Hash == h.Hash && h.ID <= n.linkedTips[shardID] {
+			break
+		}
+		branch = append(branch, h)
+		parent, known := n.BlockHeaders[shardID][h.PreviousHash]
+		if !known || parent.ID != h.PreviousID {
+			return false
+		}
+		h = parent
+	}
+	for _, h := range branch {
+		chain[h.ID] = h
+	}
+	return true
+}
+
+// HeaderGap returns the range of headers of a shard missing between the linked header chain and
+// the highest header received, and false when the chain has no gap
+func (n *Node) HeaderGap(shardID int) (int, int, bool) {
+	linked, highest := n.linkedTips[shardID], n.headerTips[shardID]
+	if highest <= linked+1 {
+		return 0, 0, false
 	}
+	return linked + 1, highest - 1, true
 }
 
+// HandleBeaconBlock follows the beacon chain: every crosslinked header joins the header chain
+// and moves the node's view of the shard tip forward
+func (n *Node) HandleBeaconBlock(beaconBlock *block.BeaconBlock) {
+	for _, header := range beaconBlock.Crosslinks {
+		n.HandleBlockHeader(header)
+		if tip, exists := n.ShardTips[header.ShardID]; !exists || header.ID > tip.ID {
+			n.ShardTips[header.ShardID] = header
+		}
+	}
+}
+
+func (n *Node) HandleERHeader(erHeader *block.ExecutionReceiptHeader) {
+	if _, exists := n.ERHeaders[erHeader.ShardID]; !exists {
+		n.ERHeaders[erHeader.ShardID] = make(map[int]*block.ExecutionReceiptHeader)
+	}
+	if _, exists := n.ERHeaders[erHeader.ShardID][erHeader.BlockHash]; !exists {
+		n.ERHeaders[erHeader.ShardID][erHeader.BlockHash] = erHeader
+	}
+}
+
+// HandleERBody verifies an execution receipt. Only honest nodes still assigned to the
+// receipt's shard verify it, a node that rotated away or is malicious drops it.
+func (n *Node) HandleERBody(erBody *block.ExecutionReceiptBody) {
+	n.HandleERHeader(erBody.Header)
+	if n.IsHonest && n.AssignedShard == erBody.Header.ShardID {
+		n.VerifiedERs[erBody.Header.BlockHash] = true
+	}
+}
+
+// IsStateSynced reports whether the node holds the state of a shard at time now. Nodes that never
+// rotated into the shard, like operators, have held its state since genesis.
+func (n *Node) IsStateSynced(shardID int, now float64) bool {
+	syncedAt, exists := n.StateSyncedAt[shardID]
+	return !exists || syncedAt <= now
+}
+
+// LatestBlockHeaderID returns the tip of the node's header chain of a shard: the highest header
+// received, or with header validation the highest one linked back to genesis
 func (n *Node) LatestBlockHeaderID(shardID int) int {
 	if _, exists := n.BlockHeaderChain[shardID]; !exists {
-		n.BlockHeaderChain[shardID] = make(map[int]*block.BlockHeader)
-		n.BlockHeaderChain[shardID][0] = &block.BlockHeader{ID: 0}
+		n.initHeaderChain(shardID)
 		return 0
 	}
+	if n.ValidateHeaders {
+		return n.linkedTips[shardID]
+	}
+	return n.headerTips[shardID]
+}
 
-	latestID := 0
-	for id := range n.BlockHeaderChain[shardID] {
-		if id > latestID {
-			latestID = id
+// downloadDelay returns the time in milliseconds a block download from peer starting at start
+// takes, from the regions of both nodes when they are placed on a geography map. It returns false
+// when lost messages make the download time out, so the node moves on to the next peer.
+func (n *Node) downloadDelay(cfg *config.Config, peer *Node, shardID int, start float64) (float64, bool) {
+	delay := utils.SimulateNetworkBlockDownloadDelay(cfg)
+	if n.Geography != nil {
+		delay = n.Geography.Delay(cfg, peer.ID, n.ID, cfg.BlockSize)
+	}
+	if n.Loss != nil {
+		extra, delivered := n.Loss.SendDownload(peer.ID, n.ID)
+		if !delivered || delay+extra > float64(cfg.TimeOut) {
+			n.Loss.RecordFallback()
+			return 0, false
 		}
+		delay += extra
 	}
-	return latestID
+	if n.Links != nil {
+		delay += n.Links.Reserve(peer.ID, n.ID, shardID, cfg.BlockSize, start)
+	}
+	return delay, true
+}
+
+// withholds reports whether the node refuses to serve the body of blk to requester
+func (n *Node) withholds(cfg *config.Config, blk *block.Block, requester *Node) bool {
+	if !blk.Withheld || n.IsHonest {
+		return false
+	}
+	return cfg.BlockWithholding != config.WithholdForColluders || requester.IsHonest
 }
 
-func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID int, currentTime int64) float64 {
+// DownloadReport describes the blocks a download could not get because their holders withheld
+// them. Every refusal cost the node a timeout, summed up in WastedTime in milliseconds. Attempts
+// holds every block request a peer answered, honestly or not.
+type DownloadReport struct {
+	Missing    []int
+	Refusals   int
+	WastedTime float64
+	Attempts   []serving.Outcome
+}
+
+func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID int, currentTime int64) (float64, DownloadReport) {
 	latestID := n.LatestBlockHeaderID(shardID)
 	startID := max(0, latestID-cfg.NumBlocksToDownload)
 	counter := 0
 	type downloadResult struct {
-		blockID int
-		block   *block.Block
-		delay   float64
+		blockID  int
+		block    *block.Block
+		delay    float64
+		refusals int
+		attempts []serving.Outcome
 	}
 
 	// Split peers into operators and regular nodes
@@ -185,7 +605,9 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 	resultChan := make(chan downloadResult, cfg.MaxP2PConnections)
 	var mu sync.Mutex
 	downloadedBlocks := make(map[int]bool)
+	syncedBlocks := make([]*block.Block, 0)
 	totalDelay := 0.0
+	report := DownloadReport{Missing: make([]int, 0), Attempts: make([]serving.Outcome, 0)}
 
 	// Process blocks in batches of size MaxP2PConnections
 	for batchStart := latestID; batchStart > startID; batchStart -= cfg.MaxP2PConnections {
@@ -193,6 +615,8 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 		batchEnd := max(startID, batchStart-cfg.MaxP2PConnections)
 		activeDLs := 0
 		batchMaxDelay := 0.0
+		// Every batch starts once the previous one is complete
+		batchStartTime := float64(currentTime) + totalDelay/1000.0
 
 		// Start downloads for this batch
 		for blockID := batchStart; blockID > batchEnd; blockID-- {
@@ -203,6 +627,8 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 			activeDLs++
 			go func(bid int) {
 				result := downloadResult{blockID: bid, delay: -1}
+				// Time spent waiting on peers the download timed out with
+				timedOut := 0.0
 
 				// Try operators first
 				for _, peer := range operators {
@@ -215,12 +641,25 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 					mu.Unlock()
 
 					if block, exists := peer.Blockchain[shardID][bid]; exists {
-						delay := utils.SimulateNetworkBlockDownloadDelay(cfg)
-						if !peer.IsHonest {
-							delay += float64(cfg.TimeOut)
+						if peer.withholds(cfg, block, n) {
+							// The peer announced the block but never sends it
+							timedOut += float64(cfg.TimeOut)
+							result.refusals++
+							continue
+						}
+						delay, ok := n.downloadDelay(cfg, peer, shardID, batchStartTime+timedOut/1000.0)
+						if !ok {
+							timedOut += float64(cfg.TimeOut)
+							continue
+						}
+						outcome := serving.Serve(cfg, peer.Serving, delay)
+						result.attempts = append(result.attempts, outcome)
+						if !outcome.Delivered {
+							timedOut += outcome.Delay
+							continue
 						}
 						result.block = block
-						result.delay = delay
+						result.delay = timedOut + outcome.Delay
 						break
 					}
 				}
@@ -237,16 +676,33 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 						mu.Unlock()
 
 						if block, exists := peer.Blockchain[shardID][bid]; exists {
-							delay := utils.SimulateNetworkBlockDownloadDelay(cfg)
-							if !peer.IsHonest {
-								delay += float64(cfg.TimeOut)
+							if peer.withholds(cfg, block, n) {
+								// The peer announced the block but never sends it
+								timedOut += float64(cfg.TimeOut)
+								result.refusals++
+								continue
+							}
+							delay, ok := n.downloadDelay(cfg, peer, shardID, batchStartTime+timedOut/1000.0)
+							if !ok {
+								timedOut += float64(cfg.TimeOut)
+								continue
+							}
+							outcome := serving.Serve(cfg, peer.Serving, delay)
+							result.attempts = append(result.attempts, outcome)
+							if !outcome.Delivered {
+								timedOut += outcome.Delay
+								continue
 							}
 							result.block = block
-							result.delay = delay
+							result.delay = timedOut + outcome.Delay
 							break
 						}
 					}
 				}
+				if result.block == nil && timedOut > 0 {
+					// Every peer timed out, the batch still waited for them
+					result.delay = timedOut
+				}
 				resultChan <- result
 			}(blockID)
 		}
@@ -254,11 +710,22 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 		// Wait for all downloads in this batch to complete
 		for i := 0; i < activeDLs; i++ {
 			result := <-resultChan
+			report.Attempts = append(report.Attempts, result.attempts...)
+			if result.refusals > 0 {
+				report.Refusals += result.refusals
+				report.WastedTime += float64(result.refusals) * float64(cfg.TimeOut)
+				if result.block == nil {
+					report.Missing = append(report.Missing, result.blockID)
+				}
+			}
 			if result.delay > 0 {
 				mu.Lock()
-				downloadedBlocks[result.blockID] = true
-				if !result.block.IsMalicious {
+				if result.block != nil {
+					downloadedBlocks[result.blockID] = true
+				}
+				if result.block != nil && n.accepts(result.block) {
 					n.Blockchain[shardID][result.blockID] = result.block
+					syncedBlocks = append(syncedBlocks, result.block)
 				}
 				batchMaxDelay = max(batchMaxDelay, result.delay)
 				mu.Unlock()
@@ -267,5 +734,13 @@ func (n *Node) DownloadLatestKBlocks(cfg *config.Config, peers []*Node, shardID
 
 		totalDelay += batchMaxDelay
 	}
-	return totalDelay
+
+	// Batches run from the newest block down, the block tree needs parents first
+	sort.Slice(syncedBlocks, func(i, j int) bool {
+		return syncedBlocks[i].ID < syncedBlocks[j].ID
+	})
+	for _, blk := range syncedBlocks {
+		n.addToBlockTree(blk, true)
+	}
+	return totalDelay, report
 }
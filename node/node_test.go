@@ -0,0 +1,151 @@
+// node/node_test.go
+
+package node
+
+import (
+	"sharding/block"
+	"sharding/config"
+	"testing"
+)
+
+func TestDrawStakeFromList(t *testing.T) {
+	cfg := &config.Config{StakeDistribution: config.ListStake, StakeList: []int{5, 0, 2}}
+	// Node IDs cycle through the list and a stake of 0 is raised to one unit
+	want := []int{5, 1, 2, 5, 1}
+	for id, stake := range want {
+		if got := drawStake(cfg, id); got != stake {
+			t.Errorf("node %d: stake %d, want %d", id, got, stake)
+		}
+	}
+
+	cfg.StakeList = nil
+	if got := drawStake(cfg, 3); got != 1 {
+		t.Errorf("empty list: stake %d, want 1", got)
+	}
+}
+
+func TestDrawStakeUniform(t *testing.T) {
+	cfg := &config.Config{StakeDistribution: config.UniformStake, StakeList: []int{9}}
+	if got := drawStake(cfg, 0); got != 1 {
+		t.Errorf("uniform stake %d, want 1", got)
+	}
+}
+
+func TestDrawStakePareto(t *testing.T) {
+	cfg := &config.Config{StakeDistribution: config.ParetoStake, StakeParetoShape: 1.1, MaxStake: 20}
+	above := 0
+	for id := 0; id < 5_000; id++ {
+		stake := drawStake(cfg, id)
+		if stake < 1 || stake > cfg.MaxStake {
+			t.Fatalf("node %d: stake %d outside [1, %d]", id, stake, cfg.MaxStake)
+		}
+		if stake > 1 {
+			above++
+		}
+	}
+	// P(stake >= 2) = 2^-1.1, close to one half
+	if above < 2_000 || above > 2_700 {
+		t.Errorf("%d of 5000 nodes drew more than one unit, want about 2330", above)
+	}
+}
+
+// header returns a header of shard 0 at height id with the given hash, built on parent
+func header(id, hash int, parent *block.BlockHeader) *block.BlockHeader {
+	return &block.BlockHeader{ID: id, Hash: hash, PreviousID: parent.ID, PreviousHash: parent.Hash}
+}
+
+func newValidatingNode() *Node {
+	return NewNode(&config.Config{NumShards: 1, EnableHeaderSync: true}, 0, false)
+}
+
+func TestCompetingHeadersAreStoredByHash(t *testing.T) {
+	n := newValidatingNode()
+	genesis := n.BlockHeaderChain[0][0]
+	first := header(1, 1, genesis)
+	second := header(1, 2, genesis)
+	n.HandleBlockHeader(first)
+	n.HandleBlockHeader(second)
+
+	if n.BlockHeaders[0][1] != first || n.BlockHeaders[0][2] != second {
+		t.Fatal("a competing header of the same height was dropped")
+	}
+	if n.BlockHeaderChain[0][1] != first || n.LatestBlockHeaderID(0) != 1 {
+		t.Fatal("header chain did not keep the first header of its height")
+	}
+
+	// A child of the second header makes its branch the longer one
+	child := header(2, 3, second)
+	n.HandleBlockHeader(child)
+	if n.BlockHeaderChain[0][1] != second || n.BlockHeaderChain[0][2] != child || n.LatestBlockHeaderID(0) != 2 {
+		t.Errorf("header chain did not switch to the branch of header %d", second.Hash)
+	}
+}
+
+func TestHeadersLinkOnceTheirParentArrives(t *testing.T) {
+	n := newValidatingNode()
+	genesis := n.BlockHeaderChain[0][0]
+	h1 := header(1, 1, genesis)
+	h2 := header(2, 2, h1)
+	h3 := header(3, 3, h2)
+	n.HandleBlockHeader(h3)
+	n.HandleBlockHeader(h2)
+
+	if from, to, missing := n.HeaderGap(0); !missing || from != 1 || to != 2 || n.LatestBlockHeaderID(0) != 0 {
+		t.Fatalf("gap %d-%d (%v) at tip %d, want 1-2 at tip 0", from, to, missing, n.LatestBlockHeaderID(0))
+	}
+	n.HandleBlockHeader(h1)
+	if _, _, missing := n.HeaderGap(0); missing || n.LatestBlockHeaderID(0) != 3 {
+		t.Errorf("tip %d after the gap filled, want 3", n.LatestBlockHeaderID(0))
+	}
+}
+
+func TestBranchLinksWhenItsMissingHeaderArrives(t *testing.T) {
+	n := newValidatingNode()
+	genesis := n.BlockHeaderChain[0][0]
+	linked := header(1, 1, genesis)
+	n.HandleBlockHeader(linked)
+
+	// The competing branch is one header longer but its first header comes last
+	fork := header(1, 2, genesis)
+	tip := header(2, 3, fork)
+	n.HandleBlockHeader(tip)
+	if n.LatestBlockHeaderID(0) != 1 || n.BlockHeaderChain[0][1] != linked {
+		t.Fatal("header chain switched to a branch it cannot link")
+	}
+	n.HandleBlockHeader(fork)
+	if n.LatestBlockHeaderID(0) != 2 || n.BlockHeaderChain[0][1] != fork || n.BlockHeaderChain[0][2] != tip {
+		t.Errorf("header chain did not follow the branch once it linked, tip %d", n.LatestBlockHeaderID(0))
+	}
+
+	// Receiving a header twice changes nothing
+	n.HandleBlockHeader(linked)
+	if n.BlockHeaderChain[0][1] != fork || len(n.BlockHeaders[0]) != 4 {
+		t.Errorf("duplicate header changed the chain or the %d stored headers", len(n.BlockHeaders[0]))
+	}
+}
+
+func TestWithholdingDecisions(t *testing.T) {
+	honest := &Node{ID: 1, IsHonest: true}
+	colluder := &Node{ID: 2}
+	producer := &Node{ID: 3}
+	withheld := &block.Block{IsMalicious: true, Withheld: true}
+	published := &block.Block{IsMalicious: true}
+
+	for mode, want := range map[config.WithholdingMode][2]bool{
+		config.WithholdFromAll:      {true, true},
+		config.WithholdForColluders: {true, false},
+	} {
+		cfg := &config.Config{BlockWithholding: mode}
+		if got := [2]bool{producer.withholds(cfg, withheld, honest), producer.withholds(cfg, withheld, colluder)}; got != want {
+			t.Errorf("%v: withholds from honest and colluding requesters %v, want %v", mode, got, want)
+		}
+		if producer.withholds(cfg, published, honest) || honest.withholds(cfg, withheld, honest) {
+			t.Errorf("%v: a published block or an honest holder withheld a body", mode)
+		}
+	}
+
+	// Only colluders take in the withheld bodies they were handed
+	if honest.accepts(withheld) || !colluder.accepts(withheld) || colluder.accepts(published) {
+		t.Error("malicious blocks accepted by the wrong nodes")
+	}
+}
@@ -0,0 +1,84 @@
+// partition/partition.go
+
+package partition
+
+import (
+	"math"
+	"math/rand"
+	"sharding/config"
+	"sort"
+)
+
+// Partition is a scheduled split of the network into an isolated side and the rest. While it is
+// active, messages between the two sides are dropped or held until it heals.
+type Partition struct {
+	config.Partition
+	Isolated map[int]bool
+	Active   bool
+	Healed   bool
+	// Messages that crossed the partition while it was active
+	Dropped int
+	Held    int
+	// HeaderSpread is, per shard, the highest minus the lowest latest header ID among nodes at heal time
+	HeaderSpread map[int]int
+	// ForkDepth is, per shard, the blocks from the common ancestor of both sides' heads to the higher head at heal time
+	ForkDepth map[int]int
+	// Seconds after healing until header chains and shard chains agree again, -1 while they still disagree
+	HeadersAgreeAfter float64
+	ChainsAgreeAfter  float64
+}
+
+func NewPartition(spec config.Partition) *Partition {
+	return &Partition{
+		Partition:         spec,
+		Isolated:          make(map[int]bool),
+		HeaderSpread:      make(map[int]int),
+		ForkDepth:         make(map[int]int),
+		HeadersAgreeAfter: -1,
+		ChainsAgreeAfter:  -1,
+	}
+}
+
+// Split picks the isolated side among ids when the partition starts. shards maps every node to
+// its assigned shard and regions to its region, nil when nodes have no region.
+func (p *Partition) Split(ids []int, shards map[int]int, regions map[int]int) {
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+
+	groups := make(map[int]bool)
+	for _, g := range p.Groups {
+		groups[g] = true
+	}
+	switch p.Partition.Split {
+	case config.SplitByShard:
+		for _, id := range sorted {
+			if shard, assigned := shards[id]; assigned && groups[shard] {
+				p.Isolated[id] = true
+			}
+		}
+	case config.SplitByRegion:
+		for _, id := range sorted {
+			if region, placed := regions[id]; placed && groups[region] {
+				p.Isolated[id] = true
+			}
+		}
+	case config.SplitByList:
+		for _, id := range p.Nodes {
+			p.Isolated[id] = true
+		}
+	case config.SplitByFraction:
+		rand.Shuffle(len(sorted), func(i, j int) {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		})
+		count := int(math.Round(p.Fraction * float64(len(sorted))))
+		for _, id := range sorted[:max(0, min(count, len(sorted)))] {
+			p.Isolated[id] = true
+		}
+	}
+}
+
+// Separates reports whether the active partition stands between nodes a and b
+func (p *Partition) Separates(a, b int) bool {
+	return p.Active && p.Isolated[a] != p.Isolated[b]
+}
@@ -0,0 +1,93 @@
+// partition/partition_test.go
+
+package partition
+
+import (
+	"sharding/config"
+	"sort"
+	"testing"
+)
+
+func isolated(p *Partition) []int {
+	ids := make([]int, 0, len(p.Isolated))
+	for id := range p.Isolated {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplit(t *testing.T) {
+	ids := []int{0, 1, 2, 3, 4, 5}
+	// Node 5 sits in no shard and no region
+	shards := map[int]int{0: 0, 1: 1, 2: 2, 3: 0, 4: 1}
+	regions := map[int]int{0: 3, 1: 3, 2: 1, 3: 2, 4: 1}
+
+	cases := map[string]struct {
+		spec config.Partition
+		want []int
+	}{
+		"shards":  {config.Partition{Split: config.SplitByShard, Groups: []int{0, 2}}, []int{0, 2, 3}},
+		"regions": {config.Partition{Split: config.SplitByRegion, Groups: []int{1}}, []int{2, 4}},
+		"list":    {config.Partition{Split: config.SplitByList, Nodes: []int{5, 1}}, []int{1, 5}},
+	}
+	for name, c := range cases {
+		p := NewPartition(c.spec)
+		p.Split(ids, shards, regions)
+		if got := isolated(p); !equal(got, c.want) {
+			t.Errorf("%s: isolated %v, want %v", name, got, c.want)
+		}
+	}
+
+	// Regions are ignored when nodes have none
+	p := NewPartition(config.Partition{Split: config.SplitByRegion, Groups: []int{1}})
+	p.Split(ids, shards, nil)
+	if len(p.Isolated) != 0 {
+		t.Errorf("isolated %v without regions", isolated(p))
+	}
+}
+
+func TestSplitByFraction(t *testing.T) {
+	ids := make([]int, 40)
+	for i := range ids {
+		ids[i] = i
+	}
+	for fraction, want := range map[float64]int{0: 0, 0.25: 10, 0.51: 20, 1: 40, 1.5: 40} {
+		p := NewPartition(config.Partition{Split: config.SplitByFraction, Fraction: fraction})
+		p.Split(ids, nil, nil)
+		if len(p.Isolated) != want {
+			t.Errorf("fraction %v isolated %d of 40 nodes, want %d", fraction, len(p.Isolated), want)
+		}
+	}
+}
+
+func TestSeparatesOnlyWhileActive(t *testing.T) {
+	p := NewPartition(config.Partition{Split: config.SplitByList, Nodes: []int{1, 2}})
+	p.Split([]int{1, 2, 3}, nil, nil)
+	if p.Separates(1, 3) {
+		t.Error("partition separates nodes before it starts")
+	}
+
+	p.Active = true
+	if !p.Separates(1, 3) || !p.Separates(3, 2) {
+		t.Error("active partition lets the isolated side reach the rest")
+	}
+	if p.Separates(1, 2) || p.Separates(3, 3) {
+		t.Error("active partition separates nodes on the same side")
+	}
+	if p.HeadersAgreeAfter != -1 || p.ChainsAgreeAfter != -1 {
+		t.Error("chains agree before the partition healed")
+	}
+}
@@ -0,0 +1,119 @@
+// producer/producer.go
+
+package producer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"sharding/config"
+	"sharding/node"
+	"sort"
+)
+
+// ProducerSelector picks the node that produces the next block of a shard.
+// winners are the nodes that won the lottery out of the shard and have not produced yet,
+// members are the regular nodes currently assigned to the shard and height is the ID of
+// the block about to be produced. It returns nil when no node is eligible.
+type ProducerSelector interface {
+	SelectProducer(shardID int, height int, winners []*node.Node, members []*node.Node) *node.Node
+}
+
+// NewProducerSelector returns the selector implementing the given rule
+func NewProducerSelector(rule config.ProducerSelectionRule) ProducerSelector {
+	switch rule {
+	case config.RoundRobin:
+		return &RoundRobinSelector{}
+	case config.StakeWeighted:
+		return &StakeWeightedSelector{}
+	case config.VRFLowestTicket:
+		return &VRFSelector{Seed: rand.Uint64()}
+	default:
+		return &LotteryWinnerSelector{}
+	}
+}
+
+// LotteryWinnerSelector only lets lottery winners produce, lowest node ID first
+type LotteryWinnerSelector struct{}
+
+func (s *LotteryWinnerSelector) SelectProducer(shardID int, height int, winners []*node.Node, members []*node.Node) *node.Node {
+	sorted := sortByID(winners)
+	if len(sorted) == 0 {
+		return nil
+	}
+	return sorted[0]
+}
+
+// RoundRobinSelector rotates through the current shard members ordered by node ID
+type RoundRobinSelector struct{}
+
+func (s *RoundRobinSelector) SelectProducer(shardID int, height int, winners []*node.Node, members []*node.Node) *node.Node {
+	sorted := sortByID(members)
+	if len(sorted) == 0 {
+		return nil
+	}
+	return sorted[height%len(sorted)]
+}
+
+// StakeWeightedSelector picks a shard member at random with probability proportional to its resources
+type StakeWeightedSelector struct{}
+
+func (s *StakeWeightedSelector) SelectProducer(shardID int, height int, winners []*node.Node, members []*node.Node) *node.Node {
+	sorted := sortByID(members)
+	totalStake := 0
+	for _, n := range sorted {
+		totalStake += n.Resources
+	}
+	if totalStake <= 0 {
+		return nil
+	}
+
+	target := rand.Intn(totalStake)
+	for _, n := range sorted {
+		target -= n.Resources
+		if target < 0 {
+			return n
+		}
+	}
+	return sorted[len(sorted)-1]
+}
+
+// VRFSelector gives every shard member a pseudo-random ticket per height and picks the lowest one.
+// The ticket is a hash of the seed, shard, height and node ID, so it is verifiable by every node.
+type VRFSelector struct {
+	Seed uint64
+}
+
+func (s *VRFSelector) SelectProducer(shardID int, height int, winners []*node.Node, members []*node.Node) *node.Node {
+	var producer *node.Node
+	lowestTicket := math.Inf(1)
+	for _, n := range sortByID(members) {
+		ticket := s.Ticket(n.ID, shardID, height)
+		if ticket < lowestTicket {
+			lowestTicket = ticket
+			producer = n
+		}
+	}
+	return producer
+}
+
+// Ticket returns the VRF output of a node for the given shard and height in [0, 1)
+func (s *VRFSelector) Ticket(nodeID int, shardID int, height int) float64 {
+	buf := make([]byte, 32)
+	binary.BigEndian.PutUint64(buf[0:], s.Seed)
+	binary.BigEndian.PutUint64(buf[8:], uint64(shardID))
+	binary.BigEndian.PutUint64(buf[16:], uint64(height))
+	binary.BigEndian.PutUint64(buf[24:], uint64(nodeID))
+	sum := sha256.Sum256(buf)
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / float64(1<<53)
+}
+
+func sortByID(nodes []*node.Node) []*node.Node {
+	sorted := make([]*node.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
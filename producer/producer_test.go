@@ -0,0 +1,80 @@
+// producer/producer_test.go
+
+package producer
+
+import (
+	"math"
+	"sharding/node"
+	"testing"
+)
+
+func nodes(stakes map[int]int) []*node.Node {
+	members := make([]*node.Node, 0, len(stakes))
+	for id, stake := range stakes {
+		members = append(members, &node.Node{ID: id, Resources: stake})
+	}
+	return members
+}
+
+func TestRoundRobinRotatesByNodeID(t *testing.T) {
+	members := nodes(map[int]int{7: 1, 3: 1, 5: 1})
+	s := &RoundRobinSelector{}
+	want := []int{3, 5, 7, 3, 5, 7}
+	for height, id := range want {
+		if got := s.SelectProducer(0, height, nil, members); got.ID != id {
+			t.Errorf("height %d: producer %d, want %d", height, got.ID, id)
+		}
+	}
+	if got := s.SelectProducer(0, 4, nil, nil); got != nil {
+		t.Errorf("empty shard: producer %d, want none", got.ID)
+	}
+}
+
+func TestLotteryWinnerOnlyTakesLowestWinner(t *testing.T) {
+	s := &LotteryWinnerSelector{}
+	winners := nodes(map[int]int{42: 1, 17: 1})
+	if got := s.SelectProducer(0, 1, winners, nodes(map[int]int{1: 1})); got.ID != 17 {
+		t.Errorf("producer %d, want the lowest winner 17", got.ID)
+	}
+	// Members that did not win the lottery never produce
+	if got := s.SelectProducer(0, 1, nil, nodes(map[int]int{1: 1})); got != nil {
+		t.Errorf("producer %d without winners, want none", got.ID)
+	}
+}
+
+func TestVRFPicksLowestTicket(t *testing.T) {
+	members := nodes(map[int]int{1: 1, 2: 1, 3: 1, 4: 1})
+	s := &VRFSelector{Seed: 99}
+	for height := 0; height < 20; height++ {
+		got := s.SelectProducer(1, height, nil, members)
+		for _, n := range members {
+			if s.Ticket(n.ID, 1, height) < s.Ticket(got.ID, 1, height) {
+				t.Fatalf("height %d: node %d holds a lower ticket than producer %d", height, n.ID, got.ID)
+			}
+		}
+		// Any node holding the seed can verify the choice
+		if again := (&VRFSelector{Seed: 99}).SelectProducer(1, height, nil, members); again.ID != got.ID {
+			t.Errorf("height %d: second selector picked %d, want %d", height, again.ID, got.ID)
+		}
+	}
+}
+
+func TestStakeWeightedFollowsStake(t *testing.T) {
+	members := nodes(map[int]int{1: 1, 2: 3})
+	s := &StakeWeightedSelector{}
+	const draws = 20_000
+	heavy := 0
+	for i := 0; i < draws; i++ {
+		if s.SelectProducer(0, i, nil, members).ID == 2 {
+			heavy++
+		}
+	}
+	// Node 2 holds three quarters of the stake, 0.02 is more than six standard deviations
+	if share := float64(heavy) / draws; math.Abs(share-0.75) > 0.02 {
+		t.Errorf("node with 3/4 of the stake produced %.3f of the blocks", share)
+	}
+
+	if got := s.SelectProducer(0, 0, nil, nodes(map[int]int{1: 0})); got != nil {
+		t.Errorf("producer %d without stake, want none", got.ID)
+	}
+}
@@ -0,0 +1,82 @@
+// security/security.go
+
+package security
+
+import (
+	"math"
+)
+
+// Bound is the closed-form probability that a shard of Size members drawn at random has a
+// malicious share above Threshold
+type Bound struct {
+	Size      int
+	Threshold float64
+	// Sampling without replacement from Population nodes of which Malicious are malicious
+	Hypergeometric float64
+	// Sampling every member independently with the malicious ratio, the large population limit
+	Binomial float64
+}
+
+// NewBound computes both tails for a shard of the given size. A size of 0 or less has no members
+// to compromise.
+func NewBound(population, malicious, size int, ratio, threshold float64) Bound {
+	bound := Bound{Size: size, Threshold: threshold}
+	if size <= 0 {
+		return bound
+	}
+	bound.Hypergeometric = HypergeometricTail(population, malicious, size, threshold)
+	bound.Binomial = BinomialTail(size, ratio, threshold)
+	return bound
+}
+
+// AnyShard returns the probability that at least one of shards independent shards with
+// per-shard probability p is compromised
+func AnyShard(p float64, shards int) float64 {
+	// 1 - (1-p)^shards, kept accurate for the tiny tails of large shards
+	return -math.Expm1(float64(shards) * math.Log1p(-p))
+}
+
+// minMalicious is the fewest malicious members that put a shard of size n above the threshold
+func minMalicious(n int, threshold float64) int {
+	return int(math.Floor(threshold*float64(n))) + 1
+}
+
+// HypergeometricTail returns P(X > threshold * n) for X malicious members among n drawn without
+// replacement from population nodes, malicious of them malicious
+func HypergeometricTail(population, malicious, n int, threshold float64) float64 {
+	n = min(n, population)
+	if n <= 0 {
+		return 0
+	}
+	tail := 0.0
+	for k := max(minMalicious(n, threshold), n-(population-malicious)); k <= min(n, malicious); k++ {
+		tail += math.Exp(logChoose(malicious, k) + logChoose(population-malicious, n-k) - logChoose(population, n))
+	}
+	return math.Min(tail, 1)
+}
+
+// BinomialTail returns P(X > threshold * n) for X malicious members among n, each malicious with
+// probability p
+func BinomialTail(n int, p, threshold float64) float64 {
+	if n <= 0 || p <= 0 {
+		return 0
+	}
+	if p >= 1 {
+		return 1
+	}
+	tail := 0.0
+	for k := minMalicious(n, threshold); k <= n; k++ {
+		tail += math.Exp(logChoose(n, k) + float64(k)*math.Log(p) + float64(n-k)*math.Log(1-p))
+	}
+	return math.Min(tail, 1)
+}
+
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,92 @@
+// security/security_test.go
+
+package security
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestTailsOfSmallShards(t *testing.T) {
+	// Two members drawn from 10 nodes, 5 of them malicious
+	if p := HypergeometricTail(10, 5, 2, 0.5); !near(p, 10.0/45.0, 1e-12) {
+		t.Errorf("both members malicious with probability %v, want C(5,2)/C(10,2)", p)
+	}
+	if p := HypergeometricTail(10, 5, 2, 0); !near(p, 35.0/45.0, 1e-12) {
+		t.Errorf("some member malicious with probability %v, want 1 - C(5,2)/C(10,2)", p)
+	}
+	// Majority of three fair coins, and more than a third of four
+	if p := BinomialTail(3, 0.5, 0.5); !near(p, 0.5, 1e-12) {
+		t.Errorf("binomial majority of three %v, want 0.5", p)
+	}
+	if p := BinomialTail(4, 0.5, 1.0/3.0); !near(p, 11.0/16.0, 1e-12) {
+		t.Errorf("binomial tail above a third of four %v, want 11/16", p)
+	}
+}
+
+func TestTailsAtTheEdges(t *testing.T) {
+	checks := []struct {
+		what string
+		got  float64
+		want float64
+	}{
+		{"no malicious nodes", HypergeometricTail(100, 0, 10, 0.3), 0},
+		{"only malicious nodes", HypergeometricTail(100, 100, 10, 0.3), 1},
+		{"shard as large as the network", HypergeometricTail(10, 4, 50, 0.3), 1},
+		{"threshold of every member", HypergeometricTail(100, 50, 10, 1), 0},
+		{"empty shard", HypergeometricTail(100, 50, 0, 0.3), 0},
+		{"honest ratio", BinomialTail(10, 0, 0.3), 0},
+		{"malicious ratio", BinomialTail(10, 1, 0.3), 1},
+		{"empty binomial shard", BinomialTail(0, 0.5, 0.3), 0},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: %v, want %v", c.what, c.got, c.want)
+		}
+	}
+}
+
+func TestHypergeometricApproachesBinomial(t *testing.T) {
+	binomial := BinomialTail(100, 0.2, 1.0/3.0)
+	previous := math.Inf(1)
+	for _, population := range []int{200, 2_000, 20_000, 200_000} {
+		hypergeometric := HypergeometricTail(population, population/5, 100, 1.0/3.0)
+		gap := math.Abs(hypergeometric - binomial)
+		// Without replacement the draws vary less, so the tail stays below the binomial one
+		if hypergeometric > binomial || gap >= previous {
+			t.Errorf("population %d: hypergeometric %v against binomial %v", population, hypergeometric, binomial)
+		}
+		previous = gap
+	}
+	if previous > 1e-2*binomial {
+		t.Errorf("hypergeometric tail still %v off the binomial %v with 200000 nodes", previous, binomial)
+	}
+}
+
+func TestAnyShard(t *testing.T) {
+	if p := AnyShard(0.5, 2); !near(p, 0.75, 1e-12) {
+		t.Errorf("either of two even shards %v, want 0.75", p)
+	}
+	if AnyShard(0, 64) != 0 || AnyShard(0.3, 0) != 0 || AnyShard(1, 4) != 1 {
+		t.Error("wrong probability at the edges")
+	}
+	// Tails far below float64 rounding of 1 - p keep their precision
+	if p := AnyShard(1e-15, 1_000); !near(p, 1e-12, 1e-20) {
+		t.Errorf("any of 1000 shards at 1e-15 each %v, want 1e-12", p)
+	}
+}
+
+func TestNewBound(t *testing.T) {
+	bound := NewBound(10, 5, 3, 0.5, 0.5)
+	// Two or three malicious of three: (C(5,2)C(5,1) + C(5,3)) / C(10,3)
+	if bound.Size != 3 || !near(bound.Hypergeometric, 0.5, 1e-12) || !near(bound.Binomial, 0.5, 1e-12) {
+		t.Errorf("bound %+v", bound)
+	}
+	if empty := NewBound(10, 5, 0, 0.5, 0.5); empty.Hypergeometric != 0 || empty.Binomial != 0 {
+		t.Errorf("empty shard bound %+v", empty)
+	}
+}
@@ -0,0 +1,75 @@
+// serving/serving.go
+
+package serving
+
+import (
+	"math/rand"
+	"sharding/config"
+	"sharding/utils"
+	"sort"
+)
+
+// Outcome is what a block request to one peer cost the requester. Delay is the time in
+// milliseconds it waited on the peer, Delivered whether it got a valid block out of it.
+type Outcome struct {
+	Strategy  config.ServingStrategyType
+	Delay     float64
+	Delivered bool
+}
+
+// Assign draws the serving strategy of a malicious node, from cfg.ServingStrategyWeights when set
+func Assign(cfg *config.Config) config.ServingStrategyType {
+	if len(cfg.ServingStrategyWeights) == 0 {
+		return cfg.ServingStrategy
+	}
+	cumulative := make([]float64, 0, len(cfg.ServingStrategyWeights))
+	total := 0.0
+	for _, w := range cfg.ServingStrategyWeights {
+		total += max(0, w)
+		cumulative = append(cumulative, total)
+	}
+	if total == 0 {
+		return cfg.ServingStrategy
+	}
+	strategy := sort.SearchFloat64s(cumulative, rand.Float64()*total)
+	return config.ServingStrategyType(min(strategy, len(cumulative)-1))
+}
+
+// mixed are the strategies a mixed node picks from for every request
+var mixed = []config.ServingStrategyType{
+	config.ServeDelayed,
+	config.ServeRefuse,
+	config.ServeSlow,
+	config.ServeCorrupt,
+	config.ServeStale,
+}
+
+// Serve applies a strategy to a block transfer that takes delay milliseconds when served honestly
+func Serve(cfg *config.Config, strategy config.ServingStrategyType, delay float64) Outcome {
+	if strategy == config.ServeMixed {
+		strategy = mixed[rand.Intn(len(mixed))]
+	}
+	timeout := float64(cfg.TimeOut)
+	outcome := Outcome{Strategy: strategy, Delay: delay, Delivered: true}
+	switch strategy {
+	case config.ServeDelayed:
+		outcome.Delay += timeout
+	case config.ServeRefuse:
+		outcome.Delay, outcome.Delivered = timeout, false
+	case config.ServeSlow:
+		if cfg.SlowServeFraction > 0 {
+			outcome.Delay = delay / cfg.SlowServeFraction
+		}
+		// The requester gives up on a transfer slower than the timeout
+		if cfg.SlowServeFraction <= 0 || outcome.Delay > timeout {
+			outcome.Delay, outcome.Delivered = timeout, false
+		}
+	case config.ServeCorrupt:
+		// The corruption goes unnoticed until the whole block is in and fails to verify
+		outcome.Delivered = false
+	case config.ServeStale:
+		// The header of the older block gives it away before its body arrives
+		outcome.Delay, outcome.Delivered = min(delay, utils.SimulateNetworkBlockHeaderDelay(cfg)), false
+	}
+	return outcome
+}
@@ -0,0 +1,101 @@
+// serving/serving_test.go
+
+package serving
+
+import (
+	"sharding/config"
+	"testing"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		TimeOut:             1000,
+		SlowServeFraction:   0.5,
+		NumNodes:            8,
+		MinGossipFanout:     2,
+		MaxGossipFanout:     2,
+		MinNetworkDelayMean: 50,
+		MaxNetworkDelayMean: 50,
+		NetworkBandwidth:    100,
+		BlockHeaderSize:     1000,
+	}
+}
+
+func TestServeStrategies(t *testing.T) {
+	cfg := testConfig()
+	cases := []struct {
+		strategy  config.ServingStrategyType
+		delay     float64
+		want      float64
+		delivered bool
+	}{
+		{config.ServeHonestly, 300, 300, true},
+		{config.ServeDelayed, 300, 1300, true},
+		{config.ServeRefuse, 300, 1000, false},
+		// Half the bandwidth doubles the transfer, unless that runs past the timeout
+		{config.ServeSlow, 300, 600, true},
+		{config.ServeSlow, 700, 1000, false},
+		// A corrupt block is only caught once it is fully in
+		{config.ServeCorrupt, 300, 300, false},
+	}
+	for _, c := range cases {
+		got := Serve(cfg, c.strategy, c.delay)
+		if got.Strategy != c.strategy || got.Delay != c.want || got.Delivered != c.delivered {
+			t.Errorf("%v at %v ms: %+v, want %v ms delivered %v", c.strategy, c.delay, got, c.want, c.delivered)
+		}
+	}
+
+	cfg.SlowServeFraction = 0
+	if got := Serve(cfg, config.ServeSlow, 300); got.Delivered || got.Delay != 1000 {
+		t.Errorf("slow serving without bandwidth: %+v, want a timeout", got)
+	}
+}
+
+func TestServeStaleStopsAtTheHeader(t *testing.T) {
+	cfg := testConfig()
+	// Three gossip hops of 50 ms bring the header in well before a 5 s transfer ends
+	got := Serve(cfg, config.ServeStale, 5000)
+	if got.Delivered || got.Delay < 150 || got.Delay > 200 {
+		t.Errorf("stale block: %+v, want caught by its header after about 150 ms", got)
+	}
+	if got := Serve(cfg, config.ServeStale, 20); got.Delay != 20 {
+		t.Errorf("stale block arriving before its header: waited %v ms, want 20", got.Delay)
+	}
+}
+
+func TestServeMixedPicksAMaliciousStrategy(t *testing.T) {
+	cfg := testConfig()
+	seen := make(map[config.ServingStrategyType]bool)
+	for i := 0; i < 500; i++ {
+		got := Serve(cfg, config.ServeMixed, 300).Strategy
+		if got == config.ServeMixed || got == config.ServeHonestly {
+			t.Fatalf("mixed serving resolved to %v", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) != len(mixed) {
+		t.Errorf("mixed serving used %d of %d strategies", len(seen), len(mixed))
+	}
+}
+
+func TestAssign(t *testing.T) {
+	cfg := testConfig()
+	cfg.ServingStrategy = config.ServeRefuse
+	if got := Assign(cfg); got != config.ServeRefuse {
+		t.Errorf("without weights: %v, want %v", got, config.ServeRefuse)
+	}
+	cfg.ServingStrategyWeights = []float64{0, -1, 0}
+	if got := Assign(cfg); got != config.ServeRefuse {
+		t.Errorf("without positive weights: %v, want %v", got, config.ServeRefuse)
+	}
+
+	// Only ServeSlow and ServeStale carry weight, one to three
+	cfg.ServingStrategyWeights = []float64{0, 0, 0, 1, 0, 3}
+	counts := make(map[config.ServingStrategyType]int)
+	for i := 0; i < 4000; i++ {
+		counts[Assign(cfg)]++
+	}
+	if len(counts) != 2 || counts[config.ServeStale] < 2700 || counts[config.ServeStale] > 3300 {
+		t.Errorf("assigned %v, want about 1000 slow and 3000 stale", counts)
+	}
+}
@@ -5,31 +5,49 @@ package shard
 import (
 	"fmt"
 	"sharding/block"
+	"sharding/forkchoice"
+	"sharding/mempool"
 	"sharding/node"
 )
 
 type Shard struct {
-	ID     int
-	Blocks []*block.Block
-	Nodes  map[int]*node.Node
+	ID         int
+	Blocks     []*block.Block
+	Nodes      map[int]*node.Node
+	Tree       *forkchoice.BlockTree
+	ForkChoice forkchoice.ForkChoiceRule
+	Mempool    *mempool.Mempool
 }
 
-func NewShard(id int) *Shard {
+func NewShard(id int, forkChoice forkchoice.ForkChoiceRule, mempoolCapacity int) *Shard {
 	s := &Shard{
-		ID:     id,
-		Blocks: make([]*block.Block, 0),
-		Nodes:  make(map[int]*node.Node),
+		ID:         id,
+		Blocks:     make([]*block.Block, 0),
+		Nodes:      make(map[int]*node.Node),
+		Tree:       forkchoice.NewBlockTree(id),
+		ForkChoice: forkChoice,
+		Mempool:    mempool.NewMempool(id, mempoolCapacity),
 	}
 	return s
 }
 
+// AddBlock records every block produced for the shard, competing blocks included
 func (s *Shard) AddBlock(blk *block.Block) {
-	for _, b := range s.Blocks {
-		if b.ID == blk.ID {
-			return
-		}
+	if s.Tree.Contains(blk.Hash) {
+		return
 	}
 	s.Blocks = append(s.Blocks, blk)
+	s.Tree.AddSyncedBlock(blk)
+}
+
+// LatestBlock returns the head of the shard's canonical chain
+func (s *Shard) LatestBlock() *block.Block {
+	return s.ForkChoice.Head(s.Tree)
+}
+
+// CanonicalChain returns the shard's canonical chain under its fork-choice rule, genesis excluded
+func (s *Shard) CanonicalChain() []*block.Block {
+	return s.Tree.Chain(s.LatestBlock())
 }
 
 func (s *Shard) LatestBlockID() int {
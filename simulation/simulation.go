@@ -5,12 +5,34 @@ package simulation
 import (
 	"container/heap"
 	"fmt"
+	"math/rand"
+	"sharding/attack"
+	"sharding/bandwidth"
+	"sharding/beacon"
 	"sharding/block"
+	"sharding/compromise"
 	"sharding/config"
+	"sharding/corruption"
+	"sharding/das"
+	"sharding/eclipse"
 	"sharding/event"
+	"sharding/finality"
+	"sharding/forkchoice"
+	"sharding/geo"
+	"sharding/headersync"
+	"sharding/latency"
+	"sharding/loss"
 	"sharding/metrics"
+	"sharding/network"
 	"sharding/node"
+	"sharding/partition"
+	"sharding/producer"
+	"sharding/serving"
 	"sharding/shard"
+	"sharding/statesync"
+	"sharding/utils"
+	"sharding/workload"
+	"sort"
 )
 
 /*
@@ -28,39 +50,162 @@ type Simulation struct {
 	NetworkBlockBroadcastDelays        map[int][]int64
 	NetworkBlockHeaderDelays           map[int][]int64
 	NetworkBlockDownloadDelays         map[int][]int64
+	NetworkERHeaderDelays              map[int][]int64
+	NetworkERBodyDelays                map[int][]int64
+	ExecutionReceipts                  map[int]*metrics.ReceiptCoverage
+	FraudRecords                       map[int]*metrics.FraudRecord
 	Logs                               []string
 	currentStepMaliciousShardRotations int
 	TotalRotations                     int
 	NextBlockProducer                  map[int]map[int]bool
 	NodeCounter                        map[int]int
+	ProducerSelector                   producer.ProducerSelector
+	// Slot each shard last produced in, a shard produces at most once per slot
+	LastProductionSlot map[int]int64
+	// Hash of the last block produced, hash 0 belongs to the genesis blocks
+	lastBlockHash int
+	// Workload feeds the shard mempools, nil when every block is assumed to be full
+	Workload *workload.Generator
+	// Finality gadget of every shard, empty when blocks never become final
+	FinalityGadgets map[int]*finality.Gadget
+	// Beacon is nil when shard headers are pushed straight to every node
+	Beacon                  *beacon.Chain
+	BeaconPropagationDelays []int64
+	// Erasure coding of shard blocks for data availability sampling
+	DAS               das.Params
+	DASRecords        []*metrics.DASRecord
+	DASSamplingDelays map[int][]int64
+	// StateSync is nil when rotated nodes may produce right away
+	StateSync               statesync.Strategy
+	StateSyncRecords        []*metrics.StateSyncRecord
+	SkippedSyncingWinners   int
+	MissedSlotsWhileSyncing int
+	// Network is nil when block propagation is estimated from the number of gossip hops
+	Network      *network.Graph
+	BlockGossips map[int][]*network.Gossip
+	// Geography is nil when link latencies ignore where nodes are
+	Geography            *geo.Map
+	RegionDownloadDelays map[int][]int64
+	// Links is nil when transfers use the full bandwidth regardless of each other
+	Links *bandwidth.Links
+	// Loss is nil when links never lose messages
+	Loss *loss.Model
+	// Scheduled network partitions, in the order of the configured schedule
+	Partitions []*partition.Partition
+	// Header lag behind the shard tips, sampled every time step
+	HeaderLagSamples map[int][]metrics.HeaderLagSample
+	NodeHeaderLags   map[int]*metrics.NodeHeaderLag
+	ProducerLags     []int
+	// HeaderSync is nil when nodes take the highest header received as their tip
+	HeaderSync *headersync.Tracker
+	// Grinder, Takeover, Eclipse and Corruption are nil when their attack is not scheduled
+	Grinder         *attack.Grinder
+	Takeover        *attack.Takeover
+	Eclipse         *eclipse.Adversary
+	Corruption      *corruption.Adversary
+	ActiveAttack    config.AttackType
+	GrindingSamples []metrics.GrindingSample
+	// Compromise tracks when shards have a malicious share above the compromise threshold
+	Compromise *compromise.Tracker
+	// Withholding is nil when malicious producers publish their block bodies
+	Withholding *metrics.WithholdingRecord
+	// Block requests answered during producer syncs, by serving strategy
+	Serving *metrics.ServingRecord
 }
 
-func NewSimulation(cfg config.Config, metrics *metrics.MetricsCollector) *Simulation {
+func NewSimulation(cfg config.Config, metricsCollector *metrics.MetricsCollector) *Simulation {
 	sim := &Simulation{
 		Config:                      cfg,
 		Nodes:                       make(map[int]*node.Node),
 		Operators:                   make(map[int]*node.Node),
 		Shards:                      make(map[int]*shard.Shard),
 		EventQueue:                  event.NewEventQueue(),
-		Metrics:                     metrics,
+		Metrics:                     metricsCollector,
 		CurrentTime:                 0,
 		NetworkBlockBroadcastDelays: make(map[int][]int64),
 		NetworkBlockHeaderDelays:    make(map[int][]int64),
 		NetworkBlockDownloadDelays:  make(map[int][]int64),
+		NetworkERHeaderDelays:       make(map[int][]int64),
+		NetworkERBodyDelays:         make(map[int][]int64),
+		ExecutionReceipts:           make(map[int]*metrics.ReceiptCoverage),
+		FraudRecords:                make(map[int]*metrics.FraudRecord),
+		FinalityGadgets:             make(map[int]*finality.Gadget),
+		BeaconPropagationDelays:     make([]int64, 0),
+		DAS:                         das.NewParams(&cfg),
+		DASRecords:                  make([]*metrics.DASRecord, 0),
+		DASSamplingDelays:           make(map[int][]int64),
+		StateSync:                   statesync.NewStrategy(&cfg),
+		StateSyncRecords:            make([]*metrics.StateSyncRecord, 0),
+		BlockGossips:                make(map[int][]*network.Gossip),
+		RegionDownloadDelays:        make(map[int][]int64),
+		Partitions:                  make([]*partition.Partition, 0),
+		HeaderLagSamples:            make(map[int][]metrics.HeaderLagSample),
+		NodeHeaderLags:              make(map[int]*metrics.NodeHeaderLag),
+		ProducerLags:                make([]int, 0),
+		GrindingSamples:             make([]metrics.GrindingSample, 0),
+		Compromise:                  compromise.NewTracker(cfg.CompromiseThreshold, cfg.CompromiseMinShardSize),
+		Serving:                     metrics.NewServingRecord(),
 		Logs:                        make([]string, 0),
 		NextBlockProducer:           make(map[int]map[int]bool),
 		NodeCounter:                 make(map[int]int),
+		ProducerSelector:            producer.NewProducerSelector(cfg.ProducerSelection),
+		LastProductionSlot:          make(map[int]int64),
 	}
 
+	sim.initializeDelayTrace()
+	sim.initializeWorkload()
 	sim.initializeNodes()
 	sim.initializeOperators()
 	sim.initializeShards()
 	sim.initializeOperatorsMap()
+	sim.initializeGeography()
+	sim.initializeNetwork()
+	sim.initializeLinks()
+	sim.initializeBeacon()
+	sim.initializeHeaderSync()
+	sim.initializeAttack()
 	sim.scheduleInitialEvents()
 
 	return sim
 }
 
+// initializeDelayTrace loads the RTT samples the empirical delay model draws from. Every node
+// shares the simulation's config, so they all see the samples.
+func (sim *Simulation) initializeDelayTrace() {
+	if sim.Config.DelayTraceFile == "" {
+		for _, model := range []config.DelayDistribution{sim.Config.BlockDelayModel, sim.Config.HeaderDelayModel, sim.Config.DownloadDelayModel} {
+			if model == config.EmpiricalDelay {
+				log := "[Latency] Empirical delays need a delay trace file, falling back to normal delays"
+				sim.Logs = append(sim.Logs, log)
+				fmt.Println(log)
+				break
+			}
+		}
+		return
+	}
+	trace, err := latency.LoadTrace(sim.Config.DelayTraceFile)
+	if err != nil {
+		log := fmt.Sprintf("[Latency] %v, falling back to normal delays", err)
+		sim.Logs = append(sim.Logs, log)
+		fmt.Println(log)
+		return
+	}
+	sim.Config.DelayTrace = trace
+}
+
+func (sim *Simulation) initializeWorkload() {
+	process, err := workload.NewArrivalProcess(&sim.Config)
+	if err != nil {
+		log := fmt.Sprintf("[Workload] %v, falling back to full blocks", err)
+		sim.Logs = append(sim.Logs, log)
+		fmt.Println(log)
+		return
+	}
+	if process != nil {
+		sim.Workload = workload.NewGenerator(process, &sim.Config)
+	}
+}
+
 func (sim *Simulation) initializeNodes() {
 	for i := 0; i < sim.Config.NumNodes; i++ {
 		n := node.NewNode(&sim.Config, i, false)
@@ -72,8 +217,9 @@ func (sim *Simulation) initializeOperators() {
 	// Calculate operators per shard to ensure equal distribution
 	operatorsPerShard := sim.Config.NumOperators / sim.Config.NumShards
 
-	// Create operators and assign them to shards sequentially
-	operatorID := 0
+	// Create operators and assign them to shards sequentially. Operator IDs follow the
+	// regular node IDs so both can be addressed by messages.
+	operatorID := sim.Config.NumNodes
 	for shardID := 0; shardID < sim.Config.NumShards; shardID++ {
 		for i := 0; i < operatorsPerShard; i++ {
 			n := node.NewNode(&sim.Config, operatorID, true)
@@ -87,7 +233,7 @@ func (sim *Simulation) initializeOperatorsMap() {
 	fmt.Println("Initializing operators map")
 	operatorsPerShard := sim.Config.NumOperators / sim.Config.NumShards
 	// Assign operators to shards in groups
-	operatorID := 0
+	operatorID := sim.Config.NumNodes
 	for shardID := 0; shardID < sim.Config.NumShards; shardID++ {
 		for i := 0; i < operatorsPerShard; i++ {
 			n := sim.Operators[operatorID]
@@ -100,14 +246,126 @@ func (sim *Simulation) initializeOperatorsMap() {
 
 func (sim *Simulation) initializeShards() {
 	for i := 0; i < sim.Config.NumShards; i++ {
-		s := shard.NewShard(i)
+		s := shard.NewShard(i, forkchoice.NewForkChoiceRule(sim.Config.ForkChoice), sim.Config.MempoolCapacity)
 		sim.Shards[s.ID] = s
 		sim.NextBlockProducer[s.ID] = make(map[int]bool)
+		if rule := finality.NewFinalityRule(&sim.Config); rule != nil {
+			sim.FinalityGadgets[s.ID] = finality.NewGadget(s.ID, rule)
+		}
 
 	}
 }
 
+func (sim *Simulation) initializeGeography() {
+	if sim.Config.RegionLatencyFile == "" {
+		return
+	}
+	geography, err := geo.LoadMatrix(sim.Config.RegionLatencyFile)
+	if err != nil {
+		log := fmt.Sprintf("[Geography] %v, falling back to uniform latencies", err)
+		sim.Logs = append(sim.Logs, log)
+		fmt.Println(log)
+		return
+	}
+
+	nodeIDs := make([]int, 0, len(sim.Nodes))
+	for id := range sim.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	operatorIDs := make([]int, 0, len(sim.Operators))
+	for id := range sim.Operators {
+		operatorIDs = append(operatorIDs, id)
+	}
+	geography.Place(nodeIDs, sim.Config.NodeRegionWeights)
+	geography.Place(operatorIDs, sim.Config.OperatorRegionWeights)
+	for _, n := range append(sim.getNodes(), sim.getOperators()...) {
+		n.Region = geography.NodeRegion[n.ID]
+		n.Geography = geography
+	}
+	sim.Geography = geography
+}
+
+func (sim *Simulation) initializeNetwork() {
+	ids := make([]int, 0, len(sim.Nodes)+len(sim.Operators))
+	for _, n := range append(sim.getNodes(), sim.getOperators()...) {
+		ids = append(ids, n.ID)
+	}
+	sim.Network = network.NewGraph(&sim.Config, ids, sim.Geography)
+	if sim.Network == nil {
+		return
+	}
+	for id, peers := range sim.Network.Peers {
+		sim.getNode(id).Peers = peers
+	}
+}
+
+func (sim *Simulation) initializeLinks() {
+	sim.Links = bandwidth.NewLinks(&sim.Config)
+	sim.Loss = loss.NewModel(&sim.Config)
+	for _, n := range append(sim.getNodes(), sim.getOperators()...) {
+		n.Links = sim.Links
+		n.Loss = sim.Loss
+	}
+}
+
+func (sim *Simulation) initializeBeacon() {
+	if !sim.Config.EnableBeaconChain {
+		return
+	}
+	sim.Beacon = beacon.NewChain(sim.getNodes(), sim.Config.BeaconCommitteeSize, sim.Config.MaxCrosslinks)
+}
+
+func (sim *Simulation) initializeHeaderSync() {
+	if sim.Config.EnableHeaderSync {
+		sim.HeaderSync = headersync.NewTracker()
+	}
+}
+
+func (sim *Simulation) initializeAttack() {
+	if sim.Config.BlockWithholding != config.NoWithholding {
+		sim.Withholding = metrics.NewWithholdingRecord()
+	}
+	for _, atkType := range sim.Config.AttackSchedule {
+		switch {
+		case atkType == config.GrindingAttack && sim.Grinder == nil:
+			sim.Grinder = attack.NewGrinder(&sim.Config)
+		case atkType == config.ShardTakeoverAttack && sim.Takeover == nil:
+			sim.Takeover = attack.NewTakeover(&sim.Config)
+		case atkType == config.EclipseAttack && sim.Eclipse == nil:
+			sim.Eclipse = eclipse.NewAdversary(&sim.Config, sim.Nodes)
+		case atkType == config.AdaptiveCorruptionAttack && sim.Corruption == nil:
+			sim.Corruption = corruption.NewAdversary(&sim.Config, sim.Compromise.Threshold)
+		}
+	}
+}
+
 func (sim *Simulation) scheduleInitialEvents() {
+	if sim.Beacon != nil && sim.Config.BeaconBlockInterval > 0 {
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.Config.BeaconBlockInterval),
+			Type:      event.BeaconBlockEvent,
+		})
+	}
+
+	if sim.Config.TimeStep > 0 {
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.Config.TimeStep),
+			Type:      event.HeaderLagEvent,
+		})
+	}
+
+	for _, spec := range sim.Config.Partitions {
+		if spec.End <= spec.Start {
+			continue
+		}
+		p := partition.NewPartition(spec)
+		sim.Partitions = append(sim.Partitions, p)
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(spec.Start),
+			Type:      event.PartitionEvent,
+			Data:      p,
+		})
+	}
 
 	// Schedule the first LotteryEvent for all nodes
 	fmt.Println("Current time", sim.CurrentTime)
@@ -128,6 +386,11 @@ func (sim *Simulation) Run() {
 
 	// Network delay for each shard
 	// fmt.Println("\nFinal network delays for shards:", sim.NetworkBlockBroadcastDelays)
+	// Transactions still arriving after the last block make up the final backlog
+	sim.fillMempools(float64(sim.Config.SimulationTime))
+	if sim.Network != nil {
+		sim.recordGossipDelays()
+	}
 	sim.handleMetricsEvent()
 }
 
@@ -139,6 +402,22 @@ func (sim *Simulation) processEvent(e *event.Event) {
 		sim.handleShardBlockProductionEvent(e)
 	case event.MessageEvent:
 		sim.handleMessageEvent(e)
+	case event.FraudDetectionEvent:
+		sim.handleFraudDetectionEvent(e)
+	case event.FinalityEvent:
+		sim.handleFinalityEvent(e)
+	case event.BeaconBlockEvent:
+		sim.handleBeaconBlockEvent()
+	case event.GossipEvent:
+		sim.handleGossipEvent(e)
+	case event.PartitionEvent:
+		sim.handlePartitionEvent(e)
+	case event.PartitionCheckEvent:
+		sim.handlePartitionCheckEvent(e)
+	case event.HeaderLagEvent:
+		sim.handleHeaderLagEvent()
+	case event.CorruptionEvent:
+		sim.handleCorruptionEvent(e)
 	default:
 		// Unknown event type
 		log := fmt.Sprintf("[Simulation] Unknown event type at time %d", sim.CurrentTime)
@@ -147,12 +426,38 @@ func (sim *Simulation) processEvent(e *event.Event) {
 }
 
 func (sim *Simulation) handleLotteryEvent() {
+	if active := attack.ActiveAttack(sim.Config.AttackSchedule, sim.CurrentTime); active != sim.ActiveAttack {
+		attack.ExecuteAttack(active, sim.CurrentTime, sim.Config.AttackTargetShard, &sim.Logs)
+		sim.ActiveAttack = active
+	}
+	grinding := sim.ActiveAttack == config.GrindingAttack && sim.Grinder != nil
+	takeover := sim.ActiveAttack == config.ShardTakeoverAttack && sim.Takeover != nil
+	if grinding {
+		sim.Grinder.StartRound(sim.CurrentTime)
+	}
+
 	for _, n := range sim.Nodes {
-		won, newShardID := n.ParticipateInLottery(sim.CurrentTime, sim.Config.NumShards)
+		var won bool
+		var newShardID int
+		if grinding && !n.IsHonest {
+			won, newShardID = sim.Grinder.Grind(n, sim.CurrentTime, sim.Config.NumShards)
+		} else {
+			won, newShardID = n.ParticipateInLottery(sim.CurrentTime, sim.Config.NumShards, sim.Config.LotteryWinProbability)
+			if takeover && !n.IsHonest {
+				won, newShardID = sim.Takeover.Decide(n, won, newShardID)
+			}
+		}
 		if won {
 			sim.processLotteryWin(n, newShardID)
 		}
 	}
+	if sim.Grinder != nil {
+		sim.sampleGrinding(grinding)
+	}
+	sim.observeCompromise()
+	if sim.ActiveAttack == config.AdaptiveCorruptionAttack && sim.Corruption != nil {
+		sim.startCorruptions()
+	}
 
 	// Schedule the next LotteryEvent for all nodes
 	if sim.CurrentTime+sim.Config.BlockProductionInterval < sim.Config.SimulationTime {
@@ -196,89 +501,999 @@ func (sim *Simulation) processLotteryWin(n *node.Node, newShardID int) {
 		newShard.AddNode(n)
 		sim.NodeCounter[newShardID]++
 		n.AssignedShard = newShardID
+		if sim.Corruption != nil && newShardID != oldShardID {
+			sim.Corruption.Moved(n.ID, oldShardID, sim.CurrentTime)
+		}
+		// A node drawn into the shard it was already in keeps its state
+		if sim.StateSync != nil && newShardID != oldShardID {
+			sim.syncState(n, newShardID)
+		}
 
 	}
 
 }
 
+// observeCompromise records the malicious share of every shard once its membership changed. The
+// members of a shard include its operators.
+func (sim *Simulation) observeCompromise() {
+	for shardID, s := range sim.Shards {
+		sim.Compromise.Observe(shardID, countMalicious(s), len(s.Nodes), sim.CurrentTime)
+	}
+}
+
+func countMalicious(s *shard.Shard) int {
+	malicious := 0
+	for _, n := range s.Nodes {
+		if !n.IsHonest {
+			malicious++
+		}
+	}
+	return malicious
+}
+
+// startCorruptions lets the adaptive adversary go for the members of a shard right after the
+// lottery placed them. Corruptions that would land after the simulation ends never start.
+func (sim *Simulation) startCorruptions() {
+	for _, c := range sim.Corruption.Plan(sim.Shards, sim.CurrentTime, sim.Config.SimulationTime) {
+		log := fmt.Sprintf("[Attack] Adversary started corrupting Node %d in Shard %d at time %d", c.NodeID, c.ShardID, sim.CurrentTime)
+		sim.Logs = append(sim.Logs, log)
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.CurrentTime + sim.Corruption.Delay),
+			Type:      event.CorruptionEvent,
+			NodeID:    c.NodeID,
+			ShardID:   c.ShardID,
+			Data:      c,
+		})
+	}
+}
+
+// handleCorruptionEvent turns a node malicious when it is still in the shard the adversary
+// found it in. The node keeps its blocks and serves them the way malicious nodes do from now on.
+func (sim *Simulation) handleCorruptionEvent(e *event.Event) {
+	c := e.Data.(*corruption.Corruption)
+	n := sim.Nodes[c.NodeID]
+	if !sim.Corruption.Land(c, n, sim.CurrentTime) {
+		log := fmt.Sprintf("[Attack] Node %d rotated out of Shard %d before the adversary corrupted it at time %d", c.NodeID, c.ShardID, sim.CurrentTime)
+		sim.Logs = append(sim.Logs, log)
+		return
+	}
+	n.IsHonest = false
+	n.Serving = serving.Assign(&sim.Config)
+	log := fmt.Sprintf("[Attack] Adversary corrupted Node %d in Shard %d at time %d", c.NodeID, c.ShardID, sim.CurrentTime)
+	sim.Logs = append(sim.Logs, log)
+	// The shard may cross the threshold right now, but observations stay once per lottery round
+	s := sim.Shards[c.ShardID]
+	sim.Compromise.Update(c.ShardID, countMalicious(s), len(s.Nodes), sim.CurrentTime)
+}
+
+// sampleGrinding records the malicious share of the grinding target shard after a lottery round,
+// next to the malicious share of every node assigned to a shard
+func (sim *Simulation) sampleGrinding(grinding bool) {
+	sample := metrics.GrindingSample{Time: sim.CurrentTime, Active: grinding}
+	assigned, malicious := 0, 0
+	for _, n := range sim.Nodes {
+		if n.AssignedShard == -1 {
+			continue
+		}
+		assigned++
+		if !n.IsHonest {
+			malicious++
+		}
+		if n.AssignedShard == sim.Grinder.TargetShard {
+			sample.TargetMembers++
+			if !n.IsHonest {
+				sample.TargetMalicious++
+			}
+		}
+	}
+	if sample.TargetMembers > 0 {
+		sample.TargetShare = float64(sample.TargetMalicious) / float64(sample.TargetMembers)
+	}
+	if assigned > 0 {
+		sample.NetworkShare = float64(malicious) / float64(assigned)
+	}
+	if grinding {
+		round := sim.Grinder.Rounds[len(sim.Grinder.Rounds)-1]
+		sample.Attempts = round.Attempts
+		sample.Cost = round.Cost
+	}
+	sim.GrindingSamples = append(sim.GrindingSamples, sample)
+}
+
+// syncState starts the state download of a node that joined a shard. The node may only produce
+// there once the state is downloaded and the blocks since it are re-executed.
+func (sim *Simulation) syncState(n *node.Node, shardID int) {
+	chain := sim.Shards[shardID].CanonicalChain()
+	cost := sim.StateSync.Cost(chain)
+	delay := utils.SimulateNetworkStateSyncDelay(&sim.Config, cost.Bytes) + float64(cost.ReplayedBlocks)*sim.Config.BlockReplayTime
+	n.StateSyncedAt[shardID] = float64(sim.CurrentTime) + delay/1000.0
+	sim.StateSyncRecords = append(sim.StateSyncRecords, &metrics.StateSyncRecord{
+		NodeID:    n.ID,
+		ShardID:   shardID,
+		Height:    len(chain),
+		StateSize: statesync.StateSize(&sim.Config, chain),
+		Bytes:     cost.Bytes,
+		Delay:     delay,
+	})
+}
+
 func (sim *Simulation) handleShardBlockProductionEvent(e *event.Event) {
 	shardID := e.ShardID
 
-	// Find the first node with bool == false
-	var producerNode *node.Node
+	// Every lottery winner leaving the shard queues an event for the slot, whatever the producer
+	// rule only the first one produces
+	if slot, produced := sim.LastProductionSlot[shardID]; produced && slot == sim.CurrentTime {
+		return
+	}
+	sim.LastProductionSlot[shardID] = sim.CurrentTime
+
+	// Lottery winners that have not produced yet
+	winners := []*node.Node{}
 	for nodeID, hasProduced := range sim.NextBlockProducer[shardID] {
 		if !hasProduced {
-			producerNode = sim.Nodes[nodeID]
-			break
+			winners = append(winners, sim.Nodes[nodeID])
 		}
 	}
+	height := sim.Shards[shardID].GetLatestBlockID() + 1
+	producers := sim.selectProducers(shardID, height, winners)
+	sim.fillMempools(float64(sim.CurrentTime))
 
-	if producerNode == nil {
+	if len(producers) == 0 {
 		// // All nodes have produced blocks, skip producing a block
 		// log := fmt.Sprintf("All nodes in shard %d have produced blocks or the block is already in the shard, skipping block production at time %d", shardID, sim.CurrentTime)
 		// sim.Logs = append(sim.Logs, log)
 
 	} else {
-		// BLock Header Chain
-		latestBlockID := sim.Shards[shardID].GetLatestBlockID()
-		/*
-			Step1: Pull out the proposers of k latest blocks
-			Step2: Create an array of proposers
-			Step3: Add all of the operators within the shard to the array
-			Step4: Call the download latest k blocks function from the array of proposers and oprators
-			Step6: Capture the time that it took to download
-		*/
-
-		proposers := sim.getProposers(sim.Config, latestBlockID, shardID)
+		// Every producer settles on a parent before any of them publishes, so concurrent
+		// producers cannot see each other's blocks
+		parents := make([]*block.Block, len(producers))
+		for i, producerNode := range producers {
+			parents[i] = sim.syncProducer(producerNode, shardID)
+		}
+		for i, producerNode := range producers {
+			if sim.missesParent(producerNode, parents[i]) {
+				continue
+			}
+			sim.produceBlock(producerNode, parents[i], shardID)
+		}
+		// reset the sim.NextBlockProducer map for the shard
+		sim.NextBlockProducer[shardID] = make(map[int]bool)
+	}
+}
+
+// selectProducers asks the producer selector for the producers of this event. With forks
+// enabled up to ConcurrentProducers distinct nodes produce competing blocks at the same time.
+func (sim *Simulation) selectProducers(shardID int, height int, winners []*node.Node) []*node.Node {
+	numProducers := 1
+	if sim.Config.EnableForks && sim.Config.ConcurrentProducers > 1 {
+		numProducers = sim.Config.ConcurrentProducers
+	}
+
+	members := sim.getShardNodes(shardID)
+	selected := make(map[int]bool)
+	// Nodes still syncing the shard state are left out like the producers picked already
+	syncing := 0
+	if sim.StateSync != nil {
+		for _, candidates := range [][]*node.Node{winners, members} {
+			for _, n := range candidates {
+				if !n.IsStateSynced(shardID, float64(sim.CurrentTime)) {
+					selected[n.ID] = true
+				}
+			}
+		}
+		syncing = len(winners) - len(excludeNodes(winners, selected))
+		sim.SkippedSyncingWinners += syncing
+		if len(members) > 0 && len(excludeNodes(members, selected)) == 0 {
+			syncing += len(members)
+		}
+	}
+	producers := make([]*node.Node, 0, numProducers)
+	for len(producers) < numProducers {
+		producerNode := sim.ProducerSelector.SelectProducer(shardID, height, excludeNodes(winners, selected), excludeNodes(members, selected))
+		if producerNode == nil {
+			break
+		}
+		selected[producerNode.ID] = true
+		producers = append(producers, producerNode)
+	}
+	if len(producers) == 0 && syncing > 0 {
+		sim.MissedSlotsWhileSyncing++
+	}
+	return producers
+}
+
+// syncProducer downloads the latest blocks of the shard to the producer and returns the block it builds on
+func (sim *Simulation) syncProducer(producerNode *node.Node, shardID int) *block.Block {
+	// BLock Header Chain
+	latestBlockID := sim.Shards[shardID].GetLatestBlockID()
+	/*
+		Step1: Pull out the proposers of k latest blocks
+		Step2: Create an array of proposers
+		Step3: Add all of the operators within the shard to the array
+		Step4: Call the download latest k blocks function from the array of proposers and oprators
+		Step6: Capture the time that it took to download
+	*/
+
+	sim.ProducerLags = append(sim.ProducerLags, max(0, latestBlockID-producerNode.LatestBlockHeaderID(shardID)))
+	proposers := sim.getProposers(sim.Config, latestBlockID, shardID)
+	victim := sim.ActiveAttack == config.EclipseAttack && sim.Eclipse != nil && producerNode.IsHonest && shardID == sim.Config.AttackTargetShard
+	eclipsed := false
+	if victim {
+		peerSet := sim.Eclipse.Select(producerNode, proposers, sim.getShardOperators(shardID))
+		proposers, eclipsed = peerSet.Peers, peerSet.Eclipsed()
+	} else {
 		proposers = append(proposers, sim.getShardOperators(shardID)...)
-		downloadTime := producerNode.DownloadLatestKBlocks(&sim.Config, proposers, shardID, sim.CurrentTime)
-		sim.NetworkBlockDownloadDelays[shardID] = append(sim.NetworkBlockDownloadDelays[shardID], int64(downloadTime))
-
-		blk := producerNode.CreateBlock(latestBlockID, sim.CurrentTime)
-		blkHeader := producerNode.CreateBlockHeader(latestBlockID, sim.CurrentTime)
-		// The proposer must add the block to its blockchain
-		blkHeader.Timestamp = sim.CurrentTime
-		blk.Timestamp = blkHeader.Timestamp
-
-		producerNode.HandleBlock(blk)
-		producerNode.HandleBlockHeader(blkHeader)
-		// Node broadcasts the block to peers in the shard
-		shardOperatorNodes := sim.getShardOperators(shardID)
-		shardNodes := append(sim.getShardNodes(shardID), shardOperatorNodes...)
-		events, delay := producerNode.BroadcastBlock(&sim.Config, blk, shardNodes, sim.CurrentTime)
+	}
+	proposers = sim.reachablePeers(producerNode.ID, proposers)
+	downloadTime, report := producerNode.DownloadLatestKBlocks(&sim.Config, proposers, shardID, sim.CurrentTime)
+	if sim.Withholding != nil {
+		sim.Withholding.RecordDownload(shardID, report.Refusals, len(report.Missing), report.WastedTime)
+	}
+	sim.Serving.Record(report.Attempts)
+	sim.NetworkBlockDownloadDelays[shardID] = append(sim.NetworkBlockDownloadDelays[shardID], int64(downloadTime))
+	if sim.Geography != nil {
+		sim.RegionDownloadDelays[producerNode.Region] = append(sim.RegionDownloadDelays[producerNode.Region], int64(downloadTime))
+	}
+
+	// Without forks every producer extends the shard's canonical head. With forks it can
+	// only extend what reached it, so stale views and concurrent producers create competing blocks.
+	// An eclipsed producer only knows what the adversary let through either way.
+	parent := sim.Shards[shardID].LatestBlock()
+	if sim.Config.EnableForks || eclipsed {
+		parent = producerNode.HeadBlock(shardID)
+	}
+	if victim {
+		head := sim.Shards[shardID].LatestBlock()
+		sim.Eclipse.RecordParent(eclipsed, parent.Hash != head.Hash, parent.IsMalicious)
+	}
+	return parent
+}
+
+// missesParent reports whether a producer has to skip its slot because the body of the parent it
+// must extend was withheld from it
+func (sim *Simulation) missesParent(producerNode *node.Node, parent *block.Block) bool {
+	if sim.Withholding == nil || !parent.Withheld {
+		return false
+	}
+	if held, exists := producerNode.Blockchain[parent.ShardID][parent.ID]; exists && held.Hash == parent.Hash {
+		return false
+	}
+	sim.Withholding.StalledSlots[parent.ShardID]++
+	log := fmt.Sprintf("[Withholding] Node %d cannot extend withheld block %d in shard %d at time %d", producerNode.ID, parent.ID, parent.ShardID, sim.CurrentTime)
+	sim.Logs = append(sim.Logs, log)
+	return true
+}
+
+// bodyRecipients leaves out the peers a withheld block body is kept from
+func (sim *Simulation) bodyRecipients(blk *block.Block, peers []*node.Node) []*node.Node {
+	if !blk.Withheld {
+		return peers
+	}
+	recipients := make([]*node.Node, 0)
+	if sim.Config.BlockWithholding == config.WithholdForColluders {
+		for _, peerNode := range peers {
+			if !peerNode.IsHonest {
+				recipients = append(recipients, peerNode)
+			}
+		}
+	}
+	return recipients
+}
+
+func (sim *Simulation) produceBlock(producerNode *node.Node, parent *block.Block, shardID int) {
+	sim.lastBlockHash++
+	blk := producerNode.CreateBlock(parent, sim.lastBlockHash, sim.CurrentTime)
+	if blk.IsMalicious && sim.Withholding != nil {
+		blk.Withheld = true
+		sim.Withholding.WithheldBlocks[shardID]++
+	}
+	blkHeader := producerNode.CreateBlockHeader(blk)
+	// Malicious blocks carry invalid transactions, so they leave the mempool untouched.
+	// Pending credits of cross-shard transactions go in before new transactions.
+	if sim.Workload != nil && !blk.IsMalicious {
+		mp := sim.Shards[shardID].Mempool
+		blk.Receipts = mp.TakeReceipts(float64(sim.CurrentTime), sim.Config.TransactionsPerBlock, sim.isReceiptValid)
+		blk.Transactions = mp.Take(sim.Config.TransactionsPerBlock - len(blk.Receipts))
+	}
+
+	// The proposer must add the block to its blockchain
+	producerNode.HandleBlock(blk)
+	producerNode.HandleBlockHeader(blkHeader)
+	// Node broadcasts the block to peers in the shard
+	shardOperatorNodes := sim.getShardOperators(shardID)
+	shardNodes := append(sim.getShardNodes(shardID), shardOperatorNodes...)
+	if sim.Network != nil {
+		sim.gossipBlock(producerNode, blk, sim.bodyRecipients(blk, shardNodes))
+	} else {
+		events, delay := producerNode.BroadcastBlock(&sim.Config, blk, sim.bodyRecipients(blk, shardNodes), sim.CurrentTime)
 
 		if len(events) > 0 {
 			sim.NetworkBlockBroadcastDelays[shardID] = append(sim.NetworkBlockBroadcastDelays[shardID], int64(delay/float64(len(events))))
 		}
+		if sim.Config.EnableForks {
+			// Peers only see the block once its propagation delay has passed
+			for _, ev := range sim.crossPartition(producerNode.ID, events) {
+				heap.Push(sim.EventQueue, ev)
+			}
+		}
+	}
+
+	log := fmt.Sprintf("[Block Production] Node %d produced block %d (hash %d, parent %d) at time %d in shard %d", producerNode.ID, blk.ID, blk.Hash, blk.PreviousHash, sim.CurrentTime, shardID)
+	sim.Logs = append(sim.Logs, log)
+	// Broadcast block header to all nodes in the whole network, or only to the beacon
+	// committee when the rest of the network follows the beacon chain
+	headerPeers := sim.getNodes()
+	if sim.Beacon != nil {
+		headerPeers = sim.Beacon.Committee
+		sim.Beacon.SubmitHeader(blkHeader, float64(sim.CurrentTime)+utils.SimulateNetworkBlockHeaderDelay(&sim.Config)/1000.0)
+	}
+	events, delay := producerNode.BroadcastBlockHeader(&sim.Config, blkHeader, headerPeers, sim.CurrentTime)
+
+	if len(events) > 0 {
+		sim.NetworkBlockHeaderDelays[shardID] = append(sim.NetworkBlockHeaderDelays[shardID], int64(delay/float64(len(events))))
+	}
+	for _, e := range sim.crossPartition(producerNode.ID, events) {
+		heap.Push(sim.EventQueue, e)
+	}
+
+	// Add the block to the shard
+	sim.Shards[shardID].AddBlock(blk)
+	sim.relayReceipts(blk)
+	sim.runFinality(blk)
 
-		log := fmt.Sprintf("[Block Production] Node %d produced block %d at time %d in shard %d", producerNode.ID, blk.ID, sim.CurrentTime, shardID)
+	if sim.Config.ERHeaderSize > 0 || sim.Config.ERBodySize > 0 {
+		sim.publishExecutionReceipt(producerNode, blk, shardNodes)
+	}
+	if blk.IsMalicious && sim.Config.EnableFraudProofs {
+		sim.scheduleFraudDetection(producerNode, blk)
+	}
+	if sim.Config.EnableDAS {
+		sim.sampleAvailability(producerNode, blk)
+	}
+}
+
+// sampleAvailability publishes the erasure-coded chunks of a block, withholding part of them if the
+// producer is malicious, and lets the other shard members and the light nodes sample them
+func (sim *Simulation) sampleAvailability(producerNode *node.Node, blk *block.Block) {
+	withheldFraction := 0.0
+	if blk.IsMalicious {
+		withheldFraction = sim.Config.DASWithheldFraction
+	}
+	available := sim.DAS.Publish(withheldFraction)
+	record := &metrics.DASRecord{
+		ShardID:              blk.ShardID,
+		Withheld:             withheldFraction > 0,
+		Recoverable:          sim.DAS.Recoverable(available),
+		Samplers:             len(excludeNodes(sim.getShardNodes(blk.ShardID), map[int]bool{producerNode.ID: true})) + sim.Config.NumLightNodes,
+		DetectionProbability: sim.DAS.DetectionProbability(available),
+	}
+	sim.DASRecords = append(sim.DASRecords, record)
+
+	for i := 0; i < record.Samplers; i++ {
+		if !sim.DAS.Sample(available) {
+			record.FailedSamplers++
+		}
+		// Samples are requested in parallel, so sampling takes as long as the slowest one
+		delay := 0.0
+		for j := 0; j < sim.DAS.Samples; j++ {
+			delay = max(delay, utils.SimulateNetworkChunkDelay(&sim.Config, sim.DAS.SampleSize))
+		}
+		sim.DASSamplingDelays[blk.ShardID] = append(sim.DASSamplingDelays[blk.ShardID], int64(delay))
+	}
+}
+
+// scheduleFraudDetection lets FraudVerifiers members of the shard, sampled at random, check the
+// block. Re-executing it takes an exponential time around FraudReexecutionFactor block intervals,
+// so later blocks may be built on it before anyone finds out. An honest verifier spots the fraud
+// with FraudDetectionProb, and the first detection raises the fraud proof.
+func (sim *Simulation) scheduleFraudDetection(producerNode *node.Node, blk *block.Block) {
+	sim.FraudRecords[blk.Hash] = &metrics.FraudRecord{
+		ShardID:    blk.ShardID,
+		BlockHash:  blk.Hash,
+		ProducedAt: blk.Timestamp,
+	}
+
+	// Zero values of a configuration that leaves them out fall back to the defaults
+	verifiers, factor := sim.Config.FraudVerifiers, sim.Config.FraudReexecutionFactor
+	if verifiers <= 0 {
+		verifiers = config.FraudVerifiers
+	}
+	if factor <= 0 {
+		factor = config.FraudReexecutionFactor
+	}
+	candidates := make([]*node.Node, 0)
+	for _, n := range sim.getShardNodes(blk.ShardID) {
+		if n.ID != producerNode.ID {
+			candidates = append(candidates, n)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	reexecution := factor * float64(sim.Config.BlockProductionInterval) * 1000.0
+
+	var detector *node.Node
+	detectionDelay := 0.0
+	for _, verifier := range candidates[:min(verifiers, len(candidates))] {
+		if !verifier.IsHonest || rand.Float64() >= sim.Config.FraudDetectionProb {
+			continue
+		}
+		delay := utils.SimulateNetworkBlockDownloadDelay(&sim.Config) + rand.ExpFloat64()*reexecution + float64(sim.Config.FraudDetectionDelay)
+		if detector == nil || delay < detectionDelay {
+			detector = verifier
+			detectionDelay = delay
+		}
+	}
+	if detector == nil {
+		log := fmt.Sprintf("[Fraud Proof] Malicious block %d (hash %d) in shard %d went undetected", blk.ID, blk.Hash, blk.ShardID)
 		sim.Logs = append(sim.Logs, log)
-		sim.NextBlockProducer[shardID][blk.ID] = true
-		// Broadcast block header to all nodes in the whole network
-		events, delay = producerNode.BroadcastBlockHeader(&sim.Config, blkHeader, sim.getNodes(), sim.CurrentTime)
+		return
+	}
 
-		if len(events) > 0 {
-			sim.NetworkBlockHeaderDelays[shardID] = append(sim.NetworkBlockHeaderDelays[shardID], int64(delay/float64(len(events))))
+	e := &event.Event{
+		Timestamp: float64(sim.CurrentTime) + detectionDelay/1000.0,
+		Type:      event.FraudDetectionEvent,
+		NodeID:    detector.ID,
+		ShardID:   blk.ShardID,
+		Data:      blk,
+	}
+	heap.Push(sim.EventQueue, e)
+}
+
+// handleFraudDetectionEvent raises the fraud proof of a detected block. The shard reverts the block
+// and everything built on it if the proof arrives within the challenge period, and the proof is
+// gossiped so every node does the same.
+func (sim *Simulation) handleFraudDetectionEvent(e *event.Event) {
+	blk := e.Data.(*block.Block)
+	prover := sim.getNode(e.NodeID)
+	if prover == nil {
+		return
+	}
+	proof := block.NewFraudProof(blk, prover.ID, e.Timestamp)
+
+	record := sim.FraudRecords[blk.Hash]
+	record.Detected = true
+	record.DetectionDelay = (e.Timestamp - float64(blk.Timestamp)) * 1000.0
+
+	if e.Timestamp-float64(blk.Timestamp) <= float64(sim.Config.ChallengePeriod) {
+		reverted := sim.Shards[blk.ShardID].Tree.Invalidate(blk.Hash)
+		record.Reverted = true
+		log := fmt.Sprintf("[Fraud Proof] Node %d proved block %d (hash %d) in shard %d fraudulent at time %d, reverting %d blocks", prover.ID, blk.ID, blk.Hash, blk.ShardID, sim.CurrentTime, len(reverted))
+		sim.Logs = append(sim.Logs, log)
+	} else {
+		log := fmt.Sprintf("[Fraud Proof] Node %d proved block %d (hash %d) in shard %d fraudulent at time %d, after the challenge period", prover.ID, blk.ID, blk.Hash, blk.ShardID, sim.CurrentTime)
+		sim.Logs = append(sim.Logs, log)
+	}
+
+	prover.HandleFraudProof(proof)
+	peers := append(sim.getNodes(), sim.getOperators()...)
+	for _, ev := range sim.crossPartition(prover.ID, prover.BroadcastFraudProof(&sim.Config, proof, peers)) {
+		heap.Push(sim.EventQueue, ev)
+	}
+}
+
+// runFinality asks the finality gadget of the shard what the new block makes final and
+// schedules the finalisation
+func (sim *Simulation) runFinality(blk *block.Block) {
+	gadget, ok := sim.FinalityGadgets[blk.ShardID]
+	if !ok {
+		return
+	}
+	s := sim.Shards[blk.ShardID]
+	final, delay := gadget.BlockProduced(s.Tree, s.LatestBlock(), blk, sim.getShardNodes(blk.ShardID), float64(sim.CurrentTime))
+	if final == nil {
+		return
+	}
+	e := &event.Event{
+		Timestamp: float64(sim.CurrentTime) + delay/1000.0,
+		Type:      event.FinalityEvent,
+		ShardID:   blk.ShardID,
+		Data:      final,
+	}
+	heap.Push(sim.EventQueue, e)
+}
+
+func (sim *Simulation) handleFinalityEvent(e *event.Event) {
+	blk := e.Data.(*block.Block)
+	finalized := sim.FinalityGadgets[e.ShardID].Finalize(sim.Shards[e.ShardID].Tree, blk, e.Timestamp)
+	if finalized > 0 {
+		log := fmt.Sprintf("[Finality] Block %d (hash %d) in shard %d became final at time %d, finalising %d blocks", blk.ID, blk.Hash, e.ShardID, sim.CurrentTime, finalized)
+		sim.Logs = append(sim.Logs, log)
+	}
+}
+
+// handleBeaconBlockEvent produces the next beacon block and gossips it to the whole network.
+// With beacon finality every crosslinked shard block becomes final.
+func (sim *Simulation) handleBeaconBlockEvent() {
+	beaconBlock := sim.Beacon.ProduceBlock(sim.CurrentTime)
+	if beaconBlock != nil {
+		log := fmt.Sprintf("[Beacon] Node %d produced beacon block %d with %d crosslinks at time %d", beaconBlock.ProducerID, beaconBlock.ID, len(beaconBlock.Crosslinks), sim.CurrentTime)
+		sim.Logs = append(sim.Logs, log)
+
+		producerNode := sim.getNode(beaconBlock.ProducerID)
+		producerNode.HandleBeaconBlock(beaconBlock)
+		peers := append(sim.getNodes(), sim.getOperators()...)
+		for _, e := range sim.crossPartition(producerNode.ID, producerNode.BroadcastBeaconBlock(&sim.Config, beaconBlock, peers, sim.CurrentTime)) {
+			heap.Push(sim.EventQueue, e)
 		}
 
-		// Add the block to the shard
-		sim.Shards[shardID].AddBlock(blk)
-		// reset the sim.NextBlockProducer map for the shard
-		sim.NextBlockProducer[shardID] = make(map[int]bool)
+		if sim.Config.Finality == config.BeaconFinality {
+			for _, header := range beaconBlock.Crosslinks {
+				tree := sim.Shards[header.ShardID].Tree
+				if blk := tree.Block(header.Hash); blk != nil {
+					sim.FinalityGadgets[header.ShardID].Finalize(tree, blk, float64(sim.CurrentTime))
+				}
+			}
+		}
+	}
+
+	if sim.CurrentTime+sim.Config.BeaconBlockInterval < sim.Config.SimulationTime {
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.CurrentTime + sim.Config.BeaconBlockInterval),
+			Type:      event.BeaconBlockEvent,
+		})
+	}
+}
+
+// fillMempools adds every transaction that arrived up to time t to the mempool of its shard
+func (sim *Simulation) fillMempools(t float64) {
+	if sim.Workload == nil {
+		return
+	}
+	for _, tx := range sim.Workload.Until(t) {
+		sim.Shards[tx.ShardID].Mempool.Add(tx)
+	}
+}
+
+// relayReceipts hands the receipts of the cross-shard transactions debited by blk to their
+// target shards. A target shard can credit a receipt once the source block header has reached
+// it through the header chain.
+func (sim *Simulation) relayReceipts(blk *block.Block) {
+	headerDelays := make(map[int]float64)
+	for _, tx := range blk.Transactions {
+		for _, targetShard := range tx.TargetShards {
+			if _, ok := headerDelays[targetShard]; !ok {
+				headerDelays[targetShard] = utils.SimulateNetworkBlockHeaderDelay(&sim.Config)
+			}
+			sim.Shards[targetShard].Mempool.AddReceipt(&block.Receipt{
+				Tx:              tx,
+				SourceShardID:   blk.ShardID,
+				SourceBlockHash: blk.Hash,
+				TargetShardID:   targetShard,
+				DebitedAt:       blk.Timestamp,
+				AvailableAt:     float64(blk.Timestamp) + headerDelays[targetShard]/1000.0,
+			})
+		}
+	}
+}
+
+// isReceiptValid reports whether the block that debited a receipt is still on the canonical
+// chain of its shard. Receipts from orphaned or reverted blocks are never credited.
+func (sim *Simulation) isReceiptValid(r *block.Receipt) bool {
+	source := sim.Shards[r.SourceShardID]
+	sourceBlock := source.Tree.Block(r.SourceBlockHash)
+	return sourceBlock != nil && !source.Tree.IsInvalid(r.SourceBlockHash) && source.Tree.IsAncestor(sourceBlock, source.LatestBlock())
+}
+
+// publishExecutionReceipt gossips the ER header of a block to the whole network and sends
+// the ER body to the verifiers of the shard
+func (sim *Simulation) publishExecutionReceipt(producerNode *node.Node, blk *block.Block, shardNodes []*node.Node) {
+	erHeader, erBody := block.NewExecutionReceipt(blk)
+
+	verifiers := excludeNodes(shardNodes, map[int]bool{producerNode.ID: true})
+	if sim.Config.NumERVerifiers > 0 && sim.Config.NumERVerifiers < len(verifiers) {
+		rand.Shuffle(len(verifiers), func(i, j int) {
+			verifiers[i], verifiers[j] = verifiers[j], verifiers[i]
+		})
+		verifiers = verifiers[:sim.Config.NumERVerifiers]
+	}
+	sim.ExecutionReceipts[blk.Hash] = &metrics.ReceiptCoverage{
+		ShardID:   blk.ShardID,
+		Verifiers: len(verifiers),
+	}
+
+	for _, e := range sim.crossPartition(producerNode.ID, producerNode.BroadcastERHeader(&sim.Config, erHeader, sim.getNodes(), sim.CurrentTime)) {
+		heap.Push(sim.EventQueue, e)
+	}
+	for _, e := range sim.crossPartition(producerNode.ID, producerNode.SendERBody(&sim.Config, erBody, verifiers, sim.CurrentTime)) {
+		heap.Push(sim.EventQueue, e)
+	}
+}
+
+// gossipBlock spreads a block from its producer through the peer graph. Every node relays it,
+// but only the shard members are meant to receive it.
+func (sim *Simulation) gossipBlock(producerNode *node.Node, blk *block.Block, shardNodes []*node.Node) {
+	audience := make(map[int]bool)
+	for _, n := range shardNodes {
+		if n.ID != producerNode.ID {
+			audience[n.ID] = true
+		}
+	}
+	g := network.NewGossip(blk, sim.Config.BlockSize, producerNode.ID, float64(sim.CurrentTime), audience)
+	sim.BlockGossips[blk.ShardID] = append(sim.BlockGossips[blk.ShardID], g)
+	sim.relayGossip(g, producerNode.ID, -1, 0, g.Start)
+}
+
+// relayGossip forwards a gossip message from node id to some of its peers, each over its own link
+func (sim *Simulation) relayGossip(g *network.Gossip, id int, from int, hops int, t float64) {
+	shardID := -1
+	if blk, ok := g.Payload.(*block.Block); ok {
+		shardID = blk.ShardID
+	}
+	for _, peer := range sim.Network.Relays(&sim.Config, id, from) {
+		g.Sent++
+		delay := sim.Network.LinkDelay(&sim.Config, id, peer, g.Size)
+		if sim.Loss != nil {
+			extra, delivered := sim.Loss.SendGossip(id, peer)
+			if !delivered {
+				g.Lost++
+				continue
+			}
+			delay += extra
+		}
+		if sim.Links != nil {
+			delay += sim.Links.Reserve(id, peer, shardID, g.Size, t)
+		}
+		sent := t
+		if p := sim.partitionBetween(id, peer); p != nil {
+			if sim.Config.PartitionMode != config.HoldAcrossPartition {
+				p.Dropped++
+				continue
+			}
+			p.Held++
+			sent = max(t, float64(p.End))
+		}
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: sent + delay/1000.0,
+			Type:      event.GossipEvent,
+			NodeID:    peer,
+			Data:      &network.Hop{Gossip: g, From: id, To: peer, Count: hops + 1},
+		})
+	}
+}
+
+func (sim *Simulation) handleGossipEvent(e *event.Event) {
+	hop := e.Data.(*network.Hop)
+	if !hop.Gossip.Receive(hop, e.Timestamp) {
+		return
+	}
+	// As with estimated propagation, blocks only reach peers this way when forks are enabled.
+	// Otherwise peers fetch them when syncing.
+	if sim.Config.EnableForks && hop.Gossip.Audience[hop.To] {
+		sim.handleMessageEvent(&event.Event{
+			Timestamp: e.Timestamp,
+			Type:      event.MessageEvent,
+			NodeID:    hop.To,
+			Data:      hop.Gossip.Payload,
+		})
+	}
+	sim.relayGossip(hop.Gossip, hop.To, hop.From, hop.Count, e.Timestamp)
+}
+
+// recordGossipDelays turns the receive times of every gossiped block into the mean broadcast
+// delay to its shard members, the figure estimated propagation reports
+func (sim *Simulation) recordGossipDelays() {
+	for shardID, gossips := range sim.BlockGossips {
+		for _, g := range gossips {
+			total, received := 0.0, 0
+			for id := range g.Audience {
+				if t, ok := g.ReceivedAt[id]; ok {
+					total += (t - g.Start) * 1000.0
+					received++
+				}
+			}
+			if received > 0 {
+				sim.NetworkBlockBroadcastDelays[shardID] = append(sim.NetworkBlockBroadcastDelays[shardID], int64(total/float64(received)))
+			}
+		}
 	}
 }
 
 func (sim *Simulation) handleMessageEvent(e *event.Event) {
-	n := sim.Nodes[e.NodeID]
+	n := sim.getNode(e.NodeID)
+	if n == nil {
+		return
+	}
 	n.ProcessMessage(e)
 
-	blk, ok := e.Data.(*block.Block)
-	if ok {
-		s := sim.Shards[blk.ShardID]
-		s.AddBlock(blk)
+	switch msg := e.Data.(type) {
+	case *block.Block:
+		s := sim.Shards[msg.ShardID]
+		s.AddBlock(msg)
+	case *block.BeaconBlock:
+		sim.BeaconPropagationDelays = append(sim.BeaconPropagationDelays, int64((e.Timestamp-float64(msg.Timestamp))*1000.0))
+	case *block.ExecutionReceiptHeader:
+		delay := (e.Timestamp - float64(msg.Timestamp)) * 1000.0
+		sim.NetworkERHeaderDelays[msg.ShardID] = append(sim.NetworkERHeaderDelays[msg.ShardID], int64(delay))
+	case *block.ExecutionReceiptBody:
+		delay := (e.Timestamp - float64(msg.Header.Timestamp)) * 1000.0
+		sim.NetworkERBodyDelays[msg.Header.ShardID] = append(sim.NetworkERBodyDelays[msg.Header.ShardID], int64(delay))
+		if receipt, ok := sim.ExecutionReceipts[msg.Header.BlockHash]; ok && n.VerifiedERs[msg.Header.BlockHash] {
+			receipt.Verified++
+		}
+	case *headersync.Request:
+		sim.serveHeaders(n, msg, e.Timestamp)
+	case *headersync.Response:
+		linked := n.LatestBlockHeaderID(msg.Request.ShardID)
+		for _, header := range msg.Headers {
+			n.HandleBlockHeader(header)
+		}
+		sim.HeaderSync.Responses++
+		if gap := sim.HeaderSync.Open(n.ID, msg.Request.ShardID); gap != nil && gap.Pending == msg.Request {
+			gap.Pending = nil
+			if n.LatestBlockHeaderID(msg.Request.ShardID) > linked {
+				gap.Attempts = 0
+			}
+		}
+	}
+
+	if sim.HeaderSync != nil {
+		switch e.Data.(type) {
+		case *block.BlockHeader, *block.BeaconBlock, *headersync.Response:
+			sim.syncHeaders(n, e.Timestamp)
+		}
+	}
+}
+
+// syncHeaders checks the header chains of a node for gaps and asks a peer for the missing
+// headers, unless a request for them is still within its timeout or the node gave up on them
+func (sim *Simulation) syncHeaders(n *node.Node, now float64) {
+	retries, timeout := sim.Config.HeaderSyncRetries, sim.Config.HeaderSyncTimeout
+	if retries <= 0 {
+		retries = config.HeaderSyncRetries
+	}
+	if timeout <= 0 {
+		timeout = config.HeaderSyncTimeout
+	}
+	for shardID := range sim.Shards {
+		from, to, missing := n.HeaderGap(shardID)
+		if !missing {
+			sim.HeaderSync.Fill(n.ID, shardID, now)
+			continue
+		}
+		gap := sim.HeaderSync.Detect(n.ID, shardID, from, to, now)
+		if gap.Pending != nil {
+			if now-gap.Pending.SentAt < float64(timeout)/1000.0 {
+				continue
+			}
+			sim.HeaderSync.TimedOut++
+			gap.Pending = nil
+		}
+		if gap.Attempts >= retries {
+			continue
+		}
+
+		peers := excludeNodes(sim.getNodes(), map[int]bool{n.ID: true})
+		if len(peers) == 0 {
+			return
+		}
+		peer := peers[rand.Intn(len(peers))]
+		req := &headersync.Request{Requester: n.ID, Peer: peer.ID, ShardID: shardID, From: from, To: to, SentAt: now}
+		gap.Pending = req
+		gap.Requests++
+		gap.Attempts++
+		sim.HeaderSync.Requests++
+		events := []*event.Event{{
+			Timestamp: now + utils.SimulateNetworkHeaderSyncDelay(&sim.Config, sim.Config.BlockHeaderSize)/1000.0,
+			Type:      event.MessageEvent,
+			NodeID:    peer.ID,
+			Data:      req,
+		}}
+		for _, e := range sim.crossPartition(n.ID, events) {
+			heap.Push(sim.EventQueue, e)
+		}
 	}
 }
 
+// serveHeaders answers a header sync request with the requested headers the peer holds
+func (sim *Simulation) serveHeaders(peer *node.Node, req *headersync.Request, now float64) {
+	response := headersync.Serve(peer.BlockHeaderChain[req.ShardID], req, sim.Config.HeaderSyncBatch)
+	sim.HeaderSync.HeadersServed += len(response.Headers)
+	size := max(1, len(response.Headers)) * sim.Config.BlockHeaderSize
+	events := []*event.Event{{
+		Timestamp: now + utils.SimulateNetworkHeaderSyncDelay(&sim.Config, size)/1000.0,
+		Type:      event.MessageEvent,
+		NodeID:    req.Requester,
+		Data:      response,
+	}}
+	for _, e := range sim.crossPartition(peer.ID, events) {
+		heap.Push(sim.EventQueue, e)
+	}
+}
+
+// handleHeaderLagEvent samples how many blocks the header chain of every node trails each shard's tip
+func (sim *Simulation) handleHeaderLagEvent() {
+	for shardID, s := range sim.Shards {
+		tip := s.GetLatestBlockID()
+		total, worst := 0, 0
+		for _, n := range sim.Nodes {
+			lag := max(0, tip-n.LatestBlockHeaderID(shardID))
+			total += lag
+			worst = max(worst, lag)
+
+			record, exists := sim.NodeHeaderLags[n.ID]
+			if !exists {
+				record = &metrics.NodeHeaderLag{}
+				sim.NodeHeaderLags[n.ID] = record
+			}
+			record.Samples++
+			record.Total += lag
+			record.Max = max(record.Max, lag)
+		}
+		sample := metrics.HeaderLagSample{Time: sim.CurrentTime, Max: worst}
+		if len(sim.Nodes) > 0 {
+			sample.Mean = float64(total) / float64(len(sim.Nodes))
+		}
+		sim.HeaderLagSamples[shardID] = append(sim.HeaderLagSamples[shardID], sample)
+	}
+
+	if sim.CurrentTime+sim.Config.TimeStep < sim.Config.SimulationTime {
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.CurrentTime + sim.Config.TimeStep),
+			Type:      event.HeaderLagEvent,
+		})
+	}
+}
+
+// handlePartitionEvent starts a scheduled partition, or heals it when it is already active
+func (sim *Simulation) handlePartitionEvent(e *event.Event) {
+	p := e.Data.(*partition.Partition)
+	if !p.Active {
+		ids := make([]int, 0, len(sim.Nodes)+len(sim.Operators))
+		shards := make(map[int]int)
+		for _, n := range append(sim.getNodes(), sim.getOperators()...) {
+			ids = append(ids, n.ID)
+			shards[n.ID] = n.AssignedShard
+		}
+		var regions map[int]int
+		if sim.Geography != nil {
+			regions = sim.Geography.NodeRegion
+		}
+		p.Split(ids, shards, regions)
+		p.Active = true
+		log := fmt.Sprintf("[Partition] Isolated %d of %d nodes by %s at time %d until time %d", len(p.Isolated), len(ids), p.Partition.Split, sim.CurrentTime, p.End)
+		sim.Logs = append(sim.Logs, log)
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(p.End),
+			Type:      event.PartitionEvent,
+			Data:      p,
+		})
+		return
+	}
+
+	p.Active = false
+	p.Healed = true
+	for shardID := range sim.Shards {
+		lowest, highest := sim.headerRange(shardID)
+		p.HeaderSpread[shardID] = highest - lowest
+		if sim.Config.EnableForks {
+			p.ForkDepth[shardID] = sim.forkDepth(p, shardID)
+		}
+	}
+	log := fmt.Sprintf("[Partition] Healed at time %d after dropping %d and holding %d messages", sim.CurrentTime, p.Dropped, p.Held)
+	sim.Logs = append(sim.Logs, log)
+	sim.checkPartitionRecovery(p)
+}
+
+// handlePartitionCheckEvent tests whether the network agrees again after a partition healed
+func (sim *Simulation) handlePartitionCheckEvent(e *event.Event) {
+	sim.checkPartitionRecovery(e.Data.(*partition.Partition))
+}
+
+// checkPartitionRecovery records when header chains and, with forks, shard chains agree after
+// the partition healed, and checks again one time step later while they do not
+func (sim *Simulation) checkPartitionRecovery(p *partition.Partition) {
+	elapsed := float64(sim.CurrentTime - p.End)
+	if p.HeadersAgreeAfter < 0 {
+		agree := true
+		for shardID := range sim.Shards {
+			lowest, highest := sim.headerRange(shardID)
+			agree = agree && lowest == highest
+		}
+		if agree {
+			p.HeadersAgreeAfter = elapsed
+		}
+	}
+	if sim.Config.EnableForks && p.ChainsAgreeAfter < 0 {
+		agree := true
+		for shardID := range sim.Shards {
+			agree = agree && sim.forkDepth(p, shardID) == 0
+		}
+		if agree {
+			p.ChainsAgreeAfter = elapsed
+		}
+	}
+
+	pending := p.HeadersAgreeAfter < 0 || (sim.Config.EnableForks && p.ChainsAgreeAfter < 0)
+	if pending && sim.CurrentTime+sim.Config.TimeStep < sim.Config.SimulationTime {
+		heap.Push(sim.EventQueue, &event.Event{
+			Timestamp: float64(sim.CurrentTime + sim.Config.TimeStep),
+			Type:      event.PartitionCheckEvent,
+			Data:      p,
+		})
+	}
+}
+
+// headerRange returns the lowest and highest latest header ID of a shard among the nodes
+func (sim *Simulation) headerRange(shardID int) (int, int) {
+	lowest, highest := -1, -1
+	for _, n := range sim.Nodes {
+		id := n.LatestBlockHeaderID(shardID)
+		if lowest < 0 || id < lowest {
+			lowest = id
+		}
+		highest = max(highest, id)
+	}
+	return lowest, highest
+}
+
+// forkDepth returns how many blocks the side with the lower head of a shard has to reorganise
+// away to join the other side, 0 when one head extends the other. The head of a side is the
+// highest head any of its shard members and operators follows.
+func (sim *Simulation) forkDepth(p *partition.Partition, shardID int) int {
+	heads := make(map[bool]*block.Block)
+	for _, n := range append(sim.getShardNodes(shardID), sim.getShardOperators(shardID)...) {
+		head := n.HeadBlock(shardID)
+		if best, exists := heads[p.Isolated[n.ID]]; !exists || head.ID > best.ID {
+			heads[p.Isolated[n.ID]] = head
+		}
+	}
+	isolated, rest := heads[true], heads[false]
+	if isolated == nil || rest == nil {
+		return 0
+	}
+	ancestor := sim.Shards[shardID].Tree.CommonAncestor(isolated, rest)
+	return min(isolated.ID, rest.ID) - ancestor.ID
+}
+
+// partitionBetween returns the active partition standing between nodes a and b, if any
+func (sim *Simulation) partitionBetween(a, b int) *partition.Partition {
+	for _, p := range sim.Partitions {
+		if p.Separates(a, b) {
+			return p
+		}
+	}
+	return nil
+}
+
+// crossPartition filters the messages a sender is about to deliver. Messages to the other side
+// of an active partition are dropped, or held and sent again once it heals.
+func (sim *Simulation) crossPartition(sender int, events []*event.Event) []*event.Event {
+	delivered := make([]*event.Event, 0, len(events))
+	for _, e := range events {
+		p := sim.partitionBetween(sender, e.NodeID)
+		if p == nil {
+			delivered = append(delivered, e)
+			continue
+		}
+		if sim.Config.PartitionMode != config.HoldAcrossPartition {
+			p.Dropped++
+			continue
+		}
+		p.Held++
+		e.Timestamp = float64(p.End) + max(0, e.Timestamp-float64(sim.CurrentTime))
+		delivered = append(delivered, e)
+	}
+	return delivered
+}
+
+// reachablePeers leaves out the peers on the other side of an active partition
+func (sim *Simulation) reachablePeers(id int, peers []*node.Node) []*node.Node {
+	if len(sim.Partitions) == 0 {
+		return peers
+	}
+	reachable := make([]*node.Node, 0, len(peers))
+	for _, peerNode := range peers {
+		if sim.partitionBetween(id, peerNode.ID) == nil {
+			reachable = append(reachable, peerNode)
+		}
+	}
+	return reachable
+}
+
 func (sim *Simulation) handleMetricsEvent() {
 	sim.Metrics.Collect(
 		sim.CurrentTime,
@@ -290,6 +1505,66 @@ func (sim *Simulation) handleMetricsEvent() {
 		sim.Logs,
 		sim.currentStepMaliciousShardRotations,
 	)
+	if sim.Config.BlockDelayModel != config.NormalDelay || sim.Config.HeaderDelayModel != config.NormalDelay || sim.Config.DownloadDelayModel != config.NormalDelay {
+		sim.Metrics.CollectLatency(&sim.Config)
+	}
+	sim.Metrics.CollectHeaderLag(sim.HeaderLagSamples, sim.NodeHeaderLags, sim.ProducerLags)
+	if sim.HeaderSync != nil {
+		sim.Metrics.CollectHeaderSync(sim.HeaderSync)
+	}
+	if sim.Grinder != nil {
+		sim.Metrics.CollectGrinding(sim.Grinder, sim.GrindingSamples)
+	}
+	sim.Metrics.CollectCompromise(sim.Compromise, sim.Takeover, sim.Config.NumShards, sim.CurrentTime)
+	sim.Metrics.CollectSecurityModel(sim.Compromise, &sim.Config, sim.Nodes, sim.Operators)
+	if sim.Withholding != nil {
+		sim.Metrics.CollectWithholding(sim.Withholding, sim.Config.BlockWithholding)
+	}
+	sim.Metrics.CollectServing(sim.Serving, sim.Nodes, sim.Operators)
+	if sim.Eclipse != nil {
+		sim.Metrics.CollectEclipse(sim.Eclipse)
+	}
+	if sim.Corruption != nil {
+		sim.Metrics.CollectCorruption(sim.Corruption, sim.Compromise, &sim.Config)
+	}
+	sim.Metrics.CollectExecutionReceipts(sim.NetworkERHeaderDelays, sim.NetworkERBodyDelays, sim.ExecutionReceipts)
+	for _, record := range sim.FraudRecords {
+		record.BuiltOnTop = sim.Shards[record.ShardID].Tree.CountDescendants(record.BlockHash)
+	}
+	sim.Metrics.CollectFraudProofs(sim.FraudRecords)
+	if sim.Beacon != nil {
+		sim.Metrics.CollectBeacon(sim.Beacon.Blocks, sim.Beacon.PendingHeaders(), sim.BeaconPropagationDelays, &sim.Config)
+	}
+	if sim.Config.EnableDAS {
+		sim.Metrics.CollectDAS(sim.DASRecords, sim.DASSamplingDelays, &sim.Config)
+	}
+	if sim.Network != nil {
+		sim.Metrics.CollectGossip(sim.Network, sim.BlockGossips)
+	}
+	if sim.Loss != nil {
+		sim.Metrics.CollectLoss(sim.Loss)
+	}
+	if sim.Links != nil {
+		sim.Metrics.CollectBandwidth(sim.Links, sim.Shards, sim.Config.SimulationTime)
+	}
+	if sim.Geography != nil {
+		sim.Metrics.CollectGeography(sim.Geography, sim.Shards, sim.Operators, sim.RegionDownloadDelays, sim.BlockGossips, sim.Network)
+	}
+	if len(sim.Partitions) > 0 {
+		sim.Metrics.CollectPartitions(sim.Partitions, &sim.Config)
+	}
+	if sim.StateSync != nil {
+		sim.Metrics.CollectStateSync(sim.StateSyncRecords, sim.SkippedSyncingWinners, sim.MissedSlotsWhileSyncing, sim.Shards, &sim.Config)
+	}
+	if len(sim.FinalityGadgets) > 0 {
+		sim.Metrics.CollectFinality(sim.Shards, sim.FinalityGadgets, &sim.Config)
+	}
+	if sim.Workload != nil {
+		sim.Metrics.CollectWorkload(sim.Shards, sim.Config.SimulationTime)
+		if sim.Config.CrossShardRatio > 0 {
+			sim.Metrics.CollectCrossShard(sim.Shards, sim.Config.BlockProductionInterval)
+		}
+	}
 
 	// Reset the malicious rotation counter for the next interval
 	sim.currentStepMaliciousShardRotations = 0
@@ -325,6 +1600,32 @@ func (sim *Simulation) getNodes() []*node.Node {
 	return nodes
 }
 
+func (sim *Simulation) getOperators() []*node.Node {
+	nodes := []*node.Node{}
+	for _, n := range sim.Operators {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// getNode looks a node up by ID among both regular nodes and operators
+func (sim *Simulation) getNode(nodeID int) *node.Node {
+	if n, ok := sim.Nodes[nodeID]; ok {
+		return n
+	}
+	return sim.Operators[nodeID]
+}
+
+func excludeNodes(nodes []*node.Node, excluded map[int]bool) []*node.Node {
+	remaining := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if !excluded[n.ID] {
+			remaining = append(remaining, n)
+		}
+	}
+	return remaining
+}
+
 func (sim *Simulation) getShardOperators(shardID int) []*node.Node {
 	nodes := []*node.Node{}
 	for _, n := range sim.Operators {
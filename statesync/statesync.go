@@ -0,0 +1,106 @@
+// statesync/statesync.go
+
+package statesync
+
+import (
+	"sharding/block"
+	"sharding/config"
+)
+
+// Cost is what a node joining a shard downloads and re-executes before it holds the shard state
+type Cost struct {
+	Bytes          int64
+	ReplayedBlocks int
+}
+
+// Duration returns the time in milliseconds the transfer and the replay take, without network latency
+func (c Cost) Duration(cfg *config.Config) float64 {
+	transmission := (float64(c.Bytes) * 8.0) / (float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+	return transmission + float64(c.ReplayedBlocks)*cfg.BlockReplayTime
+}
+
+// Strategy computes the cost of syncing to the head of a shard's canonical chain
+type Strategy interface {
+	Cost(chain []*block.Block) Cost
+}
+
+// NewStrategy returns the strategy configured in cfg, or nil when rotated nodes skip state sync
+func NewStrategy(cfg *config.Config) Strategy {
+	return NewStrategyFor(cfg.StateSync, cfg)
+}
+
+// NewStrategyFor returns the given strategy with the parameters of cfg
+func NewStrategyFor(strategy config.StateSyncStrategy, cfg *config.Config) Strategy {
+	switch strategy {
+	case config.FullStateSync:
+		return &FullSync{Config: cfg}
+	case config.SnapshotSync:
+		return &SnapshotSync{Config: cfg}
+	case config.StatelessSync:
+		return &StatelessSync{Config: cfg}
+	default:
+		return nil
+	}
+}
+
+// StateSize returns the size in bytes of the state left by a chain, growing with every block and transaction
+func StateSize(cfg *config.Config, chain []*block.Block) int64 {
+	size := cfg.GenesisStateSize
+	for _, blk := range chain {
+		size += int64(cfg.StateGrowthPerBlock + cfg.StateGrowthPerTx*transactionCount(cfg, blk))
+	}
+	return size
+}
+
+// FullSync downloads every block since genesis and re-executes it
+type FullSync struct {
+	Config *config.Config
+}
+
+func (s *FullSync) Cost(chain []*block.Block) Cost {
+	return Cost{
+		Bytes:          int64(len(chain)) * int64(s.Config.BlockSize),
+		ReplayedBlocks: len(chain),
+	}
+}
+
+// SnapshotSync downloads the state at the latest snapshot and re-executes the blocks since then
+type SnapshotSync struct {
+	Config *config.Config
+}
+
+func (s *SnapshotSync) Cost(chain []*block.Block) Cost {
+	snapshotHeight := len(chain)
+	if s.Config.SnapshotInterval > 0 {
+		snapshotHeight -= len(chain) % s.Config.SnapshotInterval
+	}
+	recent := len(chain) - snapshotHeight
+	return Cost{
+		Bytes:          StateSize(s.Config, chain[:snapshotHeight]) + int64(recent)*int64(s.Config.BlockSize),
+		ReplayedBlocks: recent,
+	}
+}
+
+// StatelessSync keeps no state. It only downloads the head block with the witnesses of its transactions.
+type StatelessSync struct {
+	Config *config.Config
+}
+
+func (s *StatelessSync) Cost(chain []*block.Block) Cost {
+	if len(chain) == 0 {
+		return Cost{}
+	}
+	head := chain[len(chain)-1]
+	return Cost{
+		Bytes:          int64(s.Config.BlockSize + s.Config.WitnessSizePerTx*transactionCount(s.Config, head)),
+		ReplayedBlocks: 1,
+	}
+}
+
+// transactionCount returns the transactions in a block, nil transactions meaning a full block
+func transactionCount(cfg *config.Config, blk *block.Block) int {
+	if blk.Transactions == nil {
+		return cfg.TransactionsPerBlock
+	}
+	return len(blk.Transactions)
+}
@@ -0,0 +1,91 @@
+// statesync/statesync_test.go
+
+package statesync
+
+import (
+	"sharding/block"
+	"sharding/config"
+	"testing"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		BlockSize:            1000,
+		TransactionsPerBlock: 10,
+		NetworkBandwidth:     8,
+		GenesisStateSize:     5000,
+		StateGrowthPerBlock:  100,
+		StateGrowthPerTx:     2,
+		SnapshotInterval:     4,
+		BlockReplayTime:      3,
+		WitnessSizePerTx:     20,
+	}
+}
+
+// chain returns length full blocks, except the head which holds the given transactions
+func chain(length, headTxs int) []*block.Block {
+	blocks := make([]*block.Block, length)
+	for i := range blocks {
+		blocks[i] = &block.Block{ID: i + 1}
+	}
+	if length > 0 {
+		blocks[length-1].Transactions = make([]*block.Transaction, headTxs)
+	}
+	return blocks
+}
+
+func TestStateSizeGrowsWithBlocksAndTransactions(t *testing.T) {
+	cfg := testConfig()
+	// Four full blocks of 10 transactions and a head of 3
+	if got, want := StateSize(cfg, chain(5, 3)), int64(5000+4*(100+20)+(100+6)); got != want {
+		t.Errorf("StateSize = %d, want %d", got, want)
+	}
+	if got := StateSize(cfg, nil); got != cfg.GenesisStateSize {
+		t.Errorf("StateSize of an empty chain = %d, want the genesis state", got)
+	}
+}
+
+func TestStrategyCosts(t *testing.T) {
+	cfg := testConfig()
+	blocks := chain(10, 3)
+	cases := []struct {
+		strategy config.StateSyncStrategy
+		want     Cost
+	}{
+		// Every block downloaded and replayed
+		{config.FullStateSync, Cost{Bytes: 10_000, ReplayedBlocks: 10}},
+		// The snapshot at height 8, then blocks 9 and 10
+		{config.SnapshotSync, Cost{Bytes: 5000 + 8*120 + 2*1000, ReplayedBlocks: 2}},
+		// The head block with a witness per transaction
+		{config.StatelessSync, Cost{Bytes: 1000 + 3*20, ReplayedBlocks: 1}},
+	}
+	for _, c := range cases {
+		if got := NewStrategyFor(c.strategy, cfg).Cost(blocks); got != c.want {
+			t.Errorf("%v: cost %+v, want %+v", c.strategy, got, c.want)
+		}
+	}
+	if NewStrategyFor(config.NoStateSync, cfg) != nil {
+		t.Error("skipping state sync should have no strategy")
+	}
+}
+
+func TestSnapshotAtEveryBlock(t *testing.T) {
+	cfg := testConfig()
+	cfg.SnapshotInterval = 0
+	blocks := chain(6, 10)
+	want := Cost{Bytes: StateSize(cfg, blocks)}
+	if got := (&SnapshotSync{Config: cfg}).Cost(blocks); got != want {
+		t.Errorf("cost %+v, want only the latest state %+v", got, want)
+	}
+	if got := (&StatelessSync{Config: cfg}).Cost(nil); got != (Cost{}) {
+		t.Errorf("stateless sync of an empty chain cost %+v", got)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	cfg := testConfig()
+	// 8000 bits over 8 Mbps take one millisecond, plus two replays of 3
+	if got := (Cost{Bytes: 1000, ReplayedBlocks: 2}).Duration(cfg); got != 7 {
+		t.Errorf("Duration = %v, want 7", got)
+	}
+}
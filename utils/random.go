@@ -4,69 +4,92 @@ import (
 	"math"
 	"math/rand"
 	"sharding/config"
+	"sharding/latency"
 )
 
 // SimulateNetworkBlockDelay calculates network delay for full block propagation
 func SimulateNetworkBlockDelay(cfg *config.Config, NumOperators int) float64 {
-	// Randomly choose network parameters
-	networkDelayMean := cfg.MinNetworkDelayMean + rand.Float64()*(cfg.MaxNetworkDelayMean-cfg.MinNetworkDelayMean)
-	networkDelayStd := cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd)
-	gossipFanout := cfg.MinGossipFanout + rand.Intn(cfg.MaxGossipFanout-cfg.MinGossipFanout+1)
+	return gossipDelay(cfg, cfg.BlockDelayModel, gossipHops(cfg, NumOperators), int64(cfg.BlockSize))
+}
 
-	// Calculate number of hops in gossip protocol
-	numHops := math.Ceil(math.Log(float64(NumOperators)) / math.Log(float64(gossipFanout)))
+// SimulateNetworkBlockHeaderDelay calculates network delay for block header propagation
+func SimulateNetworkBlockHeaderDelay(cfg *config.Config) float64 {
+	return gossipDelay(cfg, cfg.HeaderDelayModel, gossipHops(cfg, cfg.NumNodes), int64(cfg.BlockHeaderSize))
+}
 
-	totalDelay := 0.0
-	for i := 0.0; i < numHops; i++ {
-		// Per-hop latency with jitter
-		hopLatency := networkDelayMean +
-			rand.NormFloat64()*networkDelayStd/1000.0
+// SimulateNetworkBlockDownloadDelay calculates network delay for block downloads
+func SimulateNetworkBlockDownloadDelay(cfg *config.Config) float64 {
+	return transferDelay(cfg, int64(cfg.BlockSize))
+}
 
-		// Transmission delay (size in bits / bandwidth in bps)
-		transmissionDelay := (float64(cfg.BlockSize) * 8.0) /
-			(float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+// SimulateNetworkERHeaderDelay calculates network delay for execution receipt header gossip
+func SimulateNetworkERHeaderDelay(cfg *config.Config) float64 {
+	return gossipDelay(cfg, cfg.HeaderDelayModel, gossipHops(cfg, cfg.NumNodes), int64(cfg.ERHeaderSize))
+}
 
-		totalDelay += hopLatency + transmissionDelay
-	}
-	return totalDelay
+// SimulateNetworkERBodyDelay calculates network delay for sending an execution receipt body to a verifier
+func SimulateNetworkERBodyDelay(cfg *config.Config) float64 {
+	return transferDelay(cfg, int64(cfg.ERBodySize))
 }
 
-// SimulateNetworkBlockHeaderDelay calculates network delay for block header propagation
-func SimulateNetworkBlockHeaderDelay(cfg *config.Config) float64 {
-	// Randomly choose network parameters
-	networkDelayMean := cfg.MinNetworkDelayMean + rand.Float64()*(cfg.MaxNetworkDelayMean-cfg.MinNetworkDelayMean)
-	networkDelayStd := cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd)
-	gossipFanout := cfg.MinGossipFanout + rand.Intn(cfg.MaxGossipFanout-cfg.MinGossipFanout+1)
+// SimulateNetworkFraudProofDelay calculates network delay for fraud proof gossip across the network
+func SimulateNetworkFraudProofDelay(cfg *config.Config) float64 {
+	return gossipDelay(cfg, cfg.HeaderDelayModel, gossipHops(cfg, cfg.NumNodes), int64(cfg.FraudProofSize))
+}
 
-	// Calculate number of hops in gossip protocol
-	numHops := math.Ceil(math.Log(float64(cfg.NumNodes)) / math.Log(float64(gossipFanout)))
+// SimulateNetworkVoteDelay calculates network delay for a finality vote gossiped within a shard committee
+func SimulateNetworkVoteDelay(cfg *config.Config, committeeSize int) float64 {
+	// At least one hop for tiny committees
+	hops := math.Max(1, gossipHops(cfg, committeeSize))
+	return gossipDelay(cfg, cfg.HeaderDelayModel, hops, int64(cfg.VoteSize))
+}
 
-	totalDelay := 0.0
-	for i := 0.0; i < numHops; i++ {
-		// Per-hop latency with jitter
-		hopLatency := networkDelayMean +
-			rand.NormFloat64()*networkDelayStd/1000.0
+// SimulateNetworkBeaconBlockDelay calculates network delay for beacon block gossip across the network,
+// size growing with the crosslinks carried
+func SimulateNetworkBeaconBlockDelay(cfg *config.Config, size int) float64 {
+	return gossipDelay(cfg, cfg.BlockDelayModel, gossipHops(cfg, cfg.NumNodes), int64(size))
+}
 
-		// Transmission delay (size in bits / bandwidth in bps)
-		transmissionDelay := (float64(cfg.BlockHeaderSize) * 8.0) /
-			(float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+// SimulateNetworkChunkDelay calculates network delay for fetching one erasure-coded chunk of a block
+func SimulateNetworkChunkDelay(cfg *config.Config, size int) float64 {
+	return transferDelay(cfg, int64(size))
+}
 
-		totalDelay += hopLatency + transmissionDelay
-	}
+// SimulateNetworkStateSyncDelay calculates network delay for downloading size bytes of shard state and blocks
+func SimulateNetworkStateSyncDelay(cfg *config.Config, size int64) float64 {
+	return transferDelay(cfg, size)
+}
 
-	return totalDelay
+// SimulateNetworkHeaderSyncDelay calculates network delay for a header sync request or response of size bytes
+func SimulateNetworkHeaderSyncDelay(cfg *config.Config, size int) float64 {
+	// Single hop drawn from the header delay model
+	return latency.NewDelayModel(cfg, cfg.HeaderDelayModel).Sample() + transmissionDelay(cfg, int64(size))
 }
 
-// SimulateNetworkBlockDownloadDelay calculates network delay for block downloads
-func SimulateNetworkBlockDownloadDelay(cfg *config.Config) float64 {
-	networkDelayMean := cfg.MinNetworkDelayMean + rand.Float64()*(cfg.MaxNetworkDelayMean-cfg.MinNetworkDelayMean)
-	networkDelayStd := cfg.MinNetworkDelayStd + rand.Float64()*(cfg.MaxNetworkDelayStd-cfg.MinNetworkDelayStd)
+// gossipHops returns the number of hops gossip with a randomly chosen fanout needs to reach numNodes nodes
+func gossipHops(cfg *config.Config, numNodes int) float64 {
+	gossipFanout := cfg.MinGossipFanout + rand.Intn(cfg.MaxGossipFanout-cfg.MinGossipFanout+1)
+	return math.Ceil(math.Log(float64(numNodes)) / math.Log(float64(gossipFanout)))
+}
 
-	// Basic delay calculation
-	delay := networkDelayMean + rand.NormFloat64()*networkDelayStd/1000.0
+// gossipDelay adds up hops of a message of size bytes, each hop paying a latency drawn from the
+// given delay model plus the transmission delay
+func gossipDelay(cfg *config.Config, distribution config.DelayDistribution, hops float64, size int64) float64 {
+	model := latency.NewDelayModel(cfg, distribution)
+
+	totalDelay := 0.0
+	for i := 0.0; i < hops; i++ {
+		totalDelay += model.Sample() + transmissionDelay(cfg, size)
+	}
+	return totalDelay
+}
 
-	// Add transmission delay based on block size
-	transmissionDelay := (float64(cfg.BlockSize) * 8.0) / (float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
+// transferDelay is the delay of sending size bytes straight to a peer, one hop drawn from the download delay model
+func transferDelay(cfg *config.Config, size int64) float64 {
+	return latency.NewDelayModel(cfg, cfg.DownloadDelayModel).Sample() + transmissionDelay(cfg, size)
+}
 
-	return delay + transmissionDelay
+// transmissionDelay is the time to put size bytes on the wire (size in bits / bandwidth in bps), in milliseconds
+func transmissionDelay(cfg *config.Config, size int64) float64 {
+	return (float64(size) * 8.0) / (float64(cfg.NetworkBandwidth) * 1000000.0) * 1000.0
 }
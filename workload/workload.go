@@ -0,0 +1,224 @@
+// workload/workload.go
+
+package workload
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sharding/block"
+	"sharding/config"
+	"strconv"
+	"strings"
+)
+
+// ArrivalProcess produces transaction arrival times in increasing order
+type ArrivalProcess interface {
+	// Next returns the time of the next arrival and the shard it targets, -1 letting the
+	// generator pick one. It returns +Inf once the process is exhausted.
+	Next() (float64, int)
+}
+
+// NewArrivalProcess returns the arrival process configured in cfg, or nil for a saturated workload
+func NewArrivalProcess(cfg *config.Config) (ArrivalProcess, error) {
+	switch cfg.Workload {
+	case config.ConstantWorkload:
+		return &ConstantArrivals{Rate: cfg.TransactionRate}, nil
+	case config.PoissonWorkload:
+		return &PoissonArrivals{Rate: cfg.TransactionRate}, nil
+	case config.BurstyWorkload:
+		return &BurstyArrivals{
+			Rate:       cfg.TransactionRate,
+			Multiplier: cfg.BurstMultiplier,
+			Duration:   float64(cfg.BurstDuration),
+			Period:     float64(cfg.BurstPeriod),
+		}, nil
+	case config.TraceWorkload:
+		trace, err := LoadTrace(cfg.WorkloadTraceFile)
+		if err != nil {
+			return nil, err
+		}
+		return trace, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ConstantArrivals spaces transactions evenly at Rate per time unit
+type ConstantArrivals struct {
+	Rate float64
+	last float64
+}
+
+func (a *ConstantArrivals) Next() (float64, int) {
+	if a.Rate <= 0 {
+		return math.Inf(1), -1
+	}
+	a.last += 1 / a.Rate
+	return a.last, -1
+}
+
+// PoissonArrivals draws exponential gaps with mean 1/Rate
+type PoissonArrivals struct {
+	Rate float64
+	last float64
+}
+
+func (a *PoissonArrivals) Next() (float64, int) {
+	if a.Rate <= 0 {
+		return math.Inf(1), -1
+	}
+	a.last += rand.ExpFloat64() / a.Rate
+	return a.last, -1
+}
+
+// BurstyArrivals is a Poisson process whose rate is multiplied by Multiplier for the first
+// Duration time units of every Period
+type BurstyArrivals struct {
+	Rate       float64
+	Multiplier float64
+	Duration   float64
+	Period     float64
+	last       float64
+}
+
+func (a *BurstyArrivals) rateAt(t float64) float64 {
+	if a.Period > 0 && math.Mod(t, a.Period) < a.Duration {
+		return a.Rate * a.Multiplier
+	}
+	return a.Rate
+}
+
+func (a *BurstyArrivals) Next() (float64, int) {
+	peak := a.Rate * max(a.Multiplier, 1)
+	if peak <= 0 {
+		return math.Inf(1), -1
+	}
+	// Thinning: draw candidates at the peak rate and keep each with probability rate(t)/peak
+	for {
+		a.last += rand.ExpFloat64() / peak
+		if rand.Float64()*peak < a.rateAt(a.last) {
+			return a.last, -1
+		}
+	}
+}
+
+// TraceArrivals replays recorded arrivals
+type TraceArrivals struct {
+	Times  []float64
+	Shards []int
+	next   int
+}
+
+// LoadTrace reads a trace of "time[,shard]" lines, skipping blank lines and lines starting with #.
+// Arrivals without a shard are spread over the shards by the generator, shard IDs beyond
+// the configured shards wrap around.
+func LoadTrace(path string) (*TraceArrivals, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workload trace: %v", err)
+	}
+	defer f.Close()
+
+	trace := &TraceArrivals{Times: make([]float64, 0), Shards: make([]int, 0)}
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		t, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival time on line %d of workload trace: %v", lineNum, err)
+		}
+		shardID := -1
+		if len(fields) > 1 {
+			shardID, err = strconv.Atoi(strings.TrimSpace(fields[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid shard on line %d of workload trace: %v", lineNum, err)
+			}
+		}
+		if len(trace.Times) > 0 && t < trace.Times[len(trace.Times)-1] {
+			return nil, fmt.Errorf("workload trace is not sorted by time on line %d", lineNum)
+		}
+		trace.Times = append(trace.Times, t)
+		trace.Shards = append(trace.Shards, shardID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read workload trace: %v", err)
+	}
+	return trace, nil
+}
+
+func (a *TraceArrivals) Next() (float64, int) {
+	if a.next >= len(a.Times) {
+		return math.Inf(1), -1
+	}
+	a.next++
+	return a.Times[a.next-1], a.Shards[a.next-1]
+}
+
+// Generator turns an arrival process into transactions spread uniformly over the shards.
+// A CrossShardRatio share of them also touches between one and MaxShardsPerTx-1 other shards.
+type Generator struct {
+	Process         ArrivalProcess
+	NumShards       int
+	CrossShardRatio float64
+	MaxShardsPerTx  int
+	nextTime        float64
+	nextShard       int
+	nextID          int
+}
+
+func NewGenerator(process ArrivalProcess, cfg *config.Config) *Generator {
+	g := &Generator{
+		Process:         process,
+		NumShards:       cfg.NumShards,
+		CrossShardRatio: cfg.CrossShardRatio,
+		MaxShardsPerTx:  cfg.MaxShardsPerTx,
+	}
+	g.nextTime, g.nextShard = process.Next()
+	return g
+}
+
+// Until returns every transaction arriving up to time t that has not been returned yet
+func (g *Generator) Until(t float64) []*block.Transaction {
+	txs := make([]*block.Transaction, 0)
+	for g.nextTime <= t {
+		shardID := g.nextShard
+		if shardID < 0 {
+			shardID = rand.Intn(g.NumShards)
+		} else {
+			shardID %= g.NumShards
+		}
+		tx := &block.Transaction{ID: g.nextID, ShardID: shardID, CreatedAt: g.nextTime}
+		if g.CrossShardRatio > 0 && rand.Float64() < g.CrossShardRatio {
+			tx.TargetShards = g.targetShards(shardID)
+		}
+		txs = append(txs, tx)
+		g.nextID++
+		g.nextTime, g.nextShard = g.Process.Next()
+	}
+	return txs
+}
+
+// targetShards picks the shards a cross-shard transaction credits, distinct from its source
+func (g *Generator) targetShards(sourceShard int) []int {
+	maxTargets := min(g.MaxShardsPerTx, g.NumShards) - 1
+	if maxTargets < 1 {
+		return nil
+	}
+	others := make([]int, 0, g.NumShards-1)
+	for shardID := 0; shardID < g.NumShards; shardID++ {
+		if shardID != sourceShard {
+			others = append(others, shardID)
+		}
+	}
+	rand.Shuffle(len(others), func(i, j int) {
+		others[i], others[j] = others[j], others[i]
+	})
+	return others[:1+rand.Intn(maxTargets)]
+}
@@ -0,0 +1,114 @@
+// workload/workload_test.go
+
+package workload
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sharding/config"
+	"testing"
+)
+
+func TestConstantArrivalsAreEvenlySpaced(t *testing.T) {
+	a := &ConstantArrivals{Rate: 4}
+	for i := 1; i <= 8; i++ {
+		if got, shard := a.Next(); math.Abs(got-float64(i)/4) > 1e-12 || shard != -1 {
+			t.Fatalf("arrival %d at %v in shard %d, want %v in any shard", i, got, shard, float64(i)/4)
+		}
+	}
+	if got, _ := (&ConstantArrivals{}).Next(); !math.IsInf(got, 1) {
+		t.Errorf("arrival at %v without a rate, want never", got)
+	}
+}
+
+// meanRate counts the arrivals of a process in [from, to) over n windows of period
+func meanRate(process ArrivalProcess, from, to, period float64, n int) float64 {
+	count := 0
+	for {
+		t, _ := process.Next()
+		if t >= period*float64(n) {
+			break
+		}
+		if offset := math.Mod(t, period); offset >= from && offset < to {
+			count++
+		}
+	}
+	return float64(count) / ((to - from) * float64(n))
+}
+
+func TestPoissonRate(t *testing.T) {
+	rate := meanRate(&PoissonArrivals{Rate: 5}, 0, 1, 1, 4_000)
+	if math.Abs(rate-5) > 0.25 {
+		t.Errorf("poisson process ran at %.2f per time unit, want 5", rate)
+	}
+}
+
+func TestBurstsRaiseTheRate(t *testing.T) {
+	process := func() ArrivalProcess {
+		return &BurstyArrivals{Rate: 2, Multiplier: 5, Duration: 10, Period: 40}
+	}
+	inside := meanRate(process(), 0, 10, 40, 200)
+	outside := meanRate(process(), 10, 40, 40, 200)
+	if math.Abs(inside-10) > 0.6 || math.Abs(outside-2) > 0.2 {
+		t.Errorf("rate %.2f during bursts and %.2f between them, want 10 and 2", inside, outside)
+	}
+}
+
+func TestLoadTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.csv")
+	content := "# time,shard\n0.5,1\n\n1.5\n2,7\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trace, err := LoadTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTimes, wantShards := []float64{0.5, 1.5, 2}, []int{1, -1, 7}
+	for i := range wantTimes {
+		got, shard := trace.Next()
+		if got != wantTimes[i] || shard != wantShards[i] {
+			t.Errorf("arrival %d at %v in shard %d, want %v in shard %d", i, got, shard, wantTimes[i], wantShards[i])
+		}
+	}
+	if got, _ := trace.Next(); !math.IsInf(got, 1) {
+		t.Errorf("arrival at %v past the end of the trace", got)
+	}
+
+	if err := os.WriteFile(path, []byte("3\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrace(path); err == nil {
+		t.Error("unsorted trace loaded without error")
+	}
+}
+
+func TestCrossShardTargets(t *testing.T) {
+	cfg := &config.Config{NumShards: 4, CrossShardRatio: 1, MaxShardsPerTx: 3}
+	g := NewGenerator(&ConstantArrivals{Rate: 10}, cfg)
+	txs := g.Until(100)
+	if len(txs) != 1000 {
+		t.Fatalf("%d transactions in 100 time units at rate 10", len(txs))
+	}
+	for _, tx := range txs {
+		if len(tx.TargetShards) < 1 || len(tx.TargetShards) > cfg.MaxShardsPerTx-1 {
+			t.Fatalf("transaction %d credits %d shards, want 1 to %d", tx.ID, len(tx.TargetShards), cfg.MaxShardsPerTx-1)
+		}
+		seen := map[int]bool{tx.ShardID: true}
+		for _, target := range tx.TargetShards {
+			if seen[target] || target < 0 || target >= cfg.NumShards {
+				t.Fatalf("transaction %d from shard %d has targets %v", tx.ID, tx.ShardID, tx.TargetShards)
+			}
+			seen[target] = true
+		}
+	}
+
+	// A single shard leaves nothing to cross to
+	single := NewGenerator(&ConstantArrivals{Rate: 10}, &config.Config{NumShards: 1, CrossShardRatio: 1, MaxShardsPerTx: 3})
+	for _, tx := range single.Until(10) {
+		if tx.TargetShards != nil {
+			t.Fatalf("transaction %d targets %v with one shard", tx.ID, tx.TargetShards)
+		}
+	}
+}